@@ -2,13 +2,17 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
 	"log"
+	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/hussainr95/link-in-bio-service/config"
 	httphandlers "github.com/hussainr95/link-in-bio-service/internal/delivery/http"
+	"github.com/hussainr95/link-in-bio-service/internal/geoip"
+	"github.com/hussainr95/link-in-bio-service/internal/pubsub"
 	"github.com/hussainr95/link-in-bio-service/internal/repository"
 	"github.com/hussainr95/link-in-bio-service/internal/usecase"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -28,57 +32,127 @@ import (
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
-// @description "Enter your bearer token in the format: Bearer test"
+// @description "Enter your API key in the format: Bearer lib_..."
+//
+// @securityDefinitions.apikey AdminAuth
+// @in header
+// @name Authorization
+// @description "Enter the admin token in the format: Bearer <ADMIN_TOKEN>"
 
 func main() {
 	// 1. Load configuration.
 	cfg := config.NewConfig()
 
-	// 2. Connect to MongoDB.
-	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(cfg.MongoURI))
-	if err != nil {
-		log.Fatal("Failed to connect to DB:", err)
-	}
-	defer client.Disconnect(context.Background())
+	// 2. Open storage and setup repositories.
+	repos, closeStorage := openRepositories(cfg)
+	defer closeStorage()
 
-	// Ping DB to verify the connection.
-	if err := client.Ping(context.Background(), nil); err != nil {
-		log.Fatal("Could not ping DB:", err)
+	// 3. Setup usecases. Visits are published to live subscribers in-process.
+	broker := pubsub.NewBroker()
+	var webhookOptions []usecase.WebhookOption
+	if cfg.WebhookAllowPrivate {
+		webhookOptions = append(webhookOptions, usecase.WithWebhookClient(&http.Client{Timeout: 10 * time.Second}))
 	}
-
-	db := client.Database(cfg.MongoDBName)
-
-	// 3. Setup repositories.
-	linkRepo := repository.NewMongoLinkRepository(db)
-	visitRepo := repository.NewMongoVisitRepository(db)
-
-	// 4. Setup usecase with both repositories.
-	linkUsecase := usecase.NewLinkUsecase(linkRepo, visitRepo)
-
-	// 5. Setup Gin router.
+	webhookUsecase := usecase.NewWebhookUsecase(repos.Webhooks, repos.Deliveries, webhookOptions...)
+	linkOptions := []usecase.LinkOption{
+		usecase.WithLinkLifetime(cfg.DefaultLinkTTL, cfg.MaxLinkTTL),
+		usecase.WithArchivePurge(cfg.ArchivePurgeAfter),
+		usecase.WithTrashRetention(cfg.TrashRetention),
+		usecase.WithVisitorSalt(visitorSalt(cfg.VisitorHashSalt)),
+		usecase.WithVisitorSketches(repos.Sketches),
+		usecase.WithClickDedup(repos.ClickWindows, cfg.ClickDedupWindow),
+		usecase.WithClickAnomalies(repos.ClickAnomalies),
+		usecase.WithVisitRollups(repos.VisitRollups),
+		usecase.WithVisitEvents(broker),
+		usecase.WithLinkEvents(webhookUsecase),
+	}
+	if cfg.GeoIPDBPath != "" {
+		geo, err := geoip.Open(cfg.GeoIPDBPath)
+		if err != nil {
+			log.Fatal("Could not open GeoIP database:", err)
+		}
+		defer geo.Close()
+		linkOptions = append(linkOptions, usecase.WithGeoIP(geo))
+	}
+	linkUsecase := usecase.NewLinkUsecase(repos.Links, repos.Visits, linkOptions...)
+	apiKeyUsecase := usecase.NewAPIKeyUsecase(repos.APIKeys, repos.Users)
+	userUsecase := usecase.NewUserUsecase(repos.Users)
+	profileUsecase := usecase.NewProfileUsecase(repos.Profiles, repos.Links)
+	statsUsecase := usecase.NewStatsUsecase(repos.Links, repos.Visits, repos.Profiles, repos.Sketches, repos.ClickAnomalies, repos.VisitRollups,
+		usecase.WithVisitRetention(cfg.VisitRetention))
+	exportUsecase := usecase.NewExportUsecase(repos.Links, repos.Visits, repos.Profiles)
+	liveUsecase := usecase.NewLiveUsecase(repos.Links, repos.Profiles, broker)
+
+	// 4. Setup Gin router.
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.Default()
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
 
 	// Bonus step. Register Swagger route
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	// 6. Apply authentication middleware globally.
-	// All endpoints (except maybe /visit) can be protected. Adjust as needed.
-	router.Use(httphandlers.AuthMiddleware())
+	// 5. Register public bio page and redirect routes; these need no authentication.
+	profileHandler := httphandlers.NewProfileHandler(profileUsecase)
+	profileHandler.RegisterPublicRoutes(router)
+	redirectHandler := httphandlers.NewRedirectHandler(linkUsecase, cfg.RedirectStatus)
+	redirectHandler.RegisterPublicRoutes(router)
 
-	// 7. Register link routes.
+	// 6. Register admin routes, guarded by the static admin token.
+	if cfg.AdminToken == "" {
+		log.Println("ADMIN_TOKEN is not set; admin endpoints are disabled.")
+	}
+	admin := router.Group("/admin", httphandlers.AdminMiddleware(cfg.AdminToken))
+	apiKeyHandler := httphandlers.NewAPIKeyHandler(apiKeyUsecase)
+	apiKeyHandler.RegisterAdminRoutes(admin)
+	userHandler := httphandlers.NewUserHandler(userUsecase)
+	userHandler.RegisterAdminRoutes(admin)
+
+	// 7. Register link, profile, stats, export, live and webhook routes behind API key authentication.
+	api := router.Group("/", httphandlers.AuthMiddleware(apiKeyUsecase))
 	linkHandler := httphandlers.NewLinkHandler(linkUsecase)
-	linkHandler.RegisterAPIRoutes(router)
-
-	// 8. Start background cleanup goroutine.
+	linkHandler.RegisterAPIRoutes(api)
+	profileHandler.RegisterAPIRoutes(api)
+	statsHandler := httphandlers.NewStatsHandler(statsUsecase)
+	statsHandler.RegisterAPIRoutes(api)
+	exportHandler := httphandlers.NewExportHandler(exportUsecase)
+	exportHandler.RegisterAPIRoutes(api)
+	liveHandler := httphandlers.NewLiveHandler(liveUsecase)
+	liveHandler.RegisterAPIRoutes(api)
+	webhookHandler := httphandlers.NewWebhookHandler(webhookUsecase)
+	webhookHandler.RegisterAPIRoutes(api)
+
+	// 8. Start background cleanup, click anomaly detection and visit rollups.
 	go func() {
 		ticker := time.NewTicker(1 * time.Minute)
 		defer ticker.Stop()
 		for range ticker.C {
 			if err := linkUsecase.CleanupExpiredLinks(context.Background()); err != nil {
-				log.Println("Error cleaning expired links:", err)
+				log.Println("Error archiving expired links:", err)
 			} else {
-				log.Println("Expired links cleaned successfully.")
+				log.Println("Expired links archived successfully.")
+			}
+			if err := linkUsecase.PurgeDeletedLinks(context.Background()); err != nil {
+				log.Println("Error purging deleted links:", err)
+			}
+			if err := statsUsecase.DetectClickAnomalies(context.Background()); err != nil {
+				log.Println("Error detecting click anomalies:", err)
+			}
+			if err := statsUsecase.RollupVisits(context.Background()); err != nil {
+				log.Println("Error rolling up visits:", err)
+			}
+		}
+	}()
+
+	// Webhook retries back off from a minute, so deliveries are checked more
+	// often than the cleanup runs.
+	go func() {
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := webhookUsecase.DeliverDue(context.Background()); err != nil {
+				log.Println("Error delivering webhooks:", err)
 			}
 		}
 	}()
@@ -90,3 +164,68 @@ func main() {
 		log.Fatal("Failed to run server:", err)
 	}
 }
+
+// openRepositories returns the repositories of the configured storage backend
+// and a function that releases it.
+func openRepositories(cfg *config.Config) (*repository.Repositories, func()) {
+	if cfg.StorageBackend == config.StorageMemory {
+		log.Println("Using in-memory storage; all data is lost when the server stops.")
+		return repository.NewMemoryRepositories(), func() {}
+	}
+	if cfg.StorageBackend == config.StorageSQLite || cfg.StorageBackend == config.StoragePostgres {
+		return openSQLRepositories(cfg)
+	}
+	if cfg.StorageBackend == config.StorageBolt {
+		db, err := repository.OpenBolt(cfg.DatabaseURL)
+		if err != nil {
+			log.Fatal("Could not open data file:", err)
+		}
+		return repository.NewBoltRepositories(db), func() { db.Close() }
+	}
+
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(cfg.MongoURI))
+	if err != nil {
+		log.Fatal("Failed to connect to DB:", err)
+	}
+
+	// Ping DB to verify the connection.
+	if err := client.Ping(context.Background(), nil); err != nil {
+		log.Fatal("Could not ping DB:", err)
+	}
+
+	db := client.Database(cfg.MongoDBName)
+	if err := repository.EnsureMongoIndexes(context.Background(), db); err != nil {
+		log.Fatal("Could not create DB indexes:", err)
+	}
+	return repository.NewMongoRepositories(db), func() { client.Disconnect(context.Background()) }
+}
+
+// openSQLRepositories connects to the SQL database and brings its schema up
+// to date.
+func openSQLRepositories(cfg *config.Config) (*repository.Repositories, func()) {
+	dialect := repository.DialectSQLite
+	if cfg.StorageBackend == config.StoragePostgres {
+		dialect = repository.DialectPostgres
+	}
+	db, err := repository.OpenSQL(context.Background(), dialect, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal("Failed to connect to DB:", err)
+	}
+	if err := repository.MigrateSQL(context.Background(), db); err != nil {
+		log.Fatal("Could not migrate DB:", err)
+	}
+	return repository.NewSQLRepositories(db), func() { db.Close() }
+}
+
+// visitorSalt returns the configured salt, or a random one when none is set.
+func visitorSalt(configured string) []byte {
+	if configured != "" {
+		return []byte(configured)
+	}
+	log.Println("VISITOR_HASH_SALT is not set; using a random salt, so visitors are not recognised across restarts.")
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		log.Fatal("Could not generate visitor salt:", err)
+	}
+	return salt
+}
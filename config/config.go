@@ -2,15 +2,69 @@ package config
 
 import (
 	"log"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// Storage backends accepted by STORAGE_BACKEND.
+const (
+	StorageMongo    = "mongo"
+	StorageMemory   = "memory"
+	StorageSQLite   = "sqlite"
+	StoragePostgres = "postgres"
+	StorageBolt     = "bolt"
+)
+
 type Config struct {
-	Port        string
+	Port string
+	// StorageBackend is where data is kept: StorageMongo, StorageSQLite,
+	// StoragePostgres, StorageBolt for an embedded data file, or
+	// StorageMemory for local runs without a database, losing everything on
+	// exit.
+	StorageBackend string
+	// DatabaseURL is the data source of the SQL and bbolt backends: a file
+	// path for SQLite and bbolt or a connection URL for PostgreSQL.
+	DatabaseURL string
 	MongoURI    string
 	MongoDBName string
+	AdminToken  string
+	// RedirectStatus is the HTTP status used by the /r/:code redirect; one of
+	// 301, 302 or 307.
+	RedirectStatus int
+	// DefaultLinkTTL is the lifetime of links created without an expiry;
+	// zero means they never expire.
+	DefaultLinkTTL time.Duration
+	// MaxLinkTTL caps the lifetime a client may request; zero means no cap.
+	MaxLinkTTL time.Duration
+	// ArchivePurgeAfter is how long expired links stay archived before they
+	// and their visits are deleted; zero keeps them forever.
+	ArchivePurgeAfter time.Duration
+	// TrashRetention is how long deleted links can be restored before they
+	// and their visits are deleted permanently; zero keeps them forever.
+	TrashRetention time.Duration
+	// GeoIPDBPath points at a MaxMind-format country database used to
+	// resolve visitor countries; visits have no country when it is unset.
+	GeoIPDBPath string
+	// VisitorHashSalt is the secret used to hash visitor IP addresses.
+	VisitorHashSalt string
+	// TrustedProxies lists the proxy addresses or CIDRs whose
+	// X-Forwarded-For header is believed; nil trusts none.
+	TrustedProxies []string
+	// ClickDedupWindow is how long after a counted click further clicks by
+	// the same visitor on the same link are recorded as repeats; zero counts
+	// every click.
+	ClickDedupWindow time.Duration
+	// VisitRetention is how long raw visits are kept before stats are
+	// served from hourly and daily rollups only; zero keeps them forever.
+	VisitRetention time.Duration
+	// WebhookAllowPrivate lets webhooks target loopback and private network
+	// addresses, e.g. for self-hosted setups; by default they are refused.
+	WebhookAllowPrivate bool
 }
 
 func NewConfig() *Config {
@@ -25,9 +79,92 @@ func NewConfig() *Config {
 		port = "8080"
 	}
 
+	backend := storageBackend(os.Getenv("STORAGE_BACKEND"))
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		switch backend {
+		case StorageSQLite:
+			databaseURL = "link-in-bio.db"
+		case StorageBolt:
+			databaseURL = "link-in-bio.bolt"
+		}
+	}
+
 	return &Config{
-		Port:        port,
-		MongoURI:    os.Getenv("MONGO_URI"),
-		MongoDBName: os.Getenv("MONGO_DB"),
+		Port:                port,
+		StorageBackend:      backend,
+		DatabaseURL:         databaseURL,
+		MongoURI:            os.Getenv("MONGO_URI"),
+		MongoDBName:         os.Getenv("MONGO_DB"),
+		AdminToken:          os.Getenv("ADMIN_TOKEN"),
+		RedirectStatus:      redirectStatus(os.Getenv("REDIRECT_STATUS")),
+		DefaultLinkTTL:      durationEnv("LINK_DEFAULT_TTL", 0),
+		MaxLinkTTL:          durationEnv("LINK_MAX_TTL", 0),
+		ArchivePurgeAfter:   durationEnv("ARCHIVE_PURGE_AFTER", 0),
+		TrashRetention:      durationEnv("TRASH_RETENTION", 30*24*time.Hour),
+		GeoIPDBPath:         os.Getenv("GEOIP_DB_PATH"),
+		VisitorHashSalt:     os.Getenv("VISITOR_HASH_SALT"),
+		TrustedProxies:      listEnv("TRUSTED_PROXIES"),
+		ClickDedupWindow:    durationEnv("CLICK_DEDUP_WINDOW", 30*time.Second),
+		VisitRetention:      durationEnv("VISIT_RETENTION", 0),
+		WebhookAllowPrivate: boolEnv("WEBHOOK_ALLOW_PRIVATE"),
+	}
+}
+
+// durationEnv reads a Go duration such as "720h" from the environment.
+func durationEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Printf("Invalid %s %q; falling back to %s.", name, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+// boolEnv reads a boolean such as "true" or "1" from the environment;
+// anything unparsable counts as false.
+func boolEnv(name string) bool {
+	value, err := strconv.ParseBool(os.Getenv(name))
+	if err != nil && os.Getenv(name) != "" {
+		log.Printf("Invalid %s %q; falling back to false.", name, os.Getenv(name))
+	}
+	return err == nil && value
+}
+
+// listEnv reads a comma-separated list from the environment.
+func listEnv(name string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func storageBackend(value string) string {
+	switch value {
+	case "":
+		return StorageMongo
+	case StorageMongo, StorageMemory, StorageSQLite, StoragePostgres, StorageBolt:
+		return value
+	}
+	log.Printf("Unsupported STORAGE_BACKEND %q; falling back to %s.", value, StorageMongo)
+	return StorageMongo
+}
+
+func redirectStatus(value string) int {
+	if value == "" {
+		return http.StatusFound
+	}
+	status, err := strconv.Atoi(value)
+	if err == nil && (status == http.StatusMovedPermanently || status == http.StatusFound || status == http.StatusTemporaryRedirect) {
+		return status
 	}
+	log.Printf("Unsupported REDIRECT_STATUS %q; falling back to %d.", value, http.StatusFound)
+	return http.StatusFound
 }
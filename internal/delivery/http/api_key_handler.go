@@ -0,0 +1,108 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
+)
+
+type APIKeyHandler struct {
+	usecase usecase.APIKeyUsecase
+}
+
+func NewAPIKeyHandler(u usecase.APIKeyUsecase) *APIKeyHandler {
+	return &APIKeyHandler{usecase: u}
+}
+
+type createAPIKeyRequest struct {
+	UserID string `json:"userId" binding:"required"`
+	Name   string `json:"name" binding:"required"`
+}
+
+// RegisterAdminRoutes sets up the routing for API key administration
+func (h *APIKeyHandler) RegisterAdminRoutes(router gin.IRouter) {
+	router.POST("/api-keys", h.CreateKey)
+	router.GET("/api-keys", h.ListKeys)
+	router.DELETE("/api-keys/:id", h.RevokeKey)
+}
+
+// CreateKey handles POST /admin/api-keys
+// CreateKey godoc
+// @Summary Mint a new API key
+// @Description Create a named API key for a user. The plaintext key is only returned in this response.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param key body createAPIKeyRequest true "API Key Data"
+// @Success 201 {object} entity.APIKey
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security AdminAuth
+// @Router /admin/api-keys [post]
+func (h *APIKeyHandler) CreateKey(c *gin.Context) {
+	var req createAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, err := h.usecase.CreateKey(c.Request.Context(), req.UserID, req.Name)
+	if errors.Is(err, usecase.ErrAPIKeyName) || errors.Is(err, usecase.ErrUserNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// ListKeys handles GET /admin/api-keys
+// ListKeys godoc
+// @Summary List API keys
+// @Description List all API keys, including revoked ones. Secrets are never returned.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} entity.APIKey
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security AdminAuth
+// @Router /admin/api-keys [get]
+func (h *APIKeyHandler) ListKeys(c *gin.Context) {
+	keys, err := h.usecase.ListKeys(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// RevokeKey handles DELETE /admin/api-keys/:id
+// RevokeKey godoc
+// @Summary Revoke an API key
+// @Description Revoke the specified API key. Revoked keys are kept for auditing.
+// @Tags admin
+// @Produce json
+// @Param id path string true "API Key ID"
+// @Success 200 {object} map[string]string "API key revoked successfully"
+// @Failure 404 {object} map[string]string "API key not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security AdminAuth
+// @Router /admin/api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+	id := c.Param("id")
+	err := h.usecase.RevokeKey(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
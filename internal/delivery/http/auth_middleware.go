@@ -1,18 +1,81 @@
 package http
 
 import (
-	"github.com/gin-gonic/gin"
+	"crypto/subtle"
+	"errors"
 	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
 )
 
-// AuthMiddleware validates that a valid Authorization header is present.
-func AuthMiddleware() gin.HandlerFunc {
+// principalKey is the gin context key under which the authenticated API key is stored.
+const principalKey = "principal"
+
+// AuthMiddleware resolves the bearer API key in the Authorization header and
+// stores the matching key on the gin context for downstream handlers.
+func AuthMiddleware(keys usecase.APIKeyUsecase) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader != "Bearer test" {
+		token, ok := bearerToken(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+
+		key, err := keys.Authenticate(c.Request.Context(), token)
+		if errors.Is(err, usecase.ErrInvalidAPIKey) {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			return
 		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set(principalKey, key)
 		c.Next()
 	}
 }
+
+// AdminMiddleware guards administrative endpoints with a static bearer token
+// taken from configuration. An empty token disables the endpoints entirely.
+func AdminMiddleware(adminToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c)
+		if !ok || adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Principal returns the API key that authenticated the current request, or
+// nil when the request did not pass through AuthMiddleware.
+func Principal(c *gin.Context) *entity.APIKey {
+	if v, ok := c.Get(principalKey); ok {
+		if key, ok := v.(*entity.APIKey); ok {
+			return key
+		}
+	}
+	return nil
+}
+
+// currentUserID returns the ID of the user behind the authenticated API key.
+func currentUserID(c *gin.Context) string {
+	if key := Principal(c); key != nil {
+		return key.UserID
+	}
+	return ""
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	const scheme = "Bearer "
+	header := c.GetHeader("Authorization")
+	if len(header) <= len(scheme) || !strings.EqualFold(header[:len(scheme)], scheme) {
+		return "", false
+	}
+	return strings.TrimSpace(header[len(scheme):]), true
+}
@@ -0,0 +1,192 @@
+package http
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
+)
+
+// exportFlushEvery is the number of records written between flushes, so
+// large exports reach the client as they are produced.
+const exportFlushEvery = 500
+
+// visitCSVHeader names the columns of a CSV visit export.
+var visitCSVHeader = []string{
+	"id", "linkId", "visitedAt", "referrer", "referrerHost", "browser", "os", "device",
+	"language", "country", "visitorHash", "bot", "botName", "repeat",
+}
+
+// ExportHandler streams raw visit data to link owners.
+type ExportHandler struct {
+	usecase usecase.ExportUsecase
+}
+
+func NewExportHandler(u usecase.ExportUsecase) *ExportHandler {
+	return &ExportHandler{usecase: u}
+}
+
+// RegisterAPIRoutes sets up the routing for the authenticated export endpoints
+func (h *ExportHandler) RegisterAPIRoutes(router gin.IRouter) {
+	router.GET("/links/:id/visits/export", h.ExportLinkVisits)
+	router.GET("/profile/visits/export", h.ExportProfileVisits)
+}
+
+// ExportLinkVisits handles GET /links/:id/visits/export
+// ExportLinkVisits godoc
+// @Summary Export a link's visits
+// @Description Stream every visit of the link in the range as CSV or JSON lines, oldest first. Bot hits and repeat clicks are included and flagged. Defaults to the last 30 days.
+// @Tags stats
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param id path string true "Link ID or slug"
+// @Param format query string false "csv (default) or jsonl"
+// @Param from query string false "Start of the range: RFC 3339 time or YYYY-MM-DD"
+// @Param to query string false "End of the range (exclusive; a YYYY-MM-DD date includes that day), default now"
+// @Param tz query string false "IANA timezone for dates and visit times, e.g. Europe/Berlin (default UTC)"
+// @Success 200 {file} file "Visit records"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Link not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security BearerAuth
+// @Router /links/{id}/visits/export [get]
+func (h *ExportHandler) ExportLinkVisits(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+	export, err := h.usecase.ExportLinkVisits(c.Request.Context(), currentUserID(c), c.Param("id"), statsQuery(c))
+	if err != nil {
+		respondStatsError(c, err)
+		return
+	}
+	streamVisits(c, export, format)
+}
+
+// ExportProfileVisits handles GET /profile/visits/export
+// ExportProfileVisits godoc
+// @Summary Export the visits of the caller's profile
+// @Description Stream every visit of the links shown on the caller's profile as CSV or JSON lines, oldest first. Accepts the same parameters as the link export.
+// @Tags stats
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "csv (default) or jsonl"
+// @Param from query string false "Start of the range: RFC 3339 time or YYYY-MM-DD"
+// @Param to query string false "End of the range (exclusive; a YYYY-MM-DD date includes that day), default now"
+// @Param tz query string false "IANA timezone for dates and visit times, e.g. Europe/Berlin (default UTC)"
+// @Success 200 {file} file "Visit records"
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Profile not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security BearerAuth
+// @Router /profile/visits/export [get]
+func (h *ExportHandler) ExportProfileVisits(c *gin.Context) {
+	format, ok := exportFormat(c)
+	if !ok {
+		return
+	}
+	export, err := h.usecase.ExportProfileVisits(c.Request.Context(), currentUserID(c), statsQuery(c))
+	if err != nil {
+		respondStatsError(c, err)
+		return
+	}
+	streamVisits(c, export, format)
+}
+
+// exportFormat reads the format query parameter, responding with 400 when
+// it is not supported.
+func exportFormat(c *gin.Context) (string, bool) {
+	switch format := c.DefaultQuery("format", "csv"); format {
+	case "csv", "jsonl":
+		return format, true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or jsonl"})
+		return "", false
+	}
+}
+
+// streamVisits writes the export as an attachment in the given format. The
+// status is sent before the first record, so an error while streaming can
+// only cut the response short; it is recorded on the context.
+func streamVisits(c *gin.Context, export *usecase.VisitExport, format string) {
+	filename := fmt.Sprintf("%s-visits-%s-%s.%s", export.Name,
+		export.From.Format(time.DateOnly), export.To.Format(time.DateOnly), format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("Cache-Control", "no-store")
+
+	var write func(*entity.Visit) error
+	var flush func() error
+	switch format {
+	case "jsonl":
+		c.Header("Content-Type", "application/x-ndjson")
+		enc := json.NewEncoder(c.Writer)
+		write = func(visit *entity.Visit) error { return enc.Encode(visit) }
+		flush = func() error { return nil }
+	default:
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		w := csv.NewWriter(c.Writer)
+		write = func(visit *entity.Visit) error { return w.Write(visitCSVRecord(visit)) }
+		flush = func() error { w.Flush(); return w.Error() }
+		_ = w.Write(visitCSVHeader)
+	}
+	c.Status(http.StatusOK)
+
+	written := 0
+	err := export.Each(c.Request.Context(), func(visit *entity.Visit) error {
+		if err := write(visit); err != nil {
+			return err
+		}
+		if written++; written%exportFlushEvery == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		_ = c.Error(err)
+	}
+}
+
+func visitCSVRecord(visit *entity.Visit) []string {
+	record := []string{
+		visit.ID,
+		visit.LinkID,
+		visit.VisitedAt.Format(time.RFC3339),
+		visit.Referrer,
+		visit.ReferrerHost,
+		visit.Browser,
+		visit.OS,
+		visit.Device,
+		visit.Language,
+		visit.Country,
+		visit.VisitorHash,
+		strconv.FormatBool(visit.Bot),
+		visit.BotName,
+		strconv.FormatBool(visit.Repeat),
+	}
+	for i, value := range record {
+		record[i] = csvSafe(value)
+	}
+	return record
+}
+
+// csvSafe stops spreadsheets from running client-supplied values, such as
+// the language, as formulas.
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
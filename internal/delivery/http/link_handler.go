@@ -1,7 +1,11 @@
 package http
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/hussainr95/link-in-bio-service/internal/entity"
@@ -17,24 +21,29 @@ func NewLinkHandler(u usecase.LinkUsecase) *LinkHandler {
 }
 
 // RegisterAPIRoutes sets up the routing for link-related endpoints
-func (h *LinkHandler) RegisterAPIRoutes(router *gin.Engine) {
+func (h *LinkHandler) RegisterAPIRoutes(router gin.IRouter) {
 	router.POST("/links", h.CreateLink)
+	router.GET("/links", h.ListLinks)
+	router.GET("/links/archived", h.ListArchivedLinks)
+	router.GET("/links/trash", h.ListDeletedLinks)
 	router.GET("/links/:id", h.GetLink)
 	router.PUT("/links/:id", h.UpdateLink)
 	router.DELETE("/links/:id", h.DeleteLink)
+	router.POST("/links/:id/restore", h.RestoreLink)
 	router.GET("/visit/:id", h.VisitLink)
 }
 
 // CreateLink handles POST /links
 // CreateLink godoc
 // @Summary Create a new link
-// @Description Create a new link with title, URL, and expiry date.
+// @Description Create a new link with title, URL, and expiry date. A short slug is generated unless one is supplied.
 // @Tags links
 // @Accept json
 // @Produce json
 // @Param link body entity.Link true "Link Data"
 // @Success 201 {object} entity.Link
 // @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 409 {object} map[string]string "Slug already taken"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Security BearerAuth
 // @Router /links [post]
@@ -44,33 +53,114 @@ func (h *LinkHandler) CreateLink(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	link.OwnerID = currentUserID(c)
 
 	createdLink, err := h.usecase.CreateLink(c.Request.Context(), &link)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondLinkError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, createdLink)
 }
 
+// ListLinks handles GET /links
+// ListLinks godoc
+// @Summary List links
+// @Description List the caller's links one page at a time. Links in the trash are left out unless asked for by status. Pass the returned nextCursor as cursor to fetch the following page.
+// @Tags links
+// @Produce json
+// @Param status query string false "Comma-separated statuses: scheduled, active, expired, archived, deleted"
+// @Param createdFrom query string false "Only links created at or after this RFC 3339 time"
+// @Param createdTo query string false "Only links created before this RFC 3339 time"
+// @Param tag query string false "Only links with this tag"
+// @Param host query string false "Only links pointing at this host, e.g. example.com"
+// @Param title query string false "Only links whose title contains this text, ignoring case"
+// @Param sort query string false "createdAt, clicks or expiresAt; prefix with '-' for descending (default -createdAt)"
+// @Param cursor query string false "nextCursor from the previous page"
+// @Param limit query int false "Page size, 1-100 (default 20)"
+// @Success 200 {object} entity.LinkPage
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security BearerAuth
+// @Router /links [get]
+func (h *LinkHandler) ListLinks(c *gin.Context) {
+	query, err := parseLinkQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page, err := h.usecase.ListLinks(c.Request.Context(), currentUserID(c), query)
+	if err != nil {
+		respondLinkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// parseLinkQuery reads the ListLinks query string. Values are validated
+// further by the usecase.
+func parseLinkQuery(c *gin.Context) (usecase.LinkQuery, error) {
+	query := usecase.LinkQuery{
+		Tag:    c.Query("tag"),
+		Host:   c.Query("host"),
+		Title:  c.Query("title"),
+		Sort:   c.Query("sort"),
+		Cursor: c.Query("cursor"),
+	}
+	for _, value := range c.QueryArray("status") {
+		for _, status := range strings.Split(value, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				query.Statuses = append(query.Statuses, entity.LinkStatus(status))
+			}
+		}
+	}
+	var err error
+	if query.CreatedFrom, err = queryTime(c, "createdFrom"); err != nil {
+		return query, err
+	}
+	if query.CreatedTo, err = queryTime(c, "createdTo"); err != nil {
+		return query, err
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			return query, errors.New("limit must be a number")
+		}
+	}
+	return query, nil
+}
+
+// queryTime parses an optional RFC 3339 query parameter.
+func queryTime(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errors.New(name + " must be an RFC 3339 time such as 2024-01-02T15:04:05Z")
+	}
+	return &t, nil
+}
+
 // GetLink handles GET /links/:id
 // GetLink godoc
 // @Summary Get a link by ID
-// @Description Retrieve a link using its ID.
+// @Description Retrieve a link using its ID or slug.
 // @Tags links
 // @Accept json
 // @Produce json
-// @Param id path string true "Link ID"
+// @Param id path string true "Link ID or slug"
 // @Success 200 {object} entity.Link
 // @Failure 404 {object} map[string]string "Link not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
 // @Security BearerAuth
 // @Router /links/{id} [get]
 func (h *LinkHandler) GetLink(c *gin.Context) {
 	id := c.Param("id")
-	link, err := h.usecase.GetLink(c.Request.Context(), id)
+	link, err := h.usecase.GetLink(c.Request.Context(), currentUserID(c), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
+		respondLinkError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, link)
@@ -79,14 +169,16 @@ func (h *LinkHandler) GetLink(c *gin.Context) {
 // UpdateLink handles PUT /links/:id
 // UpdateLink godoc
 // @Summary Update an existing link
-// @Description Update the link’s title, URL, or expiry date.
+// @Description Update the link’s title, URL, slug, or expiry date.
 // @Tags links
 // @Accept json
 // @Produce json
-// @Param id path string true "Link ID"
+// @Param id path string true "Link ID or slug"
 // @Param link body entity.Link true "Updated Link Data"
 // @Success 200 {object} entity.Link
 // @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Link not found"
+// @Failure 409 {object} map[string]string "Slug already taken"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Security BearerAuth
 // @Router /links/{id} [put]
@@ -99,9 +191,9 @@ func (h *LinkHandler) UpdateLink(c *gin.Context) {
 	}
 	link.ID = id // ensure ID matches the path param
 
-	updatedLink, err := h.usecase.UpdateLink(c.Request.Context(), &link)
+	updatedLink, err := h.usecase.UpdateLink(c.Request.Context(), currentUserID(c), &link)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondLinkError(c, err)
 		return
 	}
 
@@ -111,22 +203,82 @@ func (h *LinkHandler) UpdateLink(c *gin.Context) {
 // DeleteLink handles DELETE /links/:id
 // DeleteLink godoc
 // @Summary Delete a link by ID
-// @Description Delete the specified link.
+// @Description Move the specified link to the trash. It stops resolving immediately and can be restored until the trash retention runs out.
 // @Tags links
 // @Accept json
 // @Produce json
-// @Param id path string true "Link ID"
-// @Success 200 {object} map[string]string "Link deleted successfully"
+// @Param id path string true "Link ID or slug"
+// @Success 200 {object} map[string]string "Link moved to trash"
+// @Failure 404 {object} map[string]string "Link not found"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Security BearerAuth
 // @Router /links/{id} [delete]
 func (h *LinkHandler) DeleteLink(c *gin.Context) {
 	id := c.Param("id")
-	if err := h.usecase.DeleteLink(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.usecase.DeleteLink(c.Request.Context(), currentUserID(c), id); err != nil {
+		respondLinkError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Link deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Link moved to trash"})
+}
+
+// RestoreLink handles POST /links/:id/restore
+// RestoreLink godoc
+// @Summary Restore a deleted link
+// @Description Take a link out of the trash. Its slug, clicks and visit history are kept.
+// @Tags links
+// @Produce json
+// @Param id path string true "Link ID or slug"
+// @Success 200 {object} entity.Link
+// @Failure 404 {object} map[string]string "Link not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security BearerAuth
+// @Router /links/{id}/restore [post]
+func (h *LinkHandler) RestoreLink(c *gin.Context) {
+	link, err := h.usecase.RestoreLink(c.Request.Context(), currentUserID(c), c.Param("id"))
+	if err != nil {
+		respondLinkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, link)
+}
+
+// ListArchivedLinks handles GET /links/archived
+// ListArchivedLinks godoc
+// @Summary List archived links
+// @Description List the caller's expired links that have been archived, most recent first. Their click history is kept.
+// @Tags links
+// @Produce json
+// @Success 200 {array} entity.Link
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security BearerAuth
+// @Router /links/archived [get]
+func (h *LinkHandler) ListArchivedLinks(c *gin.Context) {
+	links, err := h.usecase.ListArchivedLinks(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		respondLinkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, links)
+}
+
+// ListDeletedLinks handles GET /links/trash
+// ListDeletedLinks godoc
+// @Summary List links in the trash
+// @Description List the caller's deleted links, most recently deleted first. They are purged permanently once the trash retention runs out.
+// @Tags links
+// @Produce json
+// @Success 200 {array} entity.Link
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security BearerAuth
+// @Router /links/trash [get]
+func (h *LinkHandler) ListDeletedLinks(c *gin.Context) {
+	links, err := h.usecase.ListDeletedLinks(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		respondLinkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, links)
 }
 
 // VisitLink handles GET /visit/:id
@@ -136,17 +288,35 @@ func (h *LinkHandler) DeleteLink(c *gin.Context) {
 // @Tags links
 // @Accept json
 // @Produce json
-// @Param id path string true "Link ID"
+// @Param id path string true "Link ID or slug"
 // @Success 200 {object} entity.Link
+// @Failure 404 {object} map[string]string "Link not found"
+// @Failure 410 {object} map[string]string "Link expired"
 // @Failure 500 {object} map[string]string "Internal Server Error"
 // @Security BearerAuth
 // @Router /visit/{id} [get]
 func (h *LinkHandler) VisitLink(c *gin.Context) {
 	id := c.Param("id")
-	link, err := h.usecase.VisitLink(c.Request.Context(), id)
+	link, err := h.usecase.VisitLink(c.Request.Context(), id, visitRequest(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondLinkError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, link)
 }
+
+// respondLinkError maps errors returned by LinkUsecase to JSON responses.
+func respondLinkError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrLinkNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Link not found"})
+	case errors.Is(err, usecase.ErrLinkExpired):
+		c.JSON(http.StatusGone, gin.H{"error": "Link expired"})
+	case errors.Is(err, usecase.ErrInvalidLink), errors.Is(err, usecase.ErrInvalidQuery):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, usecase.ErrSlugTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
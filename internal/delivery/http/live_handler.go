@@ -0,0 +1,103 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hussainr95/link-in-bio-service/internal/pubsub"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
+)
+
+// liveHeartbeat is how often an idle stream sends a comment, so proxies do
+// not close it.
+const liveHeartbeat = 15 * time.Second
+
+// LiveHandler streams visits to link owners as Server-Sent Events.
+type LiveHandler struct {
+	usecase usecase.LiveUsecase
+}
+
+func NewLiveHandler(u usecase.LiveUsecase) *LiveHandler {
+	return &LiveHandler{usecase: u}
+}
+
+// RegisterAPIRoutes sets up the routing for the authenticated live endpoints
+func (h *LiveHandler) RegisterAPIRoutes(router gin.IRouter) {
+	router.GET("/links/:id/live", h.WatchLink)
+	router.GET("/profile/live", h.WatchProfile)
+}
+
+// WatchLink handles GET /links/:id/live
+// WatchLink godoc
+// @Summary Watch a link's visits live
+// @Description Stream the link's visits as Server-Sent Events while the connection is open. Each "visit" event carries the visit and the link's click count after it; bot hits and repeat clicks are sent too, flagged. A comment is sent every 15 seconds to keep the connection open.
+// @Tags stats
+// @Produce text/event-stream
+// @Param id path string true "Link ID or slug"
+// @Success 200 {object} entity.VisitEvent "Stream of visit events"
+// @Failure 404 {object} map[string]string "Link not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security BearerAuth
+// @Router /links/{id}/live [get]
+func (h *LiveHandler) WatchLink(c *gin.Context) {
+	sub, err := h.usecase.WatchLink(c.Request.Context(), currentUserID(c), c.Param("id"))
+	if err != nil {
+		respondStatsError(c, err)
+		return
+	}
+	streamEvents(c, sub)
+}
+
+// WatchProfile handles GET /profile/live
+// WatchProfile godoc
+// @Summary Watch visits to the caller's profile live
+// @Description Stream visits to the links on the caller's profile as Server-Sent Events. Links added to the profile after connecting are not included until the client reconnects.
+// @Tags stats
+// @Produce text/event-stream
+// @Success 200 {object} entity.VisitEvent "Stream of visit events"
+// @Failure 404 {object} map[string]string "Profile not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security BearerAuth
+// @Router /profile/live [get]
+func (h *LiveHandler) WatchProfile(c *gin.Context) {
+	sub, err := h.usecase.WatchProfile(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		respondStatsError(c, err)
+		return
+	}
+	streamEvents(c, sub)
+}
+
+// streamEvents writes the subscription's events until the client goes away.
+func streamEvents(c *gin.Context, sub *pubsub.Subscription) {
+	defer sub.Close()
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Stop nginx from buffering the stream.
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	_, _ = io.WriteString(c.Writer, ": connected\n\n")
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(liveHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			c.SSEvent("visit", event)
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"embed"
+	"html/template"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var pageTemplates = template.Must(template.New("pages").Funcs(template.FuncMap{
+	"trackedURL": trackedURL,
+	// css marks a theme value as trusted; themes are built in, never user input.
+	"css": func(value string) template.CSS { return template.CSS(value) },
+}).ParseFS(templateFS, "templates/*.html"))
+
+// profilePage is the data passed to templates/profile.html.
+type profilePage struct {
+	Title   string
+	Theme   entity.Theme
+	Profile *entity.PublicProfile
+}
+
+// errorPage is the data passed to templates/error.html.
+type errorPage struct {
+	Title   string
+	Message string
+	Theme   entity.Theme
+}
+
+// trackedURL returns the address a bio page link should point at so that the
+// click is counted before the visitor reaches the destination.
+func trackedURL(link *entity.PublicLink) string {
+	if link.Slug != "" {
+		return "/r/" + link.Slug
+	}
+	return "/r/" + link.ID
+}
+
+func renderPage(c *gin.Context, status int, name string, data any) {
+	c.Render(status, render.HTML{Template: pageTemplates, Name: name, Data: data})
+}
+
+func renderErrorPage(c *gin.Context, status int, title, message string) {
+	theme, _ := entity.LookupTheme(entity.DefaultTheme)
+	renderPage(c, status, "error.html", errorPage{Title: title, Message: message, Theme: theme})
+}
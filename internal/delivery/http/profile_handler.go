@@ -0,0 +1,139 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
+)
+
+type ProfileHandler struct {
+	usecase usecase.ProfileUsecase
+}
+
+func NewProfileHandler(u usecase.ProfileUsecase) *ProfileHandler {
+	return &ProfileHandler{usecase: u}
+}
+
+// RegisterAPIRoutes sets up the routing for the authenticated profile endpoints
+func (h *ProfileHandler) RegisterAPIRoutes(router gin.IRouter) {
+	router.GET("/profile", h.GetProfile)
+	router.PUT("/profile", h.SaveProfile)
+}
+
+// RegisterPublicRoutes sets up the routing for the unauthenticated bio pages
+func (h *ProfileHandler) RegisterPublicRoutes(router gin.IRouter) {
+	router.GET("/p/:handle", h.GetPublicProfile)
+	router.GET("/@:handle", h.RenderProfilePage)
+}
+
+// GetProfile handles GET /profile
+// GetProfile godoc
+// @Summary Get the caller's profile
+// @Description Retrieve the bio page profile owned by the authenticated user.
+// @Tags profiles
+// @Produce json
+// @Success 200 {object} entity.Profile
+// @Failure 404 {object} map[string]string "Profile not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security BearerAuth
+// @Router /profile [get]
+func (h *ProfileHandler) GetProfile(c *gin.Context) {
+	profile, err := h.usecase.GetProfile(c.Request.Context(), currentUserID(c))
+	if errors.Is(err, usecase.ErrProfileNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+// SaveProfile handles PUT /profile
+// SaveProfile godoc
+// @Summary Create or update the caller's profile
+// @Description Set the handle, display name, avatar, bio and ordered link IDs of the caller's bio page.
+// @Tags profiles
+// @Accept json
+// @Produce json
+// @Param profile body entity.Profile true "Profile Data"
+// @Success 200 {object} entity.Profile
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 409 {object} map[string]string "Handle already taken"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security BearerAuth
+// @Router /profile [put]
+func (h *ProfileHandler) SaveProfile(c *gin.Context) {
+	var profile entity.Profile
+	if err := c.ShouldBindJSON(&profile); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	saved, err := h.usecase.SaveProfile(c.Request.Context(), currentUserID(c), &profile)
+	switch {
+	case errors.Is(err, usecase.ErrInvalidProfile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, usecase.ErrHandleTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, saved)
+}
+
+// GetPublicProfile handles GET /p/:handle
+// GetPublicProfile godoc
+// @Summary Get a public bio page
+// @Description Retrieve a profile by handle together with its active, non-expired links. No authentication required.
+// @Tags profiles
+// @Produce json
+// @Param handle path string true "Profile handle"
+// @Success 200 {object} entity.PublicProfile
+// @Failure 404 {object} map[string]string "Profile not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Router /p/{handle} [get]
+func (h *ProfileHandler) GetPublicProfile(c *gin.Context) {
+	profile, err := h.usecase.GetPublicProfile(c.Request.Context(), c.Param("handle"))
+	if errors.Is(err, usecase.ErrProfileNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+// RenderProfilePage handles GET /@:handle
+// It renders the same data as GetPublicProfile as a themed HTML page. The route
+// is left out of the Swagger spec because it serves browsers, not API clients.
+func (h *ProfileHandler) RenderProfilePage(c *gin.Context) {
+	profile, err := h.usecase.GetPublicProfile(c.Request.Context(), c.Param("handle"))
+	if errors.Is(err, usecase.ErrProfileNotFound) {
+		renderErrorPage(c, http.StatusNotFound, "Page not found", "There is no bio page with this handle.")
+		return
+	}
+	if err != nil {
+		renderErrorPage(c, http.StatusInternalServerError, "Something went wrong", "Please try again in a moment.")
+		return
+	}
+
+	theme, ok := entity.LookupTheme(profile.Theme)
+	if !ok {
+		theme, _ = entity.LookupTheme(entity.DefaultTheme)
+	}
+	title := profile.DisplayName
+	if title == "" {
+		title = "@" + profile.Handle
+	}
+	renderPage(c, http.StatusOK, "profile.html", profilePage{Title: title, Theme: theme, Profile: profile})
+}
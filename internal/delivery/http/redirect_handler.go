@@ -0,0 +1,79 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
+)
+
+// RedirectHandler serves the public short links that bio pages point at.
+type RedirectHandler struct {
+	usecase usecase.LinkUsecase
+	status  int
+}
+
+// NewRedirectHandler creates a handler that answers with the given redirect
+// status (301, 302 or 307).
+func NewRedirectHandler(u usecase.LinkUsecase, status int) *RedirectHandler {
+	return &RedirectHandler{usecase: u, status: status}
+}
+
+// RegisterPublicRoutes sets up the routing for the unauthenticated redirect endpoint
+func (h *RedirectHandler) RegisterPublicRoutes(router gin.IRouter) {
+	router.GET("/r/:code", h.Redirect)
+	// Link unfurlers often probe with HEAD; they get the redirect too, and
+	// their hits are recorded as bot hits.
+	router.HEAD("/r/:code", h.Redirect)
+}
+
+// Redirect handles GET and HEAD /r/:code
+// Redirect godoc
+// @Summary Follow a link
+// @Description Record the visit and redirect the browser to the link's target URL. No authentication required.
+// @Tags links
+// @Produce html
+// @Param code path string true "Link slug or ID"
+// @Success 302 {string} string "Redirect to the target URL"
+// @Failure 404 {string} string "HTML page"
+// @Failure 410 {string} string "HTML page"
+// @Failure 500 {string} string "HTML page"
+// @Router /r/{code} [get]
+// @Router /r/{code} [head]
+func (h *RedirectHandler) Redirect(c *gin.Context) {
+	link, err := h.usecase.VisitLink(c.Request.Context(), c.Param("code"), visitRequest(c))
+	switch {
+	case errors.Is(err, usecase.ErrLinkNotFound):
+		renderErrorPage(c, http.StatusNotFound, "Link not found", "This link does not exist. Check the address and try again.")
+		return
+	case errors.Is(err, usecase.ErrLinkExpired):
+		renderErrorPage(c, http.StatusGone, "Link expired", "This link is no longer available.")
+		return
+	case err != nil:
+		renderErrorPage(c, http.StatusInternalServerError, "Something went wrong", "Please try again in a moment.")
+		return
+	}
+
+	// Redirect responses must not be cached by browsers or proxies, otherwise
+	// repeat visits would bypass click tracking.
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(h.status, link.URL)
+}
+
+// visitRequest collects the client details recorded with a visit.
+func visitRequest(c *gin.Context) usecase.VisitRequest {
+	purpose := c.GetHeader("Sec-Purpose")
+	if purpose == "" {
+		purpose = c.GetHeader("Purpose")
+	}
+	return usecase.VisitRequest{
+		IP:             c.ClientIP(),
+		UserAgent:      c.Request.UserAgent(),
+		Referrer:       c.Request.Referer(),
+		AcceptLanguage: c.GetHeader("Accept-Language"),
+		Method:         c.Request.Method,
+		Accept:         c.GetHeader("Accept"),
+		Purpose:        purpose,
+	}
+}
@@ -0,0 +1,156 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
+)
+
+// StatsHandler serves click analytics to link owners.
+type StatsHandler struct {
+	usecase usecase.StatsUsecase
+}
+
+func NewStatsHandler(u usecase.StatsUsecase) *StatsHandler {
+	return &StatsHandler{usecase: u}
+}
+
+// RegisterAPIRoutes sets up the routing for the authenticated stats endpoints
+func (h *StatsHandler) RegisterAPIRoutes(router gin.IRouter) {
+	router.GET("/links/:id/stats", h.LinkStats)
+	router.GET("/links/:id/stats/breakdown", h.LinkBreakdown)
+	router.GET("/links/:id/stats/visitors", h.LinkVisitors)
+	router.GET("/profile/stats", h.ProfileStats)
+}
+
+// LinkStats handles GET /links/:id/stats
+// LinkStats godoc
+// @Summary Get a link's clicks over time
+// @Description Count the link's clicks in hourly, daily or weekly buckets. Buckets are aligned to the given timezone and empty ones are included. Defaults to the last 7 days by day in UTC.
+// @Tags stats
+// @Produce json
+// @Param id path string true "Link ID or slug"
+// @Param from query string false "Start of the range: RFC 3339 time or YYYY-MM-DD"
+// @Param to query string false "End of the range (exclusive; a YYYY-MM-DD date includes that day), default now"
+// @Param interval query string false "hour, day or week (default day)"
+// @Param tz query string false "IANA timezone, e.g. Europe/Berlin (default UTC)"
+// @Success 200 {object} entity.LinkStats
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Link not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security BearerAuth
+// @Router /links/{id}/stats [get]
+func (h *StatsHandler) LinkStats(c *gin.Context) {
+	stats, err := h.usecase.LinkStats(c.Request.Context(), currentUserID(c), c.Param("id"), statsQuery(c))
+	if err != nil {
+		respondStatsError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// LinkBreakdown handles GET /links/:id/stats/breakdown
+// LinkBreakdown godoc
+// @Summary Get the top sources of a link's clicks
+// @Description Group the link's clicks by referrer host, country, device, browser, OS or language and return the most common values with counts and percentages. Clicks without a referrer are reported as "direct", other missing values as "unknown". Defaults to the last 30 days.
+// @Tags stats
+// @Produce json
+// @Param id path string true "Link ID or slug"
+// @Param dimension query string true "referrer, country, device, browser, os or language"
+// @Param limit query int false "Number of values to return, 1-100 (default 10)"
+// @Param from query string false "Start of the range: RFC 3339 time or YYYY-MM-DD"
+// @Param to query string false "End of the range (exclusive; a YYYY-MM-DD date includes that day), default now"
+// @Param tz query string false "IANA timezone used for dates, e.g. Europe/Berlin (default UTC)"
+// @Success 200 {object} entity.Breakdown
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Link not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security BearerAuth
+// @Router /links/{id}/stats/breakdown [get]
+func (h *StatsHandler) LinkBreakdown(c *gin.Context) {
+	q := usecase.BreakdownQuery{StatsQuery: statsQuery(c), Dimension: c.Query("dimension")}
+	if limit := c.Query("limit"); limit != "" {
+		var err error
+		if q.Limit, err = strconv.Atoi(limit); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a number"})
+			return
+		}
+	}
+	breakdown, err := h.usecase.LinkBreakdown(c.Request.Context(), currentUserID(c), c.Param("id"), q)
+	if err != nil {
+		respondStatsError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, breakdown)
+}
+
+// LinkVisitors handles GET /links/:id/stats/visitors
+// LinkVisitors godoc
+// @Summary Get a link's unique visitors
+// @Description Estimate the distinct visitors of the link per UTC day and over the range, next to its raw clicks. Visitors are recognised by a salted hash of their IP address and browser that changes every day, so a person returning on another day counts again. Estimates are accurate to about 2%. Defaults to the last 7 days.
+// @Tags stats
+// @Produce json
+// @Param id path string true "Link ID or slug"
+// @Param from query string false "First day: RFC 3339 time or YYYY-MM-DD"
+// @Param to query string false "End of the range (exclusive; a YYYY-MM-DD date includes that day), default now"
+// @Success 200 {object} entity.VisitorStats
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Link not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security BearerAuth
+// @Router /links/{id}/stats/visitors [get]
+func (h *StatsHandler) LinkVisitors(c *gin.Context) {
+	stats, err := h.usecase.LinkVisitors(c.Request.Context(), currentUserID(c), c.Param("id"), statsQuery(c))
+	if err != nil {
+		respondStatsError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// ProfileStats handles GET /profile/stats
+// ProfileStats godoc
+// @Summary Get clicks on the caller's profile over time
+// @Description Count clicks on the links shown on the caller's profile, in total, per bucket and per link. Accepts the same range parameters as link stats.
+// @Tags stats
+// @Produce json
+// @Param from query string false "Start of the range: RFC 3339 time or YYYY-MM-DD"
+// @Param to query string false "End of the range (exclusive; a YYYY-MM-DD date includes that day), default now"
+// @Param interval query string false "hour, day or week (default day)"
+// @Param tz query string false "IANA timezone, e.g. Europe/Berlin (default UTC)"
+// @Success 200 {object} entity.ProfileStats
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 404 {object} map[string]string "Profile not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security BearerAuth
+// @Router /profile/stats [get]
+func (h *StatsHandler) ProfileStats(c *gin.Context) {
+	stats, err := h.usecase.ProfileStats(c.Request.Context(), currentUserID(c), statsQuery(c))
+	if err != nil {
+		respondStatsError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+func statsQuery(c *gin.Context) usecase.StatsQuery {
+	return usecase.StatsQuery{
+		From:     c.Query("from"),
+		To:       c.Query("to"),
+		Interval: c.Query("interval"),
+		Timezone: c.Query("tz"),
+	}
+}
+
+// respondStatsError maps errors returned by StatsUsecase to JSON responses.
+func respondStatsError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrProfileNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+	default:
+		respondLinkError(c, err)
+	}
+}
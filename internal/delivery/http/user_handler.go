@@ -0,0 +1,111 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
+)
+
+type UserHandler struct {
+	usecase usecase.UserUsecase
+}
+
+func NewUserHandler(u usecase.UserUsecase) *UserHandler {
+	return &UserHandler{usecase: u}
+}
+
+type createUserRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email" binding:"required,email"`
+}
+
+// RegisterAdminRoutes sets up the routing for user administration
+func (h *UserHandler) RegisterAdminRoutes(router gin.IRouter) {
+	router.POST("/users", h.CreateUser)
+	router.GET("/users", h.ListUsers)
+	router.GET("/users/:id", h.GetUser)
+}
+
+// CreateUser handles POST /admin/users
+// CreateUser godoc
+// @Summary Create a user
+// @Description Create an account that can own links and API keys.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param user body createUserRequest true "User Data"
+// @Success 201 {object} entity.User
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 409 {object} map[string]string "Email already in use"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security AdminAuth
+// @Router /admin/users [post]
+func (h *UserHandler) CreateUser(c *gin.Context) {
+	var req createUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.usecase.CreateUser(c.Request.Context(), &entity.User{Name: req.Name, Email: req.Email})
+	switch {
+	case errors.Is(err, usecase.ErrUserNameEmpty):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, usecase.ErrUserExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+// ListUsers handles GET /admin/users
+// ListUsers godoc
+// @Summary List users
+// @Description List all user accounts.
+// @Tags admin
+// @Produce json
+// @Success 200 {array} entity.User
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security AdminAuth
+// @Router /admin/users [get]
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	users, err := h.usecase.ListUsers(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+// GetUser handles GET /admin/users/:id
+// GetUser godoc
+// @Summary Get a user by ID
+// @Description Retrieve a user account using its ID.
+// @Tags admin
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} entity.User
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security AdminAuth
+// @Router /admin/users/{id} [get]
+func (h *UserHandler) GetUser(c *gin.Context) {
+	user, err := h.usecase.GetUser(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, usecase.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,156 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
+)
+
+type createWebhookRequest struct {
+	URL    string                `json:"url" binding:"required"`
+	Events []entity.WebhookEvent `json:"events"`
+}
+
+// WebhookHandler lets owners manage the webhooks notified of link events.
+type WebhookHandler struct {
+	usecase usecase.WebhookUsecase
+}
+
+func NewWebhookHandler(u usecase.WebhookUsecase) *WebhookHandler {
+	return &WebhookHandler{usecase: u}
+}
+
+// RegisterAPIRoutes sets up the routing for the authenticated webhook endpoints
+func (h *WebhookHandler) RegisterAPIRoutes(router gin.IRouter) {
+	router.POST("/webhooks", h.CreateWebhook)
+	router.GET("/webhooks", h.ListWebhooks)
+	router.DELETE("/webhooks/:id", h.DeleteWebhook)
+	router.GET("/webhooks/:id/deliveries", h.ListDeliveries)
+	router.POST("/webhooks/:id/deliveries/:deliveryId/redeliver", h.Redeliver)
+}
+
+// CreateWebhook handles POST /webhooks
+// CreateWebhook godoc
+// @Summary Register a webhook
+// @Description Register an endpoint to receive link.created, link.updated, link.deleted, link.expired and link.visited events as signed JSON. Omit events to receive all of them. The signing secret is only returned in this response.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body createWebhookRequest true "Endpoint URL and events"
+// @Success 201 {object} entity.Webhook
+// @Failure 400 {object} map[string]string "Bad Request"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security BearerAuth
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req createWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	webhook, err := h.usecase.CreateWebhook(c.Request.Context(), &entity.Webhook{OwnerID: currentUserID(c), URL: req.URL, Events: req.Events})
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// ListWebhooks handles GET /webhooks
+// ListWebhooks godoc
+// @Summary List webhooks
+// @Description List the caller's webhooks, newest first. Secrets are not included.
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} entity.Webhook
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security BearerAuth
+// @Router /webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.usecase.ListWebhooks(c.Request.Context(), currentUserID(c))
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// DeleteWebhook handles DELETE /webhooks/:id
+// DeleteWebhook godoc
+// @Summary Delete a webhook
+// @Description Delete a webhook together with its delivery log. Pending deliveries are dropped.
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} map[string]string "Webhook deleted"
+// @Failure 404 {object} map[string]string "Webhook not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security BearerAuth
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	if err := h.usecase.DeleteWebhook(c.Request.Context(), currentUserID(c), c.Param("id")); err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted"})
+}
+
+// ListDeliveries handles GET /webhooks/:id/deliveries
+// ListDeliveries godoc
+// @Summary List a webhook's deliveries
+// @Description Show the 50 most recent deliveries to the webhook with their payload, status, number of attempts, last response and next retry.
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {array} entity.WebhookDelivery
+// @Failure 404 {object} map[string]string "Webhook not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security BearerAuth
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	deliveries, err := h.usecase.ListDeliveries(c.Request.Context(), currentUserID(c), c.Param("id"))
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+// Redeliver handles POST /webhooks/:id/deliveries/:deliveryId/redeliver
+// Redeliver godoc
+// @Summary Redeliver a webhook event
+// @Description Queue a new delivery with the same payload as a past one, to be sent right away. The payload keeps its event ID, so receivers can recognise it.
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param deliveryId path string true "Delivery ID"
+// @Success 202 {object} entity.WebhookDelivery
+// @Failure 404 {object} map[string]string "Webhook or delivery not found"
+// @Failure 500 {object} map[string]string "Internal Server Error"
+// @Security BearerAuth
+// @Router /webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	delivery, err := h.usecase.Redeliver(c.Request.Context(), currentUserID(c), c.Param("id"), c.Param("deliveryId"))
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+// respondWebhookError maps errors returned by WebhookUsecase to JSON responses.
+func respondWebhookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrWebhookNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+	case errors.Is(err, usecase.ErrDeliveryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+	case errors.Is(err, usecase.ErrInvalidWebhook):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
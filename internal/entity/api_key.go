@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// APIKey represents a credential issued to an API client. Only a hash of the
+// secret is stored; the plaintext key is returned once, when it is created.
+type APIKey struct {
+	ID         string     `json:"id" bson:"_id,omitempty"`
+	UserID     string     `json:"userId" bson:"userId"`
+	Name       string     `json:"name" bson:"name"`
+	Prefix     string     `json:"prefix" bson:"prefix"`
+	KeyHash    string     `json:"-" bson:"keyHash"`
+	Key        string     `json:"key,omitempty" bson:"-"`
+	CreatedAt  time.Time  `json:"createdAt" bson:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty" bson:"lastUsedAt,omitempty"`
+	Revoked    bool       `json:"revoked" bson:"revoked"`
+}
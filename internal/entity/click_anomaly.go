@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+// ClickAnomaly flags a window in which a link got far more clicks than usual
+// from only a handful of visitors, a typical sign of click fraud.
+type ClickAnomaly struct {
+	ID          string    `json:"id" bson:"_id,omitempty"`
+	LinkID      string    `json:"linkId" bson:"linkId"`
+	WindowStart time.Time `json:"windowStart" bson:"windowStart"`
+	WindowEnd   time.Time `json:"windowEnd" bson:"windowEnd"`
+	// Clicks counts the clicks in the window and Sources the distinct
+	// visitors they came from; TopSourceClicks is the busiest visitor's share.
+	Clicks          int64 `json:"clicks" bson:"clicks"`
+	Sources         int64 `json:"sources" bson:"sources"`
+	TopSourceClicks int64 `json:"topSourceClicks" bson:"topSourceClicks"`
+	// Baseline is the link's average number of clicks per window over the
+	// preceding day.
+	Baseline   float64   `json:"baseline" bson:"baseline"`
+	DetectedAt time.Time `json:"detectedAt" bson:"detectedAt"`
+}
@@ -2,12 +2,67 @@ package entity
 
 import "time"
 
+// LinkStatus describes where a link is in its lifetime.
+type LinkStatus string
+
+const (
+	LinkScheduled LinkStatus = "scheduled"
+	LinkActive    LinkStatus = "active"
+	LinkExpired   LinkStatus = "expired"
+	LinkArchived  LinkStatus = "archived"
+	LinkDeleted   LinkStatus = "deleted"
+)
+
 // Link represents the data model for a bio link.
 type Link struct {
-	ID        string    `json:"id" bson:"_id,omitempty"`
-	Title     string    `json:"title" bson:"title"`
-	URL       string    `json:"url" bson:"url"`
-	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
-	ExpiresAt time.Time `json:"expiresAt" bson:"expiresAt"`
-	Clicks    int       `json:"clicks" bson:"clicks"`
+	ID         string     `json:"id" bson:"_id,omitempty"`
+	OwnerID    string     `json:"ownerId" bson:"ownerId"`
+	Slug       string     `json:"slug" bson:"slug,omitempty"`
+	Title      string     `json:"title" bson:"title"`
+	URL        string     `json:"url" bson:"url"`
+	CreatedAt  time.Time  `json:"createdAt" bson:"createdAt"`
+	ActiveFrom *time.Time `json:"activeFrom" bson:"activeFrom,omitempty"`                           // nil if active from creation
+	ExpiresAt  *time.Time `json:"expiresAt" bson:"expiresAt,omitempty"`                             // nil if the link never expires
+	ArchivedAt *time.Time `json:"archivedAt,omitempty" bson:"archivedAt,omitempty" readonly:"true"` // set once an expired link is archived
+	DeletedAt  *time.Time `json:"deletedAt,omitempty" bson:"deletedAt,omitempty" readonly:"true"`   // set while the link is in the trash
+	Tags       []string   `json:"tags,omitempty" bson:"tags,omitempty"`
+	Clicks     int        `json:"clicks" bson:"clicks"`
+	Status     LinkStatus `json:"status" bson:"-"` // computed on read
+
+	// ExpiresIn and NeverExpires are request-only alternatives to ExpiresAt.
+	// ExpiresIn is a duration such as "72h" or "30d".
+	ExpiresIn    string `json:"expiresIn,omitempty" bson:"-"`
+	NeverExpires bool   `json:"neverExpires,omitempty" bson:"-"`
+}
+
+// LinkPage is one page of a link listing. NextCursor is empty on the last page.
+type LinkPage struct {
+	Links      []*Link `json:"links"`
+	NextCursor string  `json:"nextCursor,omitempty"`
+}
+
+// IsExpired reports whether the link has expired at the given time.
+func (l *Link) IsExpired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// IsScheduled reports whether the link's activation time is still ahead.
+func (l *Link) IsScheduled(now time.Time) bool {
+	return l.ActiveFrom != nil && now.Before(*l.ActiveFrom)
+}
+
+// StatusAt computes the link's status at the given time.
+func (l *Link) StatusAt(now time.Time) LinkStatus {
+	switch {
+	case l.DeletedAt != nil:
+		return LinkDeleted
+	case l.ArchivedAt != nil:
+		return LinkArchived
+	case l.IsExpired(now):
+		return LinkExpired
+	case l.IsScheduled(now):
+		return LinkScheduled
+	default:
+		return LinkActive
+	}
 }
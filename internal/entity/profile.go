@@ -0,0 +1,37 @@
+package entity
+
+import "time"
+
+// Profile represents a user's public "link in bio" page. LinkIDs holds the
+// links shown on the page, in display order.
+type Profile struct {
+	ID          string    `json:"id" bson:"_id,omitempty"`
+	UserID      string    `json:"userId" bson:"userId"`
+	Handle      string    `json:"handle" bson:"handle"`
+	DisplayName string    `json:"displayName" bson:"displayName"`
+	AvatarURL   string    `json:"avatarUrl" bson:"avatarUrl"`
+	Bio         string    `json:"bio" bson:"bio"`
+	Theme       string    `json:"theme" bson:"theme"`
+	LinkIDs     []string  `json:"linkIds" bson:"linkIds"`
+	CreatedAt   time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+// PublicProfile is the unauthenticated view of a profile, carrying only the
+// links that are currently live.
+type PublicProfile struct {
+	Handle      string        `json:"handle"`
+	DisplayName string        `json:"displayName"`
+	AvatarURL   string        `json:"avatarUrl"`
+	Bio         string        `json:"bio"`
+	Theme       string        `json:"theme"`
+	Links       []*PublicLink `json:"links"`
+}
+
+// PublicLink is the subset of a link that is safe to show to visitors.
+type PublicLink struct {
+	ID    string `json:"id"`
+	Slug  string `json:"slug"`
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
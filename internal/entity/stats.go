@@ -0,0 +1,108 @@
+package entity
+
+import "time"
+
+// StatsInterval is the width of a time-series bucket.
+type StatsInterval string
+
+const (
+	IntervalHour StatsInterval = "hour"
+	IntervalDay  StatsInterval = "day"
+	IntervalWeek StatsInterval = "week" // weeks start on Monday
+)
+
+// StatsBucket counts the clicks in one interval starting at Start.
+type StatsBucket struct {
+	Start  time.Time `json:"start"`
+	Clicks int64     `json:"clicks"`
+}
+
+// StatsRange describes the window a stats response covers. To is exclusive.
+type StatsRange struct {
+	From     time.Time     `json:"from"`
+	To       time.Time     `json:"to"`
+	Interval StatsInterval `json:"interval"`
+	Timezone string        `json:"timezone"`
+}
+
+// LinkStats is the click history of a single link. BotHits counts the hits
+// from bots in the same window, which are left out of Total and Buckets, and
+// Anomalies lists the click spikes flagged in it.
+type LinkStats struct {
+	LinkID string `json:"linkId"`
+	StatsRange
+	Total     int64           `json:"total"`
+	BotHits   int64           `json:"botHits"`
+	Buckets   []*StatsBucket  `json:"buckets"`
+	Anomalies []*ClickAnomaly `json:"anomalies"`
+}
+
+// LinkTotal is one link's share of a profile's clicks.
+type LinkTotal struct {
+	LinkID string `json:"linkId"`
+	Slug   string `json:"slug"`
+	Title  string `json:"title"`
+	Clicks int64  `json:"clicks"`
+}
+
+// ProfileStats is the combined click history of the links on a profile,
+// with the click spikes flagged on any of them.
+type ProfileStats struct {
+	Handle string `json:"handle"`
+	StatsRange
+	Total     int64           `json:"total"`
+	Buckets   []*StatsBucket  `json:"buckets"`
+	Links     []*LinkTotal    `json:"links"`
+	Anomalies []*ClickAnomaly `json:"anomalies"`
+}
+
+// BreakdownDimension is a visit attribute clicks can be grouped by.
+type BreakdownDimension string
+
+const (
+	DimensionReferrer BreakdownDimension = "referrer" // referring host
+	DimensionCountry  BreakdownDimension = "country"
+	DimensionDevice   BreakdownDimension = "device"
+	DimensionBrowser  BreakdownDimension = "browser"
+	DimensionOS       BreakdownDimension = "os"
+	DimensionLanguage BreakdownDimension = "language"
+)
+
+// BreakdownItem is one value of a dimension with its share of the clicks.
+type BreakdownItem struct {
+	Value   string  `json:"value"`
+	Clicks  int64   `json:"clicks"`
+	Percent float64 `json:"percent"`
+}
+
+// Breakdown lists the most common values of a dimension for a link. Other
+// counts the clicks whose value did not make the top list.
+type Breakdown struct {
+	LinkID    string             `json:"linkId"`
+	Dimension BreakdownDimension `json:"dimension"`
+	From      time.Time          `json:"from"`
+	To        time.Time          `json:"to"`
+	Timezone  string             `json:"timezone"`
+	Total     int64              `json:"total"`
+	Items     []*BreakdownItem   `json:"items"`
+	Other     int64              `json:"other"`
+}
+
+// DailyVisitors compares clicks with the estimated number of distinct
+// visitors on one UTC day.
+type DailyVisitors struct {
+	Day      string `json:"day"` // YYYY-MM-DD
+	Clicks   int64  `json:"clicks"`
+	Visitors int64  `json:"visitors"`
+}
+
+// VisitorStats reports unique visitors of a link. Visitors are recognised
+// within a day only, so someone returning on another day counts again.
+type VisitorStats struct {
+	LinkID   string           `json:"linkId"`
+	From     time.Time        `json:"from"`
+	To       time.Time        `json:"to"`
+	Clicks   int64            `json:"clicks"`
+	Visitors int64            `json:"visitors"`
+	Days     []*DailyVisitors `json:"days"`
+}
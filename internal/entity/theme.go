@@ -0,0 +1,73 @@
+package entity
+
+import "sort"
+
+// DefaultTheme is used for profiles that have not picked a theme.
+const DefaultTheme = "classic"
+
+// Theme describes the look of a rendered bio page.
+type Theme struct {
+	Name             string `json:"name"`
+	Background       string `json:"background"`
+	Text             string `json:"text"`
+	ButtonBackground string `json:"buttonBackground"`
+	ButtonText       string `json:"buttonText"`
+	FontFamily       string `json:"fontFamily"`
+	ButtonRadius     string `json:"buttonRadius"`
+}
+
+// builtinThemes holds the themes a profile may select by name.
+var builtinThemes = map[string]Theme{
+	"classic": {
+		Name:             "classic",
+		Background:       "#f5f5f5",
+		Text:             "#222222",
+		ButtonBackground: "#ffffff",
+		ButtonText:       "#222222",
+		FontFamily:       "-apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif",
+		ButtonRadius:     "8px",
+	},
+	"midnight": {
+		Name:             "midnight",
+		Background:       "#0f172a",
+		Text:             "#e2e8f0",
+		ButtonBackground: "#1e293b",
+		ButtonText:       "#f8fafc",
+		FontFamily:       "'Inter', 'Helvetica Neue', Arial, sans-serif",
+		ButtonRadius:     "999px",
+	},
+	"sunset": {
+		Name:             "sunset",
+		Background:       "linear-gradient(160deg, #ff9a8b 0%, #ff6a88 55%, #ff99ac 100%)",
+		Text:             "#ffffff",
+		ButtonBackground: "rgba(255, 255, 255, 0.2)",
+		ButtonText:       "#ffffff",
+		FontFamily:       "'Trebuchet MS', 'Lucida Sans', sans-serif",
+		ButtonRadius:     "16px",
+	},
+	"mono": {
+		Name:             "mono",
+		Background:       "#ffffff",
+		Text:             "#000000",
+		ButtonBackground: "#000000",
+		ButtonText:       "#ffffff",
+		FontFamily:       "'Courier New', Courier, monospace",
+		ButtonRadius:     "0",
+	},
+}
+
+// LookupTheme returns the built-in theme with the given name.
+func LookupTheme(name string) (Theme, bool) {
+	theme, ok := builtinThemes[name]
+	return theme, ok
+}
+
+// ThemeNames lists the names of all built-in themes in alphabetical order.
+func ThemeNames() []string {
+	names := make([]string, 0, len(builtinThemes))
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,11 @@
+package entity
+
+import "time"
+
+// User represents an account that owns links and API keys.
+type User struct {
+	ID        string    `json:"id" bson:"_id,omitempty"`
+	Name      string    `json:"name" bson:"name"`
+	Email     string    `json:"email" bson:"email"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+}
@@ -7,4 +7,31 @@ type Visit struct {
 	ID        string    `json:"id" bson:"_id,omitempty"`
 	LinkID    string    `json:"linkId" bson:"linkId"`
 	VisitedAt time.Time `json:"visitedAt" bson:"visitedAt"`
+
+	// Referrer is the referring page without its query string; ReferrerHost
+	// is its host without a leading "www.".
+	Referrer     string `json:"referrer,omitempty" bson:"referrer,omitempty"`
+	ReferrerHost string `json:"referrerHost,omitempty" bson:"referrerHost,omitempty"`
+	Browser      string `json:"browser,omitempty" bson:"browser,omitempty"`
+	OS           string `json:"os,omitempty" bson:"os,omitempty"`
+	Device       string `json:"device,omitempty" bson:"device,omitempty"` // desktop, mobile, tablet or unknown
+	Language     string `json:"language,omitempty" bson:"language,omitempty"`
+	Country      string `json:"country,omitempty" bson:"country,omitempty"` // ISO 3166-1 alpha-2
+	// VisitorHash is a keyed hash of the UTC day, client IP and User-Agent,
+	// so it identifies a visitor within one day only. The IP is not stored.
+	VisitorHash string `json:"visitorHash,omitempty" bson:"visitorHash,omitempty"`
+	// Bot marks hits from crawlers, link unfurlers and other software. They
+	// are kept for reference but left out of clicks and analytics.
+	Bot     bool   `json:"bot,omitempty" bson:"bot,omitempty"`
+	BotName string `json:"botName,omitempty" bson:"botName,omitempty"`
+	// Repeat marks a visit by a visitor whose previous click on the link was
+	// counted moments ago. Like bot hits, repeats are not counted.
+	Repeat bool `json:"repeat,omitempty" bson:"repeat,omitempty"`
+}
+
+// VisitEvent announces a recorded visit to live subscribers.
+type VisitEvent struct {
+	Visit *Visit `json:"visit"`
+	// Clicks is the link's click count after the visit.
+	Clicks int `json:"clicks"`
 }
@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// VisitRollup sums up the visits to a link in one UTC hour or day, so stats
+// can be served once the raw visits are gone. Repeat clicks are left out.
+type VisitRollup struct {
+	LinkID string `json:"linkId" bson:"linkId"`
+	// Granularity is IntervalHour or IntervalDay.
+	Granularity StatsInterval `json:"granularity" bson:"granularity"`
+	Start       time.Time     `json:"start" bson:"start"`
+	Clicks      int64         `json:"clicks" bson:"clicks"`
+	BotHits     int64         `json:"botHits" bson:"botHits"`
+	// Values counts the clicks per value of each breakdown dimension.
+	Values []*RollupValue `json:"values" bson:"values"`
+}
+
+// RollupValue counts the clicks with one value of a breakdown dimension.
+type RollupValue struct {
+	Dimension BreakdownDimension `json:"dimension" bson:"dimension"`
+	Value     string             `json:"value" bson:"value"`
+	Clicks    int64              `json:"clicks" bson:"clicks"`
+}
@@ -0,0 +1,11 @@
+package entity
+
+import "time"
+
+// VisitorSketch holds the non-zero HyperLogLog registers counting the
+// distinct visitors of a link on one UTC day.
+type VisitorSketch struct {
+	LinkID    string
+	Day       time.Time
+	Registers map[uint16]uint8
+}
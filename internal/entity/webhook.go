@@ -0,0 +1,110 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebhookEvent names something that happened to a link.
+type WebhookEvent string
+
+const (
+	EventLinkCreated WebhookEvent = "link.created"
+	EventLinkUpdated WebhookEvent = "link.updated"
+	EventLinkDeleted WebhookEvent = "link.deleted" // moved to the trash
+	EventLinkExpired WebhookEvent = "link.expired" // archived after expiring
+	EventLinkVisited WebhookEvent = "link.visited" // counted clicks only
+)
+
+// WebhookEvents lists every event a webhook can subscribe to.
+var WebhookEvents = []WebhookEvent{EventLinkCreated, EventLinkUpdated, EventLinkDeleted, EventLinkExpired, EventLinkVisited}
+
+// Webhook is an owner's endpoint that receives the events it subscribes to.
+// Deliveries are signed with Secret, which is only returned when the webhook
+// is created.
+type Webhook struct {
+	ID        string         `json:"id" bson:"_id,omitempty"`
+	OwnerID   string         `json:"ownerId" bson:"ownerId"`
+	URL       string         `json:"url" bson:"url"`
+	Events    []WebhookEvent `json:"events" bson:"events"`
+	Secret    string         `json:"secret,omitempty" bson:"secret"`
+	CreatedAt time.Time      `json:"createdAt" bson:"createdAt"`
+}
+
+// WebhookPayload is the JSON body sent to webhooks. ID identifies the event
+// and stays the same across redeliveries, so receivers can drop duplicates.
+type WebhookPayload struct {
+	ID        string       `json:"id"`
+	Event     WebhookEvent `json:"event"`
+	CreatedAt time.Time    `json:"createdAt"`
+	Data      WebhookData  `json:"data"`
+}
+
+// WebhookData carries the link an event is about, and the visit for
+// link.visited.
+type WebhookData struct {
+	Link  *Link         `json:"link"`
+	Visit *WebhookVisit `json:"visit,omitempty"`
+}
+
+// WebhookVisit is the visit sent with link.visited. It leaves out the
+// visitor hash, which stays inside the service, and the bot and repeat
+// marks, which are never set on counted clicks.
+type WebhookVisit struct {
+	ID           string    `json:"id"`
+	LinkID       string    `json:"linkId"`
+	VisitedAt    time.Time `json:"visitedAt"`
+	Referrer     string    `json:"referrer,omitempty"`
+	ReferrerHost string    `json:"referrerHost,omitempty"`
+	Browser      string    `json:"browser,omitempty"`
+	OS           string    `json:"os,omitempty"`
+	Device       string    `json:"device,omitempty"`
+	Language     string    `json:"language,omitempty"`
+	Country      string    `json:"country,omitempty"`
+}
+
+// NewWebhookVisit copies the fields of visit that webhooks may see.
+func NewWebhookVisit(visit *Visit) *WebhookVisit {
+	return &WebhookVisit{
+		ID:           visit.ID,
+		LinkID:       visit.LinkID,
+		VisitedAt:    visit.VisitedAt,
+		Referrer:     visit.Referrer,
+		ReferrerHost: visit.ReferrerHost,
+		Browser:      visit.Browser,
+		OS:           visit.OS,
+		Device:       visit.Device,
+		Language:     visit.Language,
+		Country:      visit.Country,
+	}
+}
+
+// DeliveryStatus is the state of a webhook delivery.
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending" // waiting for its next attempt
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed" // out of attempts
+)
+
+// WebhookDelivery is one event queued for, or sent to, a webhook.
+type WebhookDelivery struct {
+	ID        string       `json:"id" bson:"_id,omitempty"`
+	WebhookID string       `json:"webhookId" bson:"webhookId"`
+	OwnerID   string       `json:"ownerId" bson:"ownerId"`
+	Event     WebhookEvent `json:"event" bson:"event"`
+	// Payload is the exact body sent, kept so redeliveries are identical.
+	Payload json.RawMessage `json:"payload" bson:"payload" swaggertype:"object"`
+	Status  DeliveryStatus  `json:"status" bson:"status"`
+	// RedeliveryOf is the delivery this one was manually retried from.
+	RedeliveryOf  string     `json:"redeliveryOf,omitempty" bson:"redeliveryOf,omitempty"`
+	Attempts      int        `json:"attempts" bson:"attempts"`
+	NextAttemptAt *time.Time `json:"nextAttemptAt,omitempty" bson:"nextAttemptAt,omitempty"`
+	LastAttemptAt *time.Time `json:"lastAttemptAt,omitempty" bson:"lastAttemptAt,omitempty"`
+	// ResponseStatus is the HTTP status of the last attempt; LastError
+	// explains why it failed.
+	ResponseStatus int       `json:"responseStatus,omitempty" bson:"responseStatus,omitempty"`
+	LastError      string    `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	CreatedAt      time.Time `json:"createdAt" bson:"createdAt"`
+}
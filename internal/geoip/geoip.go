@@ -0,0 +1,42 @@
+// Package geoip resolves client IP addresses to countries using a local
+// MaxMind-format database, such as GeoLite2-Country or DB-IP Lite.
+package geoip
+
+import (
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Reader looks up countries in an open database file.
+type Reader struct {
+	db *maxminddb.Reader
+}
+
+// Open memory-maps the database at path.
+func Open(path string) (*Reader, error) {
+	db, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{db: db}, nil
+}
+
+// Country returns the ISO 3166-1 alpha-2 code of the country ip is located
+// in, or "" when it is unknown.
+func (r *Reader) Country(ip net.IP) string {
+	var record struct {
+		Country struct {
+			ISOCode string `maxminddb:"iso_code"`
+		} `maxminddb:"country"`
+	}
+	if ip == nil || r.db.Lookup(ip, &record) != nil {
+		return ""
+	}
+	return record.Country.ISOCode
+}
+
+// Close releases the database.
+func (r *Reader) Close() error {
+	return r.db.Close()
+}
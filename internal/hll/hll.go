@@ -0,0 +1,75 @@
+// Package hll implements a HyperLogLog cardinality estimator.
+//
+// Sketches use 2^12 registers, giving a standard error of about 1.6%. A
+// register only ever grows, so sketches can be stored sparsely and updated
+// with an atomic "max" in the database, and sketches of several days merge
+// into the sketch of their union.
+package hll
+
+import (
+	"math"
+	"math/bits"
+)
+
+const (
+	// Precision is the number of hash bits used to pick a register.
+	Precision = 12
+	// Registers is the number of registers in a sketch.
+	Registers = 1 << Precision
+)
+
+// Position returns the register a 64-bit hash falls into and the rank to
+// record there: one more than the number of leading zeros in the remaining
+// bits.
+func Position(hash uint64) (index uint16, rank uint8) {
+	index = uint16(hash >> (64 - Precision))
+	rest := hash<<Precision | 1<<(Precision-1) // guard bit caps the rank
+	return index, uint8(bits.LeadingZeros64(rest) + 1)
+}
+
+// Sketch is a dense HyperLogLog sketch. The zero value is an empty sketch.
+type Sketch struct {
+	registers [Registers]uint8
+}
+
+// Set raises register index to rank if it is lower.
+func (s *Sketch) Set(index uint16, rank uint8) {
+	if int(index) < Registers && rank > s.registers[index] {
+		s.registers[index] = rank
+	}
+}
+
+// Add records a hashed element.
+func (s *Sketch) Add(hash uint64) {
+	s.Set(Position(hash))
+}
+
+// Merge folds other into s, making s a sketch of the union of both sets.
+func (s *Sketch) Merge(other *Sketch) {
+	for i, rank := range other.registers {
+		if rank > s.registers[i] {
+			s.registers[i] = rank
+		}
+	}
+}
+
+// Estimate returns the approximate number of distinct elements added.
+func (s *Sketch) Estimate() uint64 {
+	const m = float64(Registers)
+	alpha := 0.7213 / (1 + 1.079/m)
+
+	sum, zeros := 0.0, 0
+	for _, rank := range s.registers {
+		sum += math.Ldexp(1, -int(rank))
+		if rank == 0 {
+			zeros++
+		}
+	}
+	estimate := alpha * m * m / sum
+	// Small cardinalities are estimated far more accurately by linear
+	// counting over the empty registers.
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(math.Round(estimate))
+}
@@ -0,0 +1,86 @@
+// Package pubsub fans visit events out to subscribers within one process.
+//
+// Events are only delivered to subscribers connected to the instance that
+// recorded the visit; they are not persisted or shared between instances.
+package pubsub
+
+import (
+	"sync"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+)
+
+// SubscriptionBuffer is the number of events a subscriber may fall behind
+// before further events are dropped for it.
+const SubscriptionBuffer = 64
+
+// Broker delivers published visit events to the subscribers of the visited
+// link. Publishing never blocks on slow subscribers. The zero value is not
+// usable; create brokers with NewBroker.
+type Broker struct {
+	mu   sync.Mutex
+	subs map[string]map[*Subscription]struct{} // by link ID
+}
+
+func NewBroker() *Broker {
+	return &Broker{subs: make(map[string]map[*Subscription]struct{})}
+}
+
+// Subscription receives the events of a fixed set of links until closed.
+type Subscription struct {
+	broker  *Broker
+	linkIDs []string
+	events  chan *entity.VisitEvent
+	closed  bool
+}
+
+// Subscribe starts receiving events for the given links.
+func (b *Broker) Subscribe(linkIDs []string) *Subscription {
+	sub := &Subscription{broker: b, linkIDs: linkIDs, events: make(chan *entity.VisitEvent, SubscriptionBuffer)}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, id := range linkIDs {
+		if b.subs[id] == nil {
+			b.subs[id] = make(map[*Subscription]struct{})
+		}
+		b.subs[id][sub] = struct{}{}
+	}
+	return sub
+}
+
+// Publish delivers event to the subscribers of its link, dropping it for
+// those whose buffer is full.
+func (b *Broker) Publish(event *entity.VisitEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subs[event.Visit.LinkID] {
+		select {
+		case sub.events <- event:
+		default:
+		}
+	}
+}
+
+// Events returns the channel events are delivered on. It is closed when the
+// subscription is.
+func (s *Subscription) Events() <-chan *entity.VisitEvent {
+	return s.events
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	b := s.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	for _, id := range s.linkIDs {
+		delete(b.subs[id], s)
+		if len(b.subs[id]) == 0 {
+			delete(b.subs, id)
+		}
+	}
+	close(s.events)
+}
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"context"
+	"encoding/binary"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// boltClickWindowRepository stores each window's count and expiry times under
+// the link and visitor. bbolt runs one write transaction at a time, so a
+// claim is checked and taken atomically.
+type boltClickWindowRepository struct {
+	db     *bbolt.DB
+	claims atomic.Int64
+}
+
+func NewBoltClickWindowRepository(db *bbolt.DB) ClickWindowRepository {
+	return &boltClickWindowRepository{db: db}
+}
+
+func (r *boltClickWindowRepository) Claim(ctx context.Context, linkID, visitor string, now time.Time, window time.Duration) (bool, error) {
+	sweep := r.claims.Add(1)%clickWindowSweepEvery == 0
+	claimed := false
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(boltClickWindows)
+		if sweep {
+			var expired [][]byte
+			err := b.ForEach(func(k, v []byte) error {
+				if keyTime(v[8:]).Before(now) {
+					expired = append(expired, copyKey(k))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if err := deleteKeys(b, expired); err != nil {
+				return err
+			}
+		}
+		key := []byte(linkID + ":" + visitor)
+		if v := b.Get(key); v != nil && keyTime(v).After(now.Add(-window)) {
+			return nil
+		}
+		claimed = true
+		return b.Put(key, append(timeKey(now), timeKey(now.Add(window))...))
+	})
+	return claimed, err
+}
+
+// boltClickAnomalyRepository keys anomalies by link and window start, which
+// must be unique.
+type boltClickAnomalyRepository struct {
+	db *bbolt.DB
+}
+
+func NewBoltClickAnomalyRepository(db *bbolt.DB) ClickAnomalyRepository {
+	return &boltClickAnomalyRepository{db: db}
+}
+
+func (r *boltClickAnomalyRepository) Create(ctx context.Context, anomaly *entity.ClickAnomaly) (*entity.ClickAnomaly, error) {
+	stored := *anomaly
+	stored.ID = newID()
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(boltClickAnomalies)
+		key := compositeKey(anomaly.LinkID, timeKey(anomaly.WindowStart))
+		if b.Get(key) != nil {
+			return ErrDuplicate
+		}
+		return putDoc(b, key, &stored)
+	})
+	if err != nil {
+		return nil, err
+	}
+	anomaly.ID = stored.ID
+	return anomaly, nil
+}
+
+func (r *boltClickAnomalyRepository) List(ctx context.Context, linkIDs []string, from, to time.Time) ([]*entity.ClickAnomaly, error) {
+	anomalies := []*entity.ClickAnomaly{}
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(boltClickAnomalies)
+		for _, id := range uniqueStrings(linkIDs) {
+			err := scanRange(b, compositeKey(id, timeKey(from)), compositeKey(id, timeKey(to.Add(time.Millisecond))), func(_, v []byte) error {
+				var anomaly entity.ClickAnomaly
+				if err := bson.Unmarshal(v, &anomaly); err != nil {
+					return err
+				}
+				if !anomaly.WindowStart.Before(from) && anomaly.WindowStart.Before(to) {
+					anomalies = append(anomalies, &anomaly)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(anomalies, func(i, j int) bool { return anomalies[i].WindowStart.Before(anomalies[j].WindowStart) })
+	return anomalies, nil
+}
+
+func (r *boltClickAnomalyRepository) DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error) {
+	return deleteLinkPrefixes(r.db, boltClickAnomalies, linkIDs, func([]byte) bool { return true })
+}
+
+// boltVisitorSketchRepository stores one entry per raised register, keyed by
+// link, UTC day and register, so raising a register rewrites a single byte.
+type boltVisitorSketchRepository struct {
+	db *bbolt.DB
+}
+
+func NewBoltVisitorSketchRepository(db *bbolt.DB) VisitorSketchRepository {
+	return &boltVisitorSketchRepository{db: db}
+}
+
+func (r *boltVisitorSketchRepository) Raise(ctx context.Context, linkID string, day time.Time, index uint16, rank uint8) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(boltSketches)
+		key := compositeKey(linkID, timeKey(day), binary.BigEndian.AppendUint16(nil, index))
+		if v := b.Get(key); v != nil && v[0] >= rank {
+			return nil
+		}
+		return b.Put(key, []byte{rank})
+	})
+}
+
+func (r *boltVisitorSketchRepository) List(ctx context.Context, linkIDs []string, from, to time.Time) ([]*entity.VisitorSketch, error) {
+	sketches := make([]*entity.VisitorSketch, 0)
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(boltSketches)
+		for _, id := range uniqueStrings(linkIDs) {
+			prefix := compositeKey(id)
+			var sketch *entity.VisitorSketch
+			err := scanRange(b, compositeKey(id, timeKey(from)), compositeKey(id, timeKey(to.Add(time.Millisecond))), func(k, v []byte) error {
+				day := keyTime(k[len(prefix):])
+				if day.Before(from) || !day.Before(to) {
+					return nil
+				}
+				if sketch == nil || !sketch.Day.Equal(day) {
+					sketch = &entity.VisitorSketch{LinkID: id, Day: day, Registers: make(map[uint16]uint8)}
+					sketches = append(sketches, sketch)
+				}
+				sketch.Registers[binary.BigEndian.Uint16(k[len(prefix)+8:])] = v[0]
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sketches, nil
+}
+
+// DeleteByLinkIDs reports the number of sketches deleted, not registers.
+func (r *boltVisitorSketchRepository) DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error) {
+	var lastDay []byte
+	return deleteLinkPrefixes(r.db, boltSketches, linkIDs, func(k []byte) bool {
+		// Registers of a sketch are adjacent, so a sketch starts wherever
+		// the link and day change.
+		day := k[:len(k)-2]
+		first := string(day) != string(lastDay)
+		lastDay = day
+		return first
+	})
+}
+
+// deleteLinkPrefixes deletes the entries of bucket keyed by the given links
+// and returns the number of keys counted by count.
+func deleteLinkPrefixes(db *bbolt.DB, bucket []byte, linkIDs []string, count func(k []byte) bool) (int64, error) {
+	var deleted int64
+	err := db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucket)
+		for _, id := range uniqueStrings(linkIDs) {
+			var keys [][]byte
+			err := scanPrefix(b, compositeKey(id), func(k, _ []byte) error {
+				k = copyKey(k)
+				if count(k) {
+					deleted++
+				}
+				keys = append(keys, k)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if err := deleteKeys(b, keys); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return deleted, err
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	"go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Buckets of the bbolt data file. Records are BSON documents, encoded with the
+// same tags as the Mongo collections; index buckets map a lookup key to a
+// record key, or hold bare keys when the key itself is the answer.
+var (
+	boltLinks           = []byte("links")
+	boltLinkSlugs       = []byte("link_slugs")  // slug to link ID
+	boltLinkExpiry      = []byte("link_expiry") // expiry and ID of links neither archived nor trashed
+	boltLinkOwners      = []byte("link_owners") // owner and link ID
+	boltVisits          = []byte("visits")      // link, visit time and ID
+	boltVisitTimes      = []byte("visit_times") // visit time and ID to visits key
+	boltRollups         = []byte("visit_rollups")
+	boltState           = []byte("state")
+	boltUsers           = []byte("users")
+	boltUserEmails      = []byte("user_emails") // email to user ID
+	boltAPIKeys         = []byte("api_keys")
+	boltAPIKeyHashes    = []byte("api_key_hashes") // key hash to key ID
+	boltProfiles        = []byte("profiles")
+	boltProfileHandles  = []byte("profile_handles") // handle to profile ID
+	boltProfileUsers    = []byte("profile_users")   // user ID to profile ID
+	boltSketches        = []byte("visitor_sketches")
+	boltClickWindows    = []byte("click_windows")
+	boltClickAnomalies  = []byte("click_anomalies") // link and window start
+	boltWebhooks        = []byte("webhooks")
+	boltDeliveries      = []byte("webhook_deliveries")
+	boltDeliveryHooks   = []byte("webhook_delivery_hooks") // webhook, creation time and delivery ID
+	boltDeliveryPending = []byte("webhook_delivery_due")   // next attempt and ID of pending deliveries
+)
+
+var boltBuckets = [][]byte{
+	boltLinks, boltLinkSlugs, boltLinkExpiry, boltLinkOwners, boltVisits, boltVisitTimes, boltRollups, boltState,
+	boltUsers, boltUserEmails, boltAPIKeys, boltAPIKeyHashes, boltProfiles, boltProfileHandles, boltProfileUsers,
+	boltSketches, boltClickWindows, boltClickAnomalies, boltWebhooks, boltDeliveries, boltDeliveryHooks, boltDeliveryPending,
+}
+
+// OpenBolt opens the bbolt data file at path, creating it and its buckets if
+// needed. Only one process can have the file open; others give up after a
+// few seconds.
+func OpenBolt(path string) (*bbolt.DB, error) {
+	db, err := bbolt.Open(path, 0o600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range boltBuckets {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// getDoc decodes the record stored under key into v, or returns ErrNotFound.
+func getDoc(b *bbolt.Bucket, key []byte, v any) error {
+	data := b.Get(key)
+	if data == nil {
+		return ErrNotFound
+	}
+	return bson.Unmarshal(data, v)
+}
+
+func putDoc(b *bbolt.Bucket, key []byte, v any) error {
+	data, err := bson.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Put(key, data)
+}
+
+// timeKey encodes t at millisecond precision, like BSON dates, so that keys
+// sort chronologically.
+func timeKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixMilli())^(1<<63))
+	return key
+}
+
+func keyTime(key []byte) time.Time {
+	return time.UnixMilli(int64(binary.BigEndian.Uint64(key) ^ (1 << 63))).UTC()
+}
+
+// compositeKey joins a variable-length prefix, such as a link ID, to
+// fixed-length parts. The zero byte separator keeps one prefix's keys from
+// running into those of a longer one.
+func compositeKey(prefix string, parts ...[]byte) []byte {
+	key := append([]byte(prefix), 0)
+	for _, part := range parts {
+		key = append(key, part...)
+	}
+	return key
+}
+
+// scanPrefix calls fn with each key and value starting with prefix, in key
+// order, stopping at the first error.
+func scanPrefix(b *bbolt.Bucket, prefix []byte, fn func(k, v []byte) error) error {
+	c := b.Cursor()
+	for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanRange calls fn with each key and value in [from, to), in key order.
+func scanRange(b *bbolt.Bucket, from, to []byte, fn func(k, v []byte) error) error {
+	c := b.Cursor()
+	for k, v := c.Seek(from); k != nil && bytes.Compare(k, to) < 0; k, v = c.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteKeys removes keys from b. Keys are collected first, as deleting
+// while a cursor walks the bucket can skip entries.
+func deleteKeys(b *bbolt.Bucket, keys [][]byte) error {
+	for _, key := range keys {
+		if err := b.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyKey returns a copy of a key read in a transaction, which is only valid
+// until the transaction ends.
+func copyKey(key []byte) []byte {
+	return append([]byte(nil), key...)
+}
@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// boltLinkRepository stores links by ID, with indexes of slugs for redirects,
+// of owners for listings and of expiry times for archiving.
+type boltLinkRepository struct {
+	db *bbolt.DB
+}
+
+func NewBoltLinkRepository(db *bbolt.DB) LinkRepository {
+	return &boltLinkRepository{db: db}
+}
+
+func (r *boltLinkRepository) Create(ctx context.Context, link *entity.Link) (*entity.Link, error) {
+	stored := cloneLink(link)
+	stored.ID = newID()
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		if link.Slug != "" && tx.Bucket(boltLinkSlugs).Get([]byte(link.Slug)) != nil {
+			return ErrDuplicate
+		}
+		return saveLink(tx, stored)
+	})
+	if err != nil {
+		return nil, err
+	}
+	link.ID = stored.ID
+	return link, nil
+}
+
+func (r *boltLinkRepository) GetByID(ctx context.Context, id string) (*entity.Link, error) {
+	var link *entity.Link
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		link, err = getLink(tx, id)
+		return err
+	})
+	return link, err
+}
+
+// GetByIDs returns the links matching ids in no particular order. Unknown IDs
+// are skipped rather than reported.
+func (r *boltLinkRepository) GetByIDs(ctx context.Context, ids []string) ([]*entity.Link, error) {
+	links := make([]*entity.Link, 0, len(ids))
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		for _, id := range uniqueStrings(ids) {
+			link, err := getLink(tx, id)
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			links = append(links, link)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func (r *boltLinkRepository) GetBySlug(ctx context.Context, slug string) (*entity.Link, error) {
+	if slug == "" {
+		return nil, ErrNotFound
+	}
+	var link *entity.Link
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		id := tx.Bucket(boltLinkSlugs).Get([]byte(slug))
+		if id == nil {
+			return ErrNotFound
+		}
+		var err error
+		link, err = getLink(tx, string(id))
+		return err
+	})
+	return link, err
+}
+
+// Update saves the fields a client may change, like the Mongo repository.
+func (r *boltLinkRepository) Update(ctx context.Context, link *entity.Link) (*entity.Link, error) {
+	if link.ID == "" {
+		return nil, errors.New("missing link ID")
+	}
+	var stored *entity.Link
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		var err error
+		if stored, err = getLink(tx, link.ID); err != nil {
+			return err
+		}
+		if id := tx.Bucket(boltLinkSlugs).Get([]byte(link.Slug)); link.Slug != "" && id != nil && string(id) != link.ID {
+			return ErrDuplicate
+		}
+		if err := unindexLink(tx, stored); err != nil {
+			return err
+		}
+		updated := cloneLink(link)
+		stored.Title = updated.Title
+		stored.URL = updated.URL
+		stored.Slug = updated.Slug
+		stored.ActiveFrom = updated.ActiveFrom
+		stored.ExpiresAt = updated.ExpiresAt
+		stored.ArchivedAt = updated.ArchivedAt
+		stored.DeletedAt = updated.DeletedAt
+		stored.Tags = updated.Tags
+		return saveLink(tx, stored)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, stored.ID)
+}
+
+func (r *boltLinkRepository) Delete(ctx context.Context, id string) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		link, err := getLink(tx, id)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return removeLink(tx, link)
+	})
+}
+
+func (r *boltLinkRepository) IncrementClicks(ctx context.Context, id string) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		link, err := getLink(tx, id)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		link.Clicks++
+		return putDoc(tx.Bucket(boltLinks), []byte(link.ID), link)
+	})
+}
+
+// List reads the owner's links through the owner index and filters them in
+// memory, as an owner's links fit comfortably there.
+func (r *boltLinkRepository) List(ctx context.Context, f LinkFilter) ([]*entity.Link, error) {
+	var links []*entity.Link
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		if f.OwnerID == "" {
+			links, err = allLinks(tx)
+		} else {
+			links, err = ownedLinks(tx, f.OwnerID)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ApplyLinkFilter(links, f), nil
+}
+
+func (r *boltLinkRepository) ListArchived(ctx context.Context, ownerID string) ([]*entity.Link, error) {
+	return r.listOwned(ownerID, func(link *entity.Link) *time.Time { return link.ArchivedAt })
+}
+
+func (r *boltLinkRepository) ListDeleted(ctx context.Context, ownerID string) ([]*entity.Link, error) {
+	return r.listOwned(ownerID, func(link *entity.Link) *time.Time { return link.DeletedAt })
+}
+
+// listOwned returns ownerID's links where the given timestamp is set, most
+// recent first.
+func (r *boltLinkRepository) listOwned(ownerID string, at func(*entity.Link) *time.Time) ([]*entity.Link, error) {
+	var owned []*entity.Link
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		owned, err = ownedLinks(tx, ownerID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	links := make([]*entity.Link, 0)
+	for _, link := range owned {
+		if at(link) != nil {
+			links = append(links, link)
+		}
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if cmp := at(links[i]).Compare(*at(links[j])); cmp != 0 {
+			return cmp > 0
+		}
+		return links[i].ID > links[j].ID
+	})
+	return links, nil
+}
+
+// ArchiveExpired walks the expiry index, which only holds links that are not
+// archived yet, up to now.
+func (r *boltLinkRepository) ArchiveExpired(ctx context.Context, now time.Time) ([]*entity.Link, error) {
+	archived := make([]*entity.Link, 0)
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		var ids []string
+		c := tx.Bucket(boltLinkExpiry).Cursor()
+		for k, _ := c.First(); k != nil && keyTime(k).Before(now); k, _ = c.Next() {
+			ids = append(ids, string(k[8:]))
+		}
+		for _, id := range ids {
+			link, err := getLink(tx, id)
+			if err != nil {
+				return err
+			}
+			if err := unindexLink(tx, link); err != nil {
+				return err
+			}
+			at := now
+			link.ArchivedAt = &at
+			if err := saveLink(tx, link); err != nil {
+				return err
+			}
+			archived = append(archived, link)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return archived, nil
+}
+
+func (r *boltLinkRepository) PurgeArchived(ctx context.Context, before time.Time) ([]string, error) {
+	return r.purge(func(link *entity.Link) bool {
+		return link.ArchivedAt != nil && link.ArchivedAt.Before(before)
+	})
+}
+
+func (r *boltLinkRepository) PurgeDeleted(ctx context.Context, before time.Time) ([]string, error) {
+	return r.purge(func(link *entity.Link) bool {
+		return link.DeletedAt != nil && link.DeletedAt.Before(before)
+	})
+}
+
+// purge deletes the links matching and returns their IDs.
+func (r *boltLinkRepository) purge(matching func(*entity.Link) bool) ([]string, error) {
+	var ids []string
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		links, err := allLinks(tx)
+		if err != nil {
+			return err
+		}
+		for _, link := range links {
+			if !matching(link) {
+				continue
+			}
+			if err := removeLink(tx, link); err != nil {
+				return err
+			}
+			ids = append(ids, link.ID)
+		}
+		return nil
+	})
+	return ids, err
+}
+
+func getLink(tx *bbolt.Tx, id string) (*entity.Link, error) {
+	var link entity.Link
+	if err := getDoc(tx.Bucket(boltLinks), []byte(id), &link); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func allLinks(tx *bbolt.Tx) ([]*entity.Link, error) {
+	links := make([]*entity.Link, 0)
+	err := tx.Bucket(boltLinks).ForEach(func(k, v []byte) error {
+		var link entity.Link
+		if err := bson.Unmarshal(v, &link); err != nil {
+			return err
+		}
+		links = append(links, &link)
+		return nil
+	})
+	return links, err
+}
+
+func ownedLinks(tx *bbolt.Tx, ownerID string) ([]*entity.Link, error) {
+	links := make([]*entity.Link, 0)
+	prefix := compositeKey(ownerID)
+	err := scanPrefix(tx.Bucket(boltLinkOwners), prefix, func(k, _ []byte) error {
+		link, err := getLink(tx, string(k[len(prefix):]))
+		if err != nil {
+			return err
+		}
+		links = append(links, link)
+		return nil
+	})
+	return links, err
+}
+
+// saveLink stores link and adds it to the indexes.
+func saveLink(tx *bbolt.Tx, link *entity.Link) error {
+	if err := putDoc(tx.Bucket(boltLinks), []byte(link.ID), link); err != nil {
+		return err
+	}
+	if link.Slug != "" {
+		if err := tx.Bucket(boltLinkSlugs).Put([]byte(link.Slug), []byte(link.ID)); err != nil {
+			return err
+		}
+	}
+	if link.ExpiresAt != nil && link.ArchivedAt == nil && link.DeletedAt == nil {
+		if err := tx.Bucket(boltLinkExpiry).Put(append(timeKey(*link.ExpiresAt), link.ID...), nil); err != nil {
+			return err
+		}
+	}
+	return tx.Bucket(boltLinkOwners).Put(compositeKey(link.OwnerID, []byte(link.ID)), nil)
+}
+
+// unindexLink removes the index entries of link as stored.
+func unindexLink(tx *bbolt.Tx, link *entity.Link) error {
+	if link.Slug != "" {
+		if err := tx.Bucket(boltLinkSlugs).Delete([]byte(link.Slug)); err != nil {
+			return err
+		}
+	}
+	if link.ExpiresAt != nil {
+		if err := tx.Bucket(boltLinkExpiry).Delete(append(timeKey(*link.ExpiresAt), link.ID...)); err != nil {
+			return err
+		}
+	}
+	return tx.Bucket(boltLinkOwners).Delete(compositeKey(link.OwnerID, []byte(link.ID)))
+}
+
+func removeLink(tx *bbolt.Tx, link *entity.Link) error {
+	if err := unindexLink(tx, link); err != nil {
+		return err
+	}
+	return tx.Bucket(boltLinks).Delete([]byte(link.ID))
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"slices"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"go.etcd.io/bbolt"
+)
+
+// boltProfileRepository stores profiles by ID with indexes of handles and
+// users, enforcing unique handles and one profile per user.
+type boltProfileRepository struct {
+	db *bbolt.DB
+}
+
+func NewBoltProfileRepository(db *bbolt.DB) ProfileRepository {
+	return &boltProfileRepository{db: db}
+}
+
+func (r *boltProfileRepository) Create(ctx context.Context, profile *entity.Profile) (*entity.Profile, error) {
+	stored := cloneProfile(profile)
+	stored.ID = newID()
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		handles, users := tx.Bucket(boltProfileHandles), tx.Bucket(boltProfileUsers)
+		if handles.Get([]byte(profile.Handle)) != nil || users.Get([]byte(profile.UserID)) != nil {
+			return ErrDuplicate
+		}
+		if err := putDoc(tx.Bucket(boltProfiles), []byte(stored.ID), stored); err != nil {
+			return err
+		}
+		if err := handles.Put([]byte(profile.Handle), []byte(stored.ID)); err != nil {
+			return err
+		}
+		return users.Put([]byte(profile.UserID), []byte(stored.ID))
+	})
+	if err != nil {
+		return nil, err
+	}
+	profile.ID = stored.ID
+	return profile, nil
+}
+
+func (r *boltProfileRepository) GetByHandle(ctx context.Context, handle string) (*entity.Profile, error) {
+	return r.find(boltProfileHandles, handle)
+}
+
+func (r *boltProfileRepository) GetByUserID(ctx context.Context, userID string) (*entity.Profile, error) {
+	return r.find(boltProfileUsers, userID)
+}
+
+// Update saves the fields a user may change, like the Mongo repository.
+func (r *boltProfileRepository) Update(ctx context.Context, profile *entity.Profile) (*entity.Profile, error) {
+	var stored entity.Profile
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		profiles, handles := tx.Bucket(boltProfiles), tx.Bucket(boltProfileHandles)
+		if err := getDoc(profiles, []byte(profile.ID), &stored); err != nil {
+			return err
+		}
+		if id := handles.Get([]byte(profile.Handle)); id != nil && string(id) != profile.ID {
+			return ErrDuplicate
+		}
+		if err := handles.Delete([]byte(stored.Handle)); err != nil {
+			return err
+		}
+		stored.Handle = profile.Handle
+		stored.DisplayName = profile.DisplayName
+		stored.AvatarURL = profile.AvatarURL
+		stored.Bio = profile.Bio
+		stored.Theme = profile.Theme
+		stored.LinkIDs = slices.Clone(profile.LinkIDs)
+		stored.UpdatedAt = profile.UpdatedAt
+		if err := putDoc(profiles, []byte(stored.ID), &stored); err != nil {
+			return err
+		}
+		return handles.Put([]byte(stored.Handle), []byte(stored.ID))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// find looks the profile up through the given index.
+func (r *boltProfileRepository) find(index []byte, value string) (*entity.Profile, error) {
+	var profile entity.Profile
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		id := tx.Bucket(index).Get([]byte(value))
+		if id == nil {
+			return ErrNotFound
+		}
+		return getDoc(tx.Bucket(boltProfiles), id, &profile)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
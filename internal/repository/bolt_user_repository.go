@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// boltUserRepository stores users by ID with an index of emails, which must
+// be unique.
+type boltUserRepository struct {
+	db *bbolt.DB
+}
+
+func NewBoltUserRepository(db *bbolt.DB) UserRepository {
+	return &boltUserRepository{db: db}
+}
+
+func (r *boltUserRepository) Create(ctx context.Context, user *entity.User) (*entity.User, error) {
+	stored := *user
+	stored.ID = newID()
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		emails := tx.Bucket(boltUserEmails)
+		if emails.Get([]byte(user.Email)) != nil {
+			return ErrDuplicate
+		}
+		if err := putDoc(tx.Bucket(boltUsers), []byte(stored.ID), &stored); err != nil {
+			return err
+		}
+		return emails.Put([]byte(user.Email), []byte(stored.ID))
+	})
+	if err != nil {
+		return nil, err
+	}
+	user.ID = stored.ID
+	return user, nil
+}
+
+func (r *boltUserRepository) GetByID(ctx context.Context, id string) (*entity.User, error) {
+	var user entity.User
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		return getDoc(tx.Bucket(boltUsers), []byte(id), &user)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// List returns all users, newest first.
+func (r *boltUserRepository) List(ctx context.Context) ([]*entity.User, error) {
+	users := make([]*entity.User, 0)
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(boltUsers).ForEach(func(_, v []byte) error {
+			var user entity.User
+			if err := bson.Unmarshal(v, &user); err != nil {
+				return err
+			}
+			users = append(users, &user)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.After(users[j].CreatedAt)
+		}
+		return users[i].ID > users[j].ID
+	})
+	return users, nil
+}
+
+// boltAPIKeyRepository stores API keys by ID with an index of key hashes,
+// which every authenticated request looks up.
+type boltAPIKeyRepository struct {
+	db *bbolt.DB
+}
+
+func NewBoltAPIKeyRepository(db *bbolt.DB) APIKeyRepository {
+	return &boltAPIKeyRepository{db: db}
+}
+
+func (r *boltAPIKeyRepository) Create(ctx context.Context, key *entity.APIKey) (*entity.APIKey, error) {
+	stored := cloneAPIKey(key)
+	stored.ID = newID()
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		hashes := tx.Bucket(boltAPIKeyHashes)
+		if hashes.Get([]byte(key.KeyHash)) != nil {
+			return ErrDuplicate
+		}
+		if err := putDoc(tx.Bucket(boltAPIKeys), []byte(stored.ID), stored); err != nil {
+			return err
+		}
+		return hashes.Put([]byte(key.KeyHash), []byte(stored.ID))
+	})
+	if err != nil {
+		return nil, err
+	}
+	key.ID = stored.ID
+	return key, nil
+}
+
+func (r *boltAPIKeyRepository) GetByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	var key entity.APIKey
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		id := tx.Bucket(boltAPIKeyHashes).Get([]byte(hash))
+		if id == nil {
+			return ErrNotFound
+		}
+		return getDoc(tx.Bucket(boltAPIKeys), id, &key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// List returns all keys, newest first.
+func (r *boltAPIKeyRepository) List(ctx context.Context) ([]*entity.APIKey, error) {
+	keys := make([]*entity.APIKey, 0)
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(boltAPIKeys).ForEach(func(_, v []byte) error {
+			var key entity.APIKey
+			if err := bson.Unmarshal(v, &key); err != nil {
+				return err
+			}
+			keys = append(keys, &key)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.After(keys[j].CreatedAt)
+		}
+		return keys[i].ID > keys[j].ID
+	})
+	return keys, nil
+}
+
+func (r *boltAPIKeyRepository) Revoke(ctx context.Context, id string) error {
+	return r.update(id, func(key *entity.APIKey) { key.Revoked = true })
+}
+
+func (r *boltAPIKeyRepository) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	err := r.update(id, func(key *entity.APIKey) { key.LastUsedAt = &at })
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// update applies fn to the stored key, or returns ErrNotFound.
+func (r *boltAPIKeyRepository) update(id string, fn func(*entity.APIKey)) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(boltAPIKeys)
+		var key entity.APIKey
+		if err := getDoc(b, []byte(id), &key); err != nil {
+			return err
+		}
+		fn(&key)
+		return putDoc(b, []byte(id), &key)
+	})
+}
@@ -0,0 +1,370 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// boltVisitRepository keys visits by link and time, so queries only read the
+// links and period they ask for. An index by time serves the queries across
+// all links.
+type boltVisitRepository struct {
+	db *bbolt.DB
+}
+
+func NewBoltVisitRepository(db *bbolt.DB) VisitRepository {
+	return &boltVisitRepository{db: db}
+}
+
+func (r *boltVisitRepository) Create(ctx context.Context, visit *entity.Visit) (*entity.Visit, error) {
+	stored := *visit
+	stored.ID = newID()
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		key := compositeKey(stored.LinkID, timeKey(stored.VisitedAt), []byte(stored.ID))
+		if err := putDoc(tx.Bucket(boltVisits), key, &stored); err != nil {
+			return err
+		}
+		return tx.Bucket(boltVisitTimes).Put(append(timeKey(stored.VisitedAt), stored.ID...), key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	visit.ID = stored.ID
+	return visit, nil
+}
+
+func (r *boltVisitRepository) DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error) {
+	var deleted int64
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		visits, times := tx.Bucket(boltVisits), tx.Bucket(boltVisitTimes)
+		for _, id := range uniqueStrings(linkIDs) {
+			prefix := compositeKey(id)
+			var keys [][]byte
+			err := scanPrefix(visits, prefix, func(k, _ []byte) error {
+				keys = append(keys, copyKey(k))
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			for _, key := range keys {
+				// The time index key is the visit key without the link.
+				if err := times.Delete(key[len(prefix):]); err != nil {
+					return err
+				}
+			}
+			if err := deleteKeys(visits, keys); err != nil {
+				return err
+			}
+			deleted += int64(len(keys))
+		}
+		return nil
+	})
+	return deleted, err
+}
+
+func (r *boltVisitRepository) CountByInterval(ctx context.Context, q VisitQuery, interval entity.StatsInterval, loc *time.Location) ([]*entity.StatsBucket, error) {
+	counts := map[time.Time]int64{}
+	err := r.scan(q, func(visit *entity.Visit) {
+		counts[BucketStart(visit.VisitedAt, interval, loc)]++
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sortedBuckets(counts), nil
+}
+
+func (r *boltVisitRepository) CountByLink(ctx context.Context, q VisitQuery) (map[string]int64, error) {
+	counts := map[string]int64{}
+	err := r.scan(q, func(visit *entity.Visit) {
+		counts[visit.LinkID]++
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (r *boltVisitRepository) Count(ctx context.Context, q VisitQuery) (int64, error) {
+	var count int64
+	err := r.scan(q, func(*entity.Visit) { count++ })
+	return count, err
+}
+
+func (r *boltVisitRepository) TopValues(ctx context.Context, q VisitQuery, dimension entity.BreakdownDimension, limit int) ([]*entity.BreakdownItem, error) {
+	if _, ok := VisitFields[dimension]; !ok {
+		return []*entity.BreakdownItem{}, nil
+	}
+	counts := map[string]int64{}
+	err := r.scan(q, func(visit *entity.Visit) {
+		counts[VisitDimension(visit, dimension)]++
+	})
+	if err != nil {
+		return nil, err
+	}
+	return topItems(counts, limit), nil
+}
+
+func (r *boltVisitRepository) SourceCounts(ctx context.Context, from, to time.Time, minClicks int64) ([]*LinkSources, error) {
+	perVisitor := map[string]map[string]int64{}
+	err := r.scanPeriod(from, to, func(visit *entity.Visit) {
+		if visit.Bot || visit.Repeat {
+			return
+		}
+		if perVisitor[visit.LinkID] == nil {
+			perVisitor[visit.LinkID] = map[string]int64{}
+		}
+		perVisitor[visit.LinkID][visit.VisitorHash]++
+	})
+	if err != nil {
+		return nil, err
+	}
+	sources := make([]*LinkSources, 0)
+	for linkID, visitors := range perVisitor {
+		s := &LinkSources{LinkID: linkID, Sources: int64(len(visitors))}
+		for _, clicks := range visitors {
+			s.Clicks += clicks
+			s.TopSourceClicks = max(s.TopSourceClicks, clicks)
+		}
+		if s.Clicks >= minClicks {
+			sources = append(sources, s)
+		}
+	}
+	return sources, nil
+}
+
+// Each reads the matching visits before calling fn, so fn may use the
+// repository while the visits are handed out.
+func (r *boltVisitRepository) Each(ctx context.Context, q VisitQuery, fn func(*entity.Visit) error) error {
+	var visits []*entity.Visit
+	err := r.scan(q, func(visit *entity.Visit) {
+		visits = append(visits, visit)
+	})
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(visits, func(i, j int) bool { return visits[i].VisitedAt.Before(visits[j].VisitedAt) })
+	for _, visit := range visits {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *boltVisitRepository) Rollup(ctx context.Context, from, to time.Time) ([]*entity.VisitRollup, error) {
+	builder := NewRollupBuilder(entity.IntervalHour)
+	err := r.scanPeriod(from, to, func(visit *entity.Visit) {
+		builder.AddVisit(visit, 1)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return builder.Rollups(), nil
+}
+
+func (r *boltVisitRepository) Oldest(ctx context.Context) (time.Time, error) {
+	var oldest time.Time
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		k, _ := tx.Bucket(boltVisitTimes).Cursor().First()
+		if k == nil {
+			return ErrNotFound
+		}
+		oldest = keyTime(k)
+		return nil
+	})
+	return oldest, err
+}
+
+func (r *boltVisitRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		visits, times := tx.Bucket(boltVisits), tx.Bucket(boltVisitTimes)
+		var keys [][]byte
+		c := times.Cursor()
+		for k, v := c.First(); k != nil && keyTime(k).Before(before); k, v = c.Next() {
+			if err := visits.Delete(v); err != nil {
+				return err
+			}
+			keys = append(keys, copyKey(k))
+		}
+		deleted = int64(len(keys))
+		return deleteKeys(times, keys)
+	})
+	return deleted, err
+}
+
+// scan calls fn with every visit matching q, reading each link's visits in
+// the queried period only.
+func (r *boltVisitRepository) scan(q VisitQuery, fn func(*entity.Visit)) error {
+	// Keys hold milliseconds, so the end is rounded up; Matches has the
+	// final say.
+	from, to := timeKey(q.From), timeKey(q.To.Add(time.Millisecond))
+	return r.db.View(func(tx *bbolt.Tx) error {
+		visits := tx.Bucket(boltVisits)
+		for _, id := range uniqueStrings(q.LinkIDs) {
+			err := scanRange(visits, compositeKey(id, from), compositeKey(id, to), func(_, v []byte) error {
+				var visit entity.Visit
+				if err := bson.Unmarshal(v, &visit); err != nil {
+					return err
+				}
+				if q.Matches(&visit) {
+					fn(&visit)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// scanPeriod calls fn with every visit in [from, to) through the time index.
+func (r *boltVisitRepository) scanPeriod(from, to time.Time, fn func(*entity.Visit)) error {
+	return r.db.View(func(tx *bbolt.Tx) error {
+		visits := tx.Bucket(boltVisits)
+		return scanRange(tx.Bucket(boltVisitTimes), timeKey(from), timeKey(to.Add(time.Millisecond)), func(_, key []byte) error {
+			var visit entity.Visit
+			if err := getDoc(visits, key, &visit); err != nil {
+				return err
+			}
+			if !visit.VisitedAt.Before(from) && visit.VisitedAt.Before(to) {
+				fn(&visit)
+			}
+			return nil
+		})
+	})
+}
+
+// boltVisitRollupRepository keys rollups by link, granularity and start.
+type boltVisitRollupRepository struct {
+	db *bbolt.DB
+}
+
+func NewBoltVisitRollupRepository(db *bbolt.DB) VisitRollupRepository {
+	return &boltVisitRollupRepository{db: db}
+}
+
+// rollupWatermarkKey is the key of the rollup watermark in the state bucket.
+var rollupWatermarkKey = []byte("rollup_watermark")
+
+func (r *boltVisitRollupRepository) Save(ctx context.Context, rollups []*entity.VisitRollup) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(boltRollups)
+		for _, rollup := range rollups {
+			if err := putDoc(b, rollupKeyOf(rollup.LinkID, rollup.Granularity, rollup.Start), rollup); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *boltVisitRollupRepository) CountByInterval(ctx context.Context, q RollupQuery, interval entity.StatsInterval, loc *time.Location) ([]*entity.StatsBucket, error) {
+	counts := map[time.Time]int64{}
+	err := r.scan(q, func(rollup *entity.VisitRollup) {
+		if rollup.Clicks > 0 {
+			counts[BucketStart(rollup.Start, interval, loc)] += rollup.Clicks
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sortedBuckets(counts), nil
+}
+
+func (r *boltVisitRollupRepository) CountByLink(ctx context.Context, q RollupQuery) (map[string]int64, error) {
+	counts := map[string]int64{}
+	err := r.scan(q, func(rollup *entity.VisitRollup) {
+		if rollup.Clicks > 0 {
+			counts[rollup.LinkID] += rollup.Clicks
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (r *boltVisitRollupRepository) Count(ctx context.Context, q RollupQuery) (int64, int64, error) {
+	var clicks, bots int64
+	err := r.scan(q, func(rollup *entity.VisitRollup) {
+		clicks += rollup.Clicks
+		bots += rollup.BotHits
+	})
+	return clicks, bots, err
+}
+
+func (r *boltVisitRollupRepository) TopValues(ctx context.Context, q RollupQuery, dimension entity.BreakdownDimension, limit int) ([]*entity.BreakdownItem, error) {
+	counts := map[string]int64{}
+	err := r.scan(q, func(rollup *entity.VisitRollup) {
+		for _, value := range rollup.Values {
+			if value.Dimension == dimension {
+				counts[value.Value] += value.Clicks
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return topItems(counts, limit), nil
+}
+
+func (r *boltVisitRollupRepository) DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error) {
+	return deleteLinkPrefixes(r.db, boltRollups, linkIDs, func([]byte) bool { return true })
+}
+
+func (r *boltVisitRollupRepository) Watermark(ctx context.Context) (time.Time, error) {
+	var watermark time.Time
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		if v := tx.Bucket(boltState).Get(rollupWatermarkKey); v != nil {
+			watermark = keyTime(v)
+		}
+		return nil
+	})
+	return watermark, err
+}
+
+func (r *boltVisitRollupRepository) SetWatermark(ctx context.Context, t time.Time) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(boltState).Put(rollupWatermarkKey, timeKey(t))
+	})
+}
+
+// scan calls fn with every rollup matching q.
+func (r *boltVisitRollupRepository) scan(q RollupQuery, fn func(*entity.VisitRollup)) error {
+	return r.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(boltRollups)
+		for _, id := range uniqueStrings(q.LinkIDs) {
+			from, to := rollupKeyOf(id, q.Granularity, q.From), rollupKeyOf(id, q.Granularity, q.To.Add(time.Millisecond))
+			err := scanRange(b, from, to, func(_, v []byte) error {
+				var rollup entity.VisitRollup
+				if err := bson.Unmarshal(v, &rollup); err != nil {
+					return err
+				}
+				if q.Matches(&rollup) {
+					fn(&rollup)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func rollupKeyOf(linkID string, granularity entity.StatsInterval, start time.Time) []byte {
+	return compositeKey(linkID, []byte(granularity), []byte{0}, timeKey(start))
+}
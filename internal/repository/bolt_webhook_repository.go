@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type boltWebhookRepository struct {
+	db *bbolt.DB
+}
+
+func NewBoltWebhookRepository(db *bbolt.DB) WebhookRepository {
+	return &boltWebhookRepository{db: db}
+}
+
+func (r *boltWebhookRepository) Create(ctx context.Context, webhook *entity.Webhook) (*entity.Webhook, error) {
+	stored := cloneWebhook(webhook)
+	stored.ID = newID()
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		return putDoc(tx.Bucket(boltWebhooks), []byte(stored.ID), stored)
+	})
+	if err != nil {
+		return nil, err
+	}
+	webhook.ID = stored.ID
+	return webhook, nil
+}
+
+func (r *boltWebhookRepository) GetByID(ctx context.Context, id string) (*entity.Webhook, error) {
+	var webhook entity.Webhook
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		return getDoc(tx.Bucket(boltWebhooks), []byte(id), &webhook)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *boltWebhookRepository) ListByOwner(ctx context.Context, ownerID string) ([]*entity.Webhook, error) {
+	return r.find(func(webhook *entity.Webhook) bool { return webhook.OwnerID == ownerID })
+}
+
+func (r *boltWebhookRepository) ListSubscribed(ctx context.Context, ownerID string, event entity.WebhookEvent) ([]*entity.Webhook, error) {
+	return r.find(func(webhook *entity.Webhook) bool {
+		return webhook.OwnerID == ownerID && slices.Contains(webhook.Events, event)
+	})
+}
+
+// find returns the matching webhooks, newest first. There are few webhooks,
+// so they are scanned rather than indexed.
+func (r *boltWebhookRepository) find(matching func(*entity.Webhook) bool) ([]*entity.Webhook, error) {
+	webhooks := make([]*entity.Webhook, 0)
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(boltWebhooks).ForEach(func(_, v []byte) error {
+			var webhook entity.Webhook
+			if err := bson.Unmarshal(v, &webhook); err != nil {
+				return err
+			}
+			if matching(&webhook) {
+				webhooks = append(webhooks, &webhook)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		if !webhooks[i].CreatedAt.Equal(webhooks[j].CreatedAt) {
+			return webhooks[i].CreatedAt.After(webhooks[j].CreatedAt)
+		}
+		return webhooks[i].ID > webhooks[j].ID
+	})
+	return webhooks, nil
+}
+
+func (r *boltWebhookRepository) Delete(ctx context.Context, id string) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(boltWebhooks)
+		if b.Get([]byte(id)) == nil {
+			return ErrNotFound
+		}
+		return b.Delete([]byte(id))
+	})
+}
+
+// boltWebhookDeliveryRepository stores deliveries by ID, indexed by webhook
+// for listings and by next attempt for the pending ones, which workers
+// claim in order.
+type boltWebhookDeliveryRepository struct {
+	db *bbolt.DB
+}
+
+func NewBoltWebhookDeliveryRepository(db *bbolt.DB) WebhookDeliveryRepository {
+	return &boltWebhookDeliveryRepository{db: db}
+}
+
+func (r *boltWebhookDeliveryRepository) Create(ctx context.Context, delivery *entity.WebhookDelivery) (*entity.WebhookDelivery, error) {
+	stored := cloneDelivery(delivery)
+	stored.ID = newID()
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		if err := saveDelivery(tx, stored); err != nil {
+			return err
+		}
+		return tx.Bucket(boltDeliveryHooks).Put(deliveryHookKey(stored), nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	delivery.ID = stored.ID
+	return delivery, nil
+}
+
+func (r *boltWebhookDeliveryRepository) GetByID(ctx context.Context, id string) (*entity.WebhookDelivery, error) {
+	var delivery *entity.WebhookDelivery
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		delivery, err = getDelivery(tx, []byte(id))
+		return err
+	})
+	return delivery, err
+}
+
+// ListByWebhook walks the webhook's index entries backwards, which yields
+// the deliveries newest first.
+func (r *boltWebhookDeliveryRepository) ListByWebhook(ctx context.Context, webhookID string, limit int) ([]*entity.WebhookDelivery, error) {
+	deliveries := make([]*entity.WebhookDelivery, 0)
+	err := r.db.View(func(tx *bbolt.Tx) error {
+		prefix := compositeKey(webhookID)
+		var ids [][]byte
+		err := scanPrefix(tx.Bucket(boltDeliveryHooks), prefix, func(k, _ []byte) error {
+			ids = append(ids, k[len(prefix)+8:])
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for i := len(ids) - 1; i >= 0 && len(deliveries) < limit; i-- {
+			delivery, err := getDelivery(tx, ids[i])
+			if err != nil {
+				return err
+			}
+			deliveries = append(deliveries, delivery)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *boltWebhookDeliveryRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*entity.WebhookDelivery, error) {
+	var due *entity.WebhookDelivery
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		k, _ := tx.Bucket(boltDeliveryPending).Cursor().First()
+		if k == nil || keyTime(k).After(now) {
+			return ErrNotFound
+		}
+		var err error
+		if due, err = getDelivery(tx, k[8:]); err != nil {
+			return err
+		}
+		if err := unindexDelivery(tx, due); err != nil {
+			return err
+		}
+		next := now.Add(lease)
+		due.NextAttemptAt = &next
+		return saveDelivery(tx, due)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return due, nil
+}
+
+func (r *boltWebhookDeliveryRepository) Update(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		stored, err := getDelivery(tx, []byte(delivery.ID))
+		if err != nil {
+			return err
+		}
+		if err := unindexDelivery(tx, stored); err != nil {
+			return err
+		}
+		stored.Status = delivery.Status
+		stored.Attempts = delivery.Attempts
+		stored.NextAttemptAt = cloneTime(delivery.NextAttemptAt)
+		stored.LastAttemptAt = cloneTime(delivery.LastAttemptAt)
+		stored.ResponseStatus = delivery.ResponseStatus
+		stored.LastError = delivery.LastError
+		return saveDelivery(tx, stored)
+	})
+}
+
+func (r *boltWebhookDeliveryRepository) DeleteByWebhookID(ctx context.Context, webhookID string) (int64, error) {
+	var deleted int64
+	err := r.db.Update(func(tx *bbolt.Tx) error {
+		hooks := tx.Bucket(boltDeliveryHooks)
+		prefix := compositeKey(webhookID)
+		var keys [][]byte
+		err := scanPrefix(hooks, prefix, func(k, _ []byte) error {
+			keys = append(keys, copyKey(k))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			delivery, err := getDelivery(tx, key[len(prefix)+8:])
+			if err != nil {
+				return err
+			}
+			if err := unindexDelivery(tx, delivery); err != nil {
+				return err
+			}
+			if err := tx.Bucket(boltDeliveries).Delete([]byte(delivery.ID)); err != nil {
+				return err
+			}
+		}
+		deleted = int64(len(keys))
+		return deleteKeys(hooks, keys)
+	})
+	return deleted, err
+}
+
+func getDelivery(tx *bbolt.Tx, id []byte) (*entity.WebhookDelivery, error) {
+	var delivery entity.WebhookDelivery
+	if err := getDoc(tx.Bucket(boltDeliveries), id, &delivery); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// saveDelivery stores delivery and, while it is pending, indexes its next
+// attempt.
+func saveDelivery(tx *bbolt.Tx, delivery *entity.WebhookDelivery) error {
+	if err := putDoc(tx.Bucket(boltDeliveries), []byte(delivery.ID), delivery); err != nil {
+		return err
+	}
+	if key := deliveryDueKey(delivery); key != nil {
+		return tx.Bucket(boltDeliveryPending).Put(key, nil)
+	}
+	return nil
+}
+
+func unindexDelivery(tx *bbolt.Tx, delivery *entity.WebhookDelivery) error {
+	if key := deliveryDueKey(delivery); key != nil {
+		return tx.Bucket(boltDeliveryPending).Delete(key)
+	}
+	return nil
+}
+
+// deliveryDueKey returns the key of delivery in the index of pending
+// deliveries, or nil if it does not belong there.
+func deliveryDueKey(delivery *entity.WebhookDelivery) []byte {
+	if delivery.Status != entity.DeliveryPending || delivery.NextAttemptAt == nil {
+		return nil
+	}
+	return append(timeKey(*delivery.NextAttemptAt), delivery.ID...)
+}
+
+func deliveryHookKey(delivery *entity.WebhookDelivery) []byte {
+	return compositeKey(delivery.WebhookID, timeKey(delivery.CreatedAt), []byte(delivery.ID))
+}
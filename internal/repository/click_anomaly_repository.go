@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ClickAnomalyRepository stores flagged click spikes.
+type ClickAnomalyRepository interface {
+	// Create stores an anomaly, returning ErrDuplicate when the link already
+	// has one for the same window.
+	Create(ctx context.Context, anomaly *entity.ClickAnomaly) (*entity.ClickAnomaly, error)
+	// List returns the anomalies of the given links whose window starts in
+	// [from, to), oldest first.
+	List(ctx context.Context, linkIDs []string, from, to time.Time) ([]*entity.ClickAnomaly, error)
+	DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error)
+}
+
+type mongoClickAnomalyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoClickAnomalyRepository(db *mongo.Database) ClickAnomalyRepository {
+	return &mongoClickAnomalyRepository{
+		collection: db.Collection("click_anomalies"),
+	}
+}
+
+func (r *mongoClickAnomalyRepository) Create(ctx context.Context, anomaly *entity.ClickAnomaly) (*entity.ClickAnomaly, error) {
+	res, err := r.collection.InsertOne(ctx, anomaly)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		anomaly.ID = oid.Hex()
+	}
+	return anomaly, nil
+}
+
+func (r *mongoClickAnomalyRepository) List(ctx context.Context, linkIDs []string, from, to time.Time) ([]*entity.ClickAnomaly, error) {
+	anomalies := []*entity.ClickAnomaly{}
+	if len(linkIDs) == 0 {
+		return anomalies, nil
+	}
+	filter := bson.M{"linkId": bson.M{"$in": linkIDs}, "windowStart": bson.M{"$gte": from, "$lt": to}}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "windowStart", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &anomalies); err != nil {
+		return nil, err
+	}
+	return anomalies, nil
+}
+
+func (r *mongoClickAnomalyRepository) DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error) {
+	if len(linkIDs) == 0 {
+		return 0, nil
+	}
+	res, err := r.collection.DeleteMany(ctx, bson.M{"linkId": bson.M{"$in": linkIDs}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
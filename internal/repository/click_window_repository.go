@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ClickWindowRepository remembers when a visitor's click on a link was last
+// counted, so rapid repeats can be told apart from new clicks.
+type ClickWindowRepository interface {
+	// Claim reports whether a click by visitor on the link at now should be
+	// counted: it is when no click of theirs was counted within window
+	// before now. A successful claim starts a new window. Concurrent claims
+	// for the same visitor and link succeed at most once per window.
+	Claim(ctx context.Context, linkID, visitor string, now time.Time, window time.Duration) (bool, error)
+}
+
+type mongoClickWindowRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoClickWindowRepository(db *mongo.Database) ClickWindowRepository {
+	return &mongoClickWindowRepository{
+		collection: db.Collection("click_windows"),
+	}
+}
+
+func (r *mongoClickWindowRepository) Claim(ctx context.Context, linkID, visitor string, now time.Time, window time.Duration) (bool, error) {
+	// Only a claim whose window has passed matches. Otherwise the upsert
+	// tries to insert a second document with the same _id and fails, which
+	// is how an open window is detected atomically.
+	filter := bson.M{"_id": linkID + ":" + visitor, "countedAt": bson.M{"$lte": now.Add(-window)}}
+	update := bson.M{"$set": bson.M{"countedAt": now, "expiresAt": now.Add(window)}}
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,10 @@
+package repository
+
+import "errors"
+
+var (
+	// ErrNotFound is returned when the requested document does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrDuplicate is returned when a write would violate a unique constraint.
+	ErrDuplicate = errors.New("duplicate")
+)
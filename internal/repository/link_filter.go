@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+)
+
+// LinkSort is a key links can be ordered by.
+type LinkSort string
+
+const (
+	LinkSortCreatedAt LinkSort = "createdAt"
+	LinkSortClicks    LinkSort = "clicks"
+	LinkSortExpiresAt LinkSort = "expiresAt"
+)
+
+// LinkCursor is the position of the last link on the previous page. Only the
+// field matching the filter's sort key is used, with ID breaking ties.
+type LinkCursor struct {
+	ID        string     `json:"id"`
+	CreatedAt time.Time  `json:"createdAt,omitempty"`
+	Clicks    int        `json:"clicks,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// CursorFor returns the cursor positioned at link.
+func CursorFor(link *entity.Link) *LinkCursor {
+	return &LinkCursor{ID: link.ID, CreatedAt: link.CreatedAt, Clicks: link.Clicks, ExpiresAt: link.ExpiresAt}
+}
+
+// LinkFilter selects and orders links for LinkRepository.List. Zero-valued
+// fields do not filter. Links without an expiry sort before all others in
+// ascending order and after them in descending order.
+type LinkFilter struct {
+	OwnerID string
+	// Statuses keeps links whose status at Now is one of the given values.
+	// When empty, every link except those in the trash is kept.
+	Statuses    []entity.LinkStatus
+	Now         time.Time
+	CreatedFrom *time.Time // inclusive
+	CreatedTo   *time.Time // exclusive
+	Tag         string
+	// Host matches the host of the link's URL, ignoring case and port.
+	Host string
+	// TitleContains matches a case-insensitive substring of the title.
+	TitleContains string
+
+	SortBy     LinkSort
+	Descending bool
+	After      *LinkCursor
+	Limit      int // zero means no limit
+}
+
+// Matches reports whether link passes every filter in f, ignoring paging.
+func (f LinkFilter) Matches(link *entity.Link) bool {
+	if f.OwnerID != "" && link.OwnerID != f.OwnerID {
+		return false
+	}
+	status := link.StatusAt(f.Now)
+	if len(f.Statuses) == 0 {
+		if status == entity.LinkDeleted {
+			return false
+		}
+	} else if !containsStatus(f.Statuses, status) {
+		return false
+	}
+	if f.CreatedFrom != nil && link.CreatedAt.Before(*f.CreatedFrom) {
+		return false
+	}
+	if f.CreatedTo != nil && !link.CreatedAt.Before(*f.CreatedTo) {
+		return false
+	}
+	if f.Tag != "" && !containsString(link.Tags, f.Tag) {
+		return false
+	}
+	if f.Host != "" {
+		u, err := url.Parse(link.URL)
+		if err != nil || !strings.EqualFold(u.Hostname(), f.Host) {
+			return false
+		}
+	}
+	if f.TitleContains != "" && !strings.Contains(strings.ToLower(link.Title), strings.ToLower(f.TitleContains)) {
+		return false
+	}
+	return true
+}
+
+// Less reports whether a is listed before b under the filter's ordering.
+func (f LinkFilter) Less(a, b *entity.Link) bool {
+	return f.before(CursorFor(a), CursorFor(b))
+}
+
+func (f LinkFilter) before(a, b *LinkCursor) bool {
+	cmp := 0
+	switch f.SortBy {
+	case LinkSortClicks:
+		cmp = compareInts(a.Clicks, b.Clicks)
+	case LinkSortExpiresAt:
+		cmp = compareOptionalTimes(a.ExpiresAt, b.ExpiresAt)
+	default:
+		cmp = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if cmp == 0 {
+		cmp = strings.Compare(a.ID, b.ID)
+	}
+	if f.Descending {
+		return cmp > 0
+	}
+	return cmp < 0
+}
+
+// ApplyLinkFilter filters, orders and pages links in memory, for backends
+// that cannot express f as a query.
+func ApplyLinkFilter(links []*entity.Link, f LinkFilter) []*entity.Link {
+	matched := make([]*entity.Link, 0)
+	for _, link := range links {
+		if !f.Matches(link) {
+			continue
+		}
+		if f.After != nil && !f.before(f.After, CursorFor(link)) {
+			continue
+		}
+		matched = append(matched, link)
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return f.Less(matched[i], matched[j]) })
+	if f.Limit > 0 && len(matched) > f.Limit {
+		matched = matched[:f.Limit]
+	}
+	return matched
+}
+
+func containsStatus(statuses []entity.LinkStatus, status entity.LinkStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareOptionalTimes orders nil before any time.
+func compareOptionalTimes(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	return a.Compare(*b)
+}
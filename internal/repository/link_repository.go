@@ -3,21 +3,53 @@ package repository
 import (
 	"context"
 	"errors"
+	"regexp"
 	"time"
 
 	"github.com/hussainr95/link-in-bio-service/internal/entity"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// LinkRepository stores links. Implementations must pass the conformance
+// suite in package repotest, which pins down the behavior described here.
 type LinkRepository interface {
+	// Create assigns the link an ID, or returns ErrDuplicate if its slug is
+	// taken.
 	Create(ctx context.Context, link *entity.Link) (*entity.Link, error)
+	// GetByID returns ErrNotFound for unknown and malformed IDs.
 	GetByID(ctx context.Context, id string) (*entity.Link, error)
+	// GetByIDs returns each known link among ids once, in no particular
+	// order.
+	GetByIDs(ctx context.Context, ids []string) ([]*entity.Link, error)
+	// GetBySlug returns ErrNotFound for unknown slugs and the empty slug.
+	GetBySlug(ctx context.Context, slug string) (*entity.Link, error)
+	// Update saves the fields a client may change, keeping the owner,
+	// creation time and clicks, and returns the stored link. It returns
+	// ErrNotFound for unknown IDs and ErrDuplicate if the slug is taken.
 	Update(ctx context.Context, link *entity.Link) (*entity.Link, error)
+	// Delete permanently deletes a link. Deleting a missing link is not an
+	// error.
 	Delete(ctx context.Context, id string) error
+	// IncrementClicks atomically adds a click. A click on a missing link,
+	// which may have been deleted meanwhile, is ignored.
 	IncrementClicks(ctx context.Context, id string) error
-	DeleteExpired(ctx context.Context) error
+	// List returns the links selected by filter, in the filter's order.
+	List(ctx context.Context, filter LinkFilter) ([]*entity.Link, error)
+	ListArchived(ctx context.Context, ownerID string) ([]*entity.Link, error)
+	ListDeleted(ctx context.Context, ownerID string) ([]*entity.Link, error)
+	// ArchiveExpired marks every link outside the archive and the trash that
+	// expired before now as archived at now and returns the links it
+	// changed. A link expiring at now has not expired before it.
+	ArchiveExpired(ctx context.Context, now time.Time) ([]*entity.Link, error)
+	// PurgeArchived permanently deletes links archived before the cutoff and
+	// returns their IDs.
+	PurgeArchived(ctx context.Context, before time.Time) ([]string, error)
+	// PurgeDeleted permanently deletes links moved to the trash before the
+	// cutoff and returns their IDs.
+	PurgeDeleted(ctx context.Context, before time.Time) ([]string, error)
 }
 
 type mongoLinkRepository struct {
@@ -32,6 +64,9 @@ func NewMongoLinkRepository(db *mongo.Database) LinkRepository {
 
 func (r *mongoLinkRepository) Create(ctx context.Context, link *entity.Link) (*entity.Link, error) {
 	res, err := r.collection.InsertOne(ctx, link)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrDuplicate
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -43,17 +78,60 @@ func (r *mongoLinkRepository) Create(ctx context.Context, link *entity.Link) (*e
 
 func (r *mongoLinkRepository) GetByID(ctx context.Context, id string) (*entity.Link, error) {
 	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	var link entity.Link
+	err = r.collection.FindOne(ctx, bson.M{"_id": oid}).Decode(&link)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
+	return &link, nil
+}
 
+func (r *mongoLinkRepository) GetBySlug(ctx context.Context, slug string) (*entity.Link, error) {
+	if slug == "" {
+		return nil, ErrNotFound
+	}
 	var link entity.Link
-	if err := r.collection.FindOne(ctx, bson.M{"_id": oid}).Decode(&link); err != nil {
+	err := r.collection.FindOne(ctx, bson.M{"slug": slug}).Decode(&link)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
 		return nil, err
 	}
 	return &link, nil
 }
 
+// GetByIDs returns the links matching ids in no particular order. Unknown or
+// malformed IDs are skipped rather than reported.
+func (r *mongoLinkRepository) GetByIDs(ctx context.Context, ids []string) ([]*entity.Link, error) {
+	oids := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+			oids = append(oids, oid)
+		}
+	}
+
+	links := make([]*entity.Link, 0, len(oids))
+	if len(oids) == 0 {
+		return links, nil
+	}
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": oids}})
+	if err != nil {
+		return nil, err
+	}
+	if err := cursor.All(ctx, &links); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
 func (r *mongoLinkRepository) Update(ctx context.Context, link *entity.Link) (*entity.Link, error) {
 	if link.ID == "" {
 		return nil, errors.New("missing link ID")
@@ -61,20 +139,32 @@ func (r *mongoLinkRepository) Update(ctx context.Context, link *entity.Link) (*e
 
 	oid, err := primitive.ObjectIDFromHex(link.ID)
 	if err != nil {
-		return nil, err
+		return nil, ErrNotFound
 	}
 
 	filter := bson.M{"_id": oid}
-	update := bson.M{
-		"$set": bson.M{
-			"title":     link.Title,
-			"url":       link.URL,
-			"expiresAt": link.ExpiresAt,
-			"clicks":    link.Clicks,
-		},
+	set := bson.M{
+		"title":      link.Title,
+		"url":        link.URL,
+		"activeFrom": link.ActiveFrom,
+		"expiresAt":  link.ExpiresAt,
+		"archivedAt": link.ArchivedAt,
+		"deletedAt":  link.DeletedAt,
+		"tags":       link.Tags,
+	}
+	update := bson.M{"$set": set}
+	// The unique slug index covers every string, so links without a slug
+	// must not store an empty one.
+	if link.Slug == "" {
+		update["$unset"] = bson.M{"slug": ""}
+	} else {
+		set["slug"] = link.Slug
 	}
 
 	_, err = r.collection.UpdateOne(ctx, filter, update)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrDuplicate
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -85,7 +175,8 @@ func (r *mongoLinkRepository) Update(ctx context.Context, link *entity.Link) (*e
 func (r *mongoLinkRepository) Delete(ctx context.Context, id string) error {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return err
+		// No link has a malformed ID, so there is nothing to change.
+		return nil
 	}
 
 	_, err = r.collection.DeleteOne(ctx, bson.M{"_id": oid})
@@ -95,7 +186,8 @@ func (r *mongoLinkRepository) Delete(ctx context.Context, id string) error {
 func (r *mongoLinkRepository) IncrementClicks(ctx context.Context, id string) error {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return err
+		// No link has a malformed ID, so there is nothing to change.
+		return nil
 	}
 	filter := bson.M{"_id": oid}
 	update := bson.M{"$inc": bson.M{"clicks": 1}}
@@ -103,8 +195,231 @@ func (r *mongoLinkRepository) IncrementClicks(ctx context.Context, id string) er
 	return err
 }
 
-func (r *mongoLinkRepository) DeleteExpired(ctx context.Context) error {
-	// Delete all links with expiresAt before now
-	_, err := r.collection.DeleteMany(ctx, bson.M{"expiresAt": bson.M{"$lt": time.Now()}})
-	return err
+func (r *mongoLinkRepository) List(ctx context.Context, f LinkFilter) ([]*entity.Link, error) {
+	filter, err := mongoLinkFilter(f)
+	if err != nil {
+		return nil, err
+	}
+	field := string(f.SortBy)
+	if field == "" {
+		field = string(LinkSortCreatedAt)
+	}
+	dir := 1
+	if f.Descending {
+		dir = -1
+	}
+	opts := options.Find().SetSort(bson.D{{Key: field, Value: dir}, {Key: "_id", Value: dir}})
+	if f.Limit > 0 {
+		opts.SetLimit(int64(f.Limit))
+	}
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	links := make([]*entity.Link, 0)
+	if err := cursor.All(ctx, &links); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func (r *mongoLinkRepository) ListArchived(ctx context.Context, ownerID string) ([]*entity.Link, error) {
+	return r.listOwned(ctx, ownerID, "archivedAt")
+}
+
+func (r *mongoLinkRepository) ListDeleted(ctx context.Context, ownerID string) ([]*entity.Link, error) {
+	return r.listOwned(ctx, ownerID, "deletedAt")
+}
+
+// listOwned returns ownerID's links where the given timestamp field is set,
+// most recent first.
+func (r *mongoLinkRepository) listOwned(ctx context.Context, ownerID, setField string) ([]*entity.Link, error) {
+	filter := bson.M{"ownerId": ownerID, setField: bson.M{"$ne": nil}}
+	opts := options.Find().SetSort(bson.D{{Key: setField, Value: -1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	links := make([]*entity.Link, 0)
+	if err := cursor.All(ctx, &links); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func (r *mongoLinkRepository) ArchiveExpired(ctx context.Context, now time.Time) ([]*entity.Link, error) {
+	// Archive all links with expiresAt before now that are neither archived
+	// yet nor in the trash
+	filter := bson.M{"expiresAt": bson.M{"$lt": now}, "archivedAt": nil, "deletedAt": nil}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var expired []*entity.Link
+	if err := cursor.All(ctx, &expired); err != nil {
+		return nil, err
+	}
+
+	archived := make([]*entity.Link, 0, len(expired))
+	for _, link := range expired {
+		oid, err := primitive.ObjectIDFromHex(link.ID)
+		if err != nil {
+			continue
+		}
+		// Re-check archivedAt so that a link archived concurrently by another
+		// instance is only reported once.
+		res, err := r.collection.UpdateOne(ctx, bson.M{"_id": oid, "archivedAt": nil, "deletedAt": nil}, bson.M{"$set": bson.M{"archivedAt": now}})
+		if err != nil {
+			return nil, err
+		}
+		if res.ModifiedCount == 1 {
+			at := now
+			link.ArchivedAt = &at
+			archived = append(archived, link)
+		}
+	}
+	return archived, nil
+}
+
+func (r *mongoLinkRepository) PurgeArchived(ctx context.Context, before time.Time) ([]string, error) {
+	return r.purge(ctx, bson.M{"archivedAt": bson.M{"$lt": before}})
+}
+
+func (r *mongoLinkRepository) PurgeDeleted(ctx context.Context, before time.Time) ([]string, error) {
+	return r.purge(ctx, bson.M{"deletedAt": bson.M{"$lt": before}})
+}
+
+// purge deletes the links matching filter and returns their IDs, so callers
+// can clean up data that references them.
+func (r *mongoLinkRepository) purge(ctx context.Context, filter bson.M) ([]string, error) {
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	oids := make([]primitive.ObjectID, len(docs))
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		oids[i] = doc.ID
+		ids[i] = doc.ID.Hex()
+	}
+	if _, err := r.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": oids}}); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// mongoLinkFilter translates f into a query document.
+func mongoLinkFilter(f LinkFilter) (bson.M, error) {
+	and := bson.A{}
+	if f.OwnerID != "" {
+		and = append(and, bson.M{"ownerId": f.OwnerID})
+	}
+	if len(f.Statuses) == 0 {
+		and = append(and, bson.M{"deletedAt": nil})
+	} else {
+		statuses := bson.A{}
+		for _, status := range f.Statuses {
+			statuses = append(statuses, mongoStatusFilter(status, f.Now))
+		}
+		and = append(and, bson.M{"$or": statuses})
+	}
+	if f.CreatedFrom != nil {
+		and = append(and, bson.M{"createdAt": bson.M{"$gte": *f.CreatedFrom}})
+	}
+	if f.CreatedTo != nil {
+		and = append(and, bson.M{"createdAt": bson.M{"$lt": *f.CreatedTo}})
+	}
+	if f.Tag != "" {
+		and = append(and, bson.M{"tags": f.Tag})
+	}
+	if f.Host != "" {
+		// Scheme, optional userinfo, the host itself, then an optional port.
+		pattern := `^[A-Za-z][A-Za-z0-9+.-]*://([^/?#@]*@)?` + regexp.QuoteMeta(f.Host) + `(:[0-9]*)?([/?#]|$)`
+		and = append(and, bson.M{"url": primitive.Regex{Pattern: pattern, Options: "i"}})
+	}
+	if f.TitleContains != "" {
+		and = append(and, bson.M{"title": primitive.Regex{Pattern: regexp.QuoteMeta(f.TitleContains), Options: "i"}})
+	}
+	if f.After != nil {
+		after, err := mongoCursorFilter(f)
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, after)
+	}
+	return bson.M{"$and": and}, nil
+}
+
+// mongoStatusFilter mirrors entity.Link.StatusAt as a query.
+func mongoStatusFilter(status entity.LinkStatus, now time.Time) bson.M {
+	live := bson.M{"deletedAt": nil, "archivedAt": nil}
+	notExpired := bson.M{"$or": bson.A{bson.M{"expiresAt": nil}, bson.M{"expiresAt": bson.M{"$gt": now}}}}
+	switch status {
+	case entity.LinkDeleted:
+		return bson.M{"deletedAt": bson.M{"$ne": nil}}
+	case entity.LinkArchived:
+		return bson.M{"deletedAt": nil, "archivedAt": bson.M{"$ne": nil}}
+	case entity.LinkExpired:
+		return bson.M{"$and": bson.A{live, bson.M{"expiresAt": bson.M{"$lte": now}}}}
+	case entity.LinkScheduled:
+		return bson.M{"$and": bson.A{live, notExpired, bson.M{"activeFrom": bson.M{"$gt": now}}}}
+	case entity.LinkActive:
+		notScheduled := bson.M{"$or": bson.A{bson.M{"activeFrom": nil}, bson.M{"activeFrom": bson.M{"$lte": now}}}}
+		return bson.M{"$and": bson.A{live, notExpired, notScheduled}}
+	}
+	// Unknown statuses match nothing.
+	return bson.M{"_id": nil}
+}
+
+// mongoCursorFilter selects the links after f.After in the filter's order.
+func mongoCursorFilter(f LinkFilter) (bson.M, error) {
+	oid, err := primitive.ObjectIDFromHex(f.After.ID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	next, idNext := "$gt", bson.M{"$gt": oid}
+	if f.Descending {
+		next, idNext = "$lt", bson.M{"$lt": oid}
+	}
+
+	var field string
+	var value interface{}
+	switch f.SortBy {
+	case LinkSortClicks:
+		field, value = "clicks", f.After.Clicks
+	case LinkSortExpiresAt:
+		field = "expiresAt"
+		if f.After.ExpiresAt == nil {
+			// Links without an expiry sort first ascending and last descending.
+			if f.Descending {
+				return bson.M{"expiresAt": nil, "_id": idNext}, nil
+			}
+			return bson.M{"$or": bson.A{
+				bson.M{"expiresAt": nil, "_id": idNext},
+				bson.M{"expiresAt": bson.M{"$ne": nil}},
+			}}, nil
+		}
+		value = *f.After.ExpiresAt
+	default:
+		field, value = "createdAt", f.After.CreatedAt
+	}
+
+	or := bson.A{
+		bson.M{field: bson.M{next: value}},
+		bson.M{field: value, "_id": idNext},
+	}
+	if field == "expiresAt" && f.Descending {
+		or = append(or, bson.M{"expiresAt": nil})
+	}
+	return bson.M{"$or": or}, nil
 }
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+)
+
+// clickWindowSweepEvery is how many claims pass between sweeps of expired
+// windows, which stand in for the TTL index of the Mongo collection.
+const clickWindowSweepEvery = 1024
+
+type memoryClickWindowRepository struct {
+	mu      sync.Mutex
+	windows map[string]clickWindow // by link ID and visitor
+	claims  int
+}
+
+type clickWindow struct {
+	countedAt time.Time
+	expiresAt time.Time
+}
+
+func NewMemoryClickWindowRepository() ClickWindowRepository {
+	return &memoryClickWindowRepository{windows: make(map[string]clickWindow)}
+}
+
+func (r *memoryClickWindowRepository) Claim(ctx context.Context, linkID, visitor string, now time.Time, window time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.claims++; r.claims >= clickWindowSweepEvery {
+		r.claims = 0
+		for key, w := range r.windows {
+			if w.expiresAt.Before(now) {
+				delete(r.windows, key)
+			}
+		}
+	}
+	key := linkID + ":" + visitor
+	if w, ok := r.windows[key]; ok && w.countedAt.After(now.Add(-window)) {
+		return false, nil
+	}
+	r.windows[key] = clickWindow{countedAt: now, expiresAt: now.Add(window)}
+	return true, nil
+}
+
+// memoryClickAnomalyRepository allows one anomaly per link and window start,
+// like the unique index of the Mongo collection.
+type memoryClickAnomalyRepository struct {
+	mu        sync.RWMutex
+	anomalies []*entity.ClickAnomaly
+}
+
+func NewMemoryClickAnomalyRepository() ClickAnomalyRepository {
+	return &memoryClickAnomalyRepository{anomalies: make([]*entity.ClickAnomaly, 0)}
+}
+
+func (r *memoryClickAnomalyRepository) Create(ctx context.Context, anomaly *entity.ClickAnomaly) (*entity.ClickAnomaly, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.anomalies {
+		if existing.LinkID == anomaly.LinkID && existing.WindowStart.Equal(anomaly.WindowStart) {
+			return nil, ErrDuplicate
+		}
+	}
+	anomaly.ID = newID()
+	stored := *anomaly
+	r.anomalies = append(r.anomalies, &stored)
+	return anomaly, nil
+}
+
+func (r *memoryClickAnomalyRepository) List(ctx context.Context, linkIDs []string, from, to time.Time) ([]*entity.ClickAnomaly, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	anomalies := []*entity.ClickAnomaly{}
+	for _, anomaly := range r.anomalies {
+		if slices.Contains(linkIDs, anomaly.LinkID) && !anomaly.WindowStart.Before(from) && anomaly.WindowStart.Before(to) {
+			c := *anomaly
+			anomalies = append(anomalies, &c)
+		}
+	}
+	sort.SliceStable(anomalies, func(i, j int) bool { return anomalies[i].WindowStart.Before(anomalies[j].WindowStart) })
+	return anomalies, nil
+}
+
+func (r *memoryClickAnomalyRepository) DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := make([]*entity.ClickAnomaly, 0, len(r.anomalies))
+	for _, anomaly := range r.anomalies {
+		if !slices.Contains(linkIDs, anomaly.LinkID) {
+			kept = append(kept, anomaly)
+		}
+	}
+	deleted := int64(len(r.anomalies) - len(kept))
+	r.anomalies = kept
+	return deleted, nil
+}
+
+// memoryVisitorSketchRepository keeps one sketch per link and UTC day.
+type memoryVisitorSketchRepository struct {
+	mu       sync.RWMutex
+	sketches map[sketchID]*entity.VisitorSketch
+}
+
+type sketchID struct {
+	linkID string
+	day    int64
+}
+
+func NewMemoryVisitorSketchRepository() VisitorSketchRepository {
+	return &memoryVisitorSketchRepository{sketches: make(map[sketchID]*entity.VisitorSketch)}
+}
+
+func (r *memoryVisitorSketchRepository) Raise(ctx context.Context, linkID string, day time.Time, index uint16, rank uint8) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := sketchID{linkID, day.UnixNano()}
+	sketch, ok := r.sketches[id]
+	if !ok {
+		sketch = &entity.VisitorSketch{LinkID: linkID, Day: day.UTC(), Registers: make(map[uint16]uint8)}
+		r.sketches[id] = sketch
+	}
+	sketch.Registers[index] = max(sketch.Registers[index], rank)
+	return nil
+}
+
+func (r *memoryVisitorSketchRepository) List(ctx context.Context, linkIDs []string, from, to time.Time) ([]*entity.VisitorSketch, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	sketches := make([]*entity.VisitorSketch, 0)
+	for _, sketch := range r.sketches {
+		if slices.Contains(linkIDs, sketch.LinkID) && !sketch.Day.Before(from) && sketch.Day.Before(to) {
+			c := *sketch
+			c.Registers = make(map[uint16]uint8, len(sketch.Registers))
+			for index, rank := range sketch.Registers {
+				c.Registers[index] = rank
+			}
+			sketches = append(sketches, &c)
+		}
+	}
+	return sketches, nil
+}
+
+func (r *memoryVisitorSketchRepository) DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	for id := range r.sketches {
+		if slices.Contains(linkIDs, id.linkID) {
+			delete(r.sketches, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
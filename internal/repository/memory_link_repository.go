@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+)
+
+// memoryLinkRepository keeps links in a map guarded by a mutex, with an index
+// of slugs for redirects. Links are copied on the way in and out, so callers
+// never share the stored values.
+type memoryLinkRepository struct {
+	mu    sync.RWMutex
+	links map[string]*entity.Link
+	slugs map[string]string // slug to link ID
+}
+
+func NewMemoryLinkRepository() LinkRepository {
+	return &memoryLinkRepository{links: make(map[string]*entity.Link), slugs: make(map[string]string)}
+}
+
+func (r *memoryLinkRepository) Create(ctx context.Context, link *entity.Link) (*entity.Link, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.slugTaken(link.Slug, "") {
+		return nil, ErrDuplicate
+	}
+	link.ID = newID()
+	r.links[link.ID] = cloneLink(link)
+	r.index(link.Slug, link.ID)
+	return link, nil
+}
+
+func (r *memoryLinkRepository) GetByID(ctx context.Context, id string) (*entity.Link, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	link, ok := r.links[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneLink(link), nil
+}
+
+// GetByIDs returns the links matching ids in no particular order. Unknown IDs
+// are skipped rather than reported.
+func (r *memoryLinkRepository) GetByIDs(ctx context.Context, ids []string) ([]*entity.Link, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	links := make([]*entity.Link, 0, len(ids))
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if link, ok := r.links[id]; ok && !seen[id] {
+			seen[id] = true
+			links = append(links, cloneLink(link))
+		}
+	}
+	return links, nil
+}
+
+func (r *memoryLinkRepository) GetBySlug(ctx context.Context, slug string) (*entity.Link, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if id, ok := r.slugs[slug]; ok && slug != "" {
+		return cloneLink(r.links[id]), nil
+	}
+	return nil, ErrNotFound
+}
+
+// Update saves the fields a client may change, like the Mongo repository.
+func (r *memoryLinkRepository) Update(ctx context.Context, link *entity.Link) (*entity.Link, error) {
+	if link.ID == "" {
+		return nil, errors.New("missing link ID")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.links[link.ID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if r.slugTaken(link.Slug, link.ID) {
+		return nil, ErrDuplicate
+	}
+	updated := cloneLink(link)
+	delete(r.slugs, stored.Slug)
+	r.index(updated.Slug, stored.ID)
+	stored.Title = updated.Title
+	stored.URL = updated.URL
+	stored.Slug = updated.Slug
+	stored.ActiveFrom = updated.ActiveFrom
+	stored.ExpiresAt = updated.ExpiresAt
+	stored.ArchivedAt = updated.ArchivedAt
+	stored.DeletedAt = updated.DeletedAt
+	stored.Tags = updated.Tags
+	return cloneLink(stored), nil
+}
+
+func (r *memoryLinkRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.remove(id)
+	return nil
+}
+
+func (r *memoryLinkRepository) IncrementClicks(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if link, ok := r.links[id]; ok {
+		link.Clicks++
+	}
+	return nil
+}
+
+func (r *memoryLinkRepository) List(ctx context.Context, f LinkFilter) ([]*entity.Link, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	links := make([]*entity.Link, 0, len(r.links))
+	for _, link := range r.links {
+		links = append(links, link)
+	}
+	matched := ApplyLinkFilter(links, f)
+	for i, link := range matched {
+		matched[i] = cloneLink(link)
+	}
+	return matched, nil
+}
+
+func (r *memoryLinkRepository) ListArchived(ctx context.Context, ownerID string) ([]*entity.Link, error) {
+	return r.listOwned(ownerID, func(link *entity.Link) *time.Time { return link.ArchivedAt }), nil
+}
+
+func (r *memoryLinkRepository) ListDeleted(ctx context.Context, ownerID string) ([]*entity.Link, error) {
+	return r.listOwned(ownerID, func(link *entity.Link) *time.Time { return link.DeletedAt }), nil
+}
+
+// listOwned returns ownerID's links where the given timestamp is set, most
+// recent first.
+func (r *memoryLinkRepository) listOwned(ownerID string, at func(*entity.Link) *time.Time) []*entity.Link {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	links := make([]*entity.Link, 0)
+	for _, link := range r.links {
+		if link.OwnerID == ownerID && at(link) != nil {
+			links = append(links, cloneLink(link))
+		}
+	}
+	sort.Slice(links, func(i, j int) bool {
+		if cmp := at(links[i]).Compare(*at(links[j])); cmp != 0 {
+			return cmp > 0
+		}
+		return links[i].ID > links[j].ID
+	})
+	return links
+}
+
+func (r *memoryLinkRepository) ArchiveExpired(ctx context.Context, now time.Time) ([]*entity.Link, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	archived := make([]*entity.Link, 0)
+	for _, link := range r.links {
+		if link.ArchivedAt == nil && link.DeletedAt == nil && link.ExpiresAt != nil && link.ExpiresAt.Before(now) {
+			at := now
+			link.ArchivedAt = &at
+			archived = append(archived, cloneLink(link))
+		}
+	}
+	return archived, nil
+}
+
+func (r *memoryLinkRepository) PurgeArchived(ctx context.Context, before time.Time) ([]string, error) {
+	return r.purge(func(link *entity.Link) bool {
+		return link.ArchivedAt != nil && link.ArchivedAt.Before(before)
+	}), nil
+}
+
+func (r *memoryLinkRepository) PurgeDeleted(ctx context.Context, before time.Time) ([]string, error) {
+	return r.purge(func(link *entity.Link) bool {
+		return link.DeletedAt != nil && link.DeletedAt.Before(before)
+	}), nil
+}
+
+// purge deletes the links matching and returns their IDs.
+func (r *memoryLinkRepository) purge(matching func(*entity.Link) bool) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []string
+	for id, link := range r.links {
+		if matching(link) {
+			ids = append(ids, id)
+			r.remove(id)
+		}
+	}
+	return ids
+}
+
+// index records the link using slug. The caller must hold the lock.
+func (r *memoryLinkRepository) index(slug, id string) {
+	if slug != "" {
+		r.slugs[slug] = id
+	}
+}
+
+// remove deletes a link and its slug. The caller must hold the lock.
+func (r *memoryLinkRepository) remove(id string) {
+	if link, ok := r.links[id]; ok {
+		delete(r.slugs, link.Slug)
+		delete(r.links, id)
+	}
+}
+
+// slugTaken reports whether another link than exceptID uses slug. Links
+// without a slug never collide. The caller must hold the lock.
+func (r *memoryLinkRepository) slugTaken(slug, exceptID string) bool {
+	id, ok := r.slugs[slug]
+	return ok && slug != "" && id != exceptID
+}
+
+func cloneLink(link *entity.Link) *entity.Link {
+	c := *link
+	c.ActiveFrom = cloneTime(link.ActiveFrom)
+	c.ExpiresAt = cloneTime(link.ExpiresAt)
+	c.ArchivedAt = cloneTime(link.ArchivedAt)
+	c.DeletedAt = cloneTime(link.DeletedAt)
+	c.Tags = slices.Clone(link.Tags)
+	return &c
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+)
+
+// memoryProfileRepository keeps profiles in a map, enforcing unique handles
+// and one profile per user like the Mongo indexes do.
+type memoryProfileRepository struct {
+	mu       sync.RWMutex
+	profiles map[string]*entity.Profile
+}
+
+func NewMemoryProfileRepository() ProfileRepository {
+	return &memoryProfileRepository{profiles: make(map[string]*entity.Profile)}
+}
+
+func (r *memoryProfileRepository) Create(ctx context.Context, profile *entity.Profile) (*entity.Profile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.profiles {
+		if existing.Handle == profile.Handle || existing.UserID == profile.UserID {
+			return nil, ErrDuplicate
+		}
+	}
+	profile.ID = newID()
+	r.profiles[profile.ID] = cloneProfile(profile)
+	return profile, nil
+}
+
+func (r *memoryProfileRepository) GetByHandle(ctx context.Context, handle string) (*entity.Profile, error) {
+	return r.find(func(profile *entity.Profile) bool { return profile.Handle == handle })
+}
+
+func (r *memoryProfileRepository) GetByUserID(ctx context.Context, userID string) (*entity.Profile, error) {
+	return r.find(func(profile *entity.Profile) bool { return profile.UserID == userID })
+}
+
+// Update saves the fields a user may change, like the Mongo repository.
+func (r *memoryProfileRepository) Update(ctx context.Context, profile *entity.Profile) (*entity.Profile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.profiles[profile.ID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	for id, existing := range r.profiles {
+		if id != profile.ID && existing.Handle == profile.Handle {
+			return nil, ErrDuplicate
+		}
+	}
+	stored.Handle = profile.Handle
+	stored.DisplayName = profile.DisplayName
+	stored.AvatarURL = profile.AvatarURL
+	stored.Bio = profile.Bio
+	stored.Theme = profile.Theme
+	stored.LinkIDs = slices.Clone(profile.LinkIDs)
+	stored.UpdatedAt = profile.UpdatedAt
+	return cloneProfile(stored), nil
+}
+
+func (r *memoryProfileRepository) find(matching func(*entity.Profile) bool) (*entity.Profile, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, profile := range r.profiles {
+		if matching(profile) {
+			return cloneProfile(profile), nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func cloneProfile(profile *entity.Profile) *entity.Profile {
+	c := *profile
+	c.LinkIDs = slices.Clone(profile.LinkIDs)
+	return &c
+}
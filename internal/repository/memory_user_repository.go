@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+)
+
+// memoryUserRepository keeps users in a map, enforcing unique emails like the
+// Mongo index does.
+type memoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[string]*entity.User
+}
+
+func NewMemoryUserRepository() UserRepository {
+	return &memoryUserRepository{users: make(map[string]*entity.User)}
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user *entity.User) (*entity.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return nil, ErrDuplicate
+		}
+	}
+	user.ID = newID()
+	stored := *user
+	r.users[user.ID] = &stored
+	return user, nil
+}
+
+func (r *memoryUserRepository) GetByID(ctx context.Context, id string) (*entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c := *user
+	return &c, nil
+}
+
+// List returns all users, newest first.
+func (r *memoryUserRepository) List(ctx context.Context) ([]*entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	users := make([]*entity.User, 0, len(r.users))
+	for _, user := range r.users {
+		c := *user
+		users = append(users, &c)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.After(users[j].CreatedAt)
+		}
+		return users[i].ID > users[j].ID
+	})
+	return users, nil
+}
+
+// memoryAPIKeyRepository keeps API keys in a map with an index of key hashes,
+// which every authenticated request looks up.
+type memoryAPIKeyRepository struct {
+	mu     sync.RWMutex
+	keys   map[string]*entity.APIKey
+	hashes map[string]string // key hash to key ID
+}
+
+func NewMemoryAPIKeyRepository() APIKeyRepository {
+	return &memoryAPIKeyRepository{keys: make(map[string]*entity.APIKey), hashes: make(map[string]string)}
+}
+
+func (r *memoryAPIKeyRepository) Create(ctx context.Context, key *entity.APIKey) (*entity.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key.ID = newID()
+	r.keys[key.ID] = cloneAPIKey(key)
+	r.hashes[key.KeyHash] = key.ID
+	return key, nil
+}
+
+func (r *memoryAPIKeyRepository) GetByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	id, ok := r.hashes[hash]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneAPIKey(r.keys[id]), nil
+}
+
+// List returns all keys, newest first.
+func (r *memoryAPIKeyRepository) List(ctx context.Context) ([]*entity.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	keys := make([]*entity.APIKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		keys = append(keys, cloneAPIKey(key))
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.After(keys[j].CreatedAt)
+		}
+		return keys[i].ID > keys[j].ID
+	})
+	return keys, nil
+}
+
+func (r *memoryAPIKeyRepository) Revoke(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, ok := r.keys[id]
+	if !ok {
+		return ErrNotFound
+	}
+	key.Revoked = true
+	return nil
+}
+
+func (r *memoryAPIKeyRepository) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if key, ok := r.keys[id]; ok {
+		key.LastUsedAt = &at
+	}
+	return nil
+}
+
+func cloneAPIKey(key *entity.APIKey) *entity.APIKey {
+	c := *key
+	c.LastUsedAt = cloneTime(key.LastUsedAt)
+	return &c
+}
@@ -0,0 +1,340 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+)
+
+// memoryVisitRepository keeps visits per link so queries only scan the links
+// they ask for. Stored visits are never modified, so they are shared between
+// readers and copied only when handed to callers.
+type memoryVisitRepository struct {
+	mu     sync.RWMutex
+	byLink map[string][]*entity.Visit
+}
+
+func NewMemoryVisitRepository() VisitRepository {
+	return &memoryVisitRepository{byLink: make(map[string][]*entity.Visit)}
+}
+
+func (r *memoryVisitRepository) Create(ctx context.Context, visit *entity.Visit) (*entity.Visit, error) {
+	visit.ID = newID()
+	stored := *visit
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byLink[visit.LinkID] = append(r.byLink[visit.LinkID], &stored)
+	return visit, nil
+}
+
+func (r *memoryVisitRepository) DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	for _, id := range linkIDs {
+		deleted += int64(len(r.byLink[id]))
+		delete(r.byLink, id)
+	}
+	return deleted, nil
+}
+
+func (r *memoryVisitRepository) CountByInterval(ctx context.Context, q VisitQuery, interval entity.StatsInterval, loc *time.Location) ([]*entity.StatsBucket, error) {
+	counts := map[time.Time]int64{}
+	r.scan(q, func(visit *entity.Visit) {
+		counts[BucketStart(visit.VisitedAt, interval, loc)]++
+	})
+	return sortedBuckets(counts), nil
+}
+
+func (r *memoryVisitRepository) CountByLink(ctx context.Context, q VisitQuery) (map[string]int64, error) {
+	counts := map[string]int64{}
+	r.scan(q, func(visit *entity.Visit) {
+		counts[visit.LinkID]++
+	})
+	return counts, nil
+}
+
+func (r *memoryVisitRepository) Count(ctx context.Context, q VisitQuery) (int64, error) {
+	var count int64
+	r.scan(q, func(*entity.Visit) { count++ })
+	return count, nil
+}
+
+func (r *memoryVisitRepository) TopValues(ctx context.Context, q VisitQuery, dimension entity.BreakdownDimension, limit int) ([]*entity.BreakdownItem, error) {
+	if _, ok := VisitFields[dimension]; !ok {
+		return []*entity.BreakdownItem{}, nil
+	}
+	counts := map[string]int64{}
+	r.scan(q, func(visit *entity.Visit) {
+		counts[VisitDimension(visit, dimension)]++
+	})
+	return topItems(counts, limit), nil
+}
+
+func (r *memoryVisitRepository) SourceCounts(ctx context.Context, from, to time.Time, minClicks int64) ([]*LinkSources, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	sources := make([]*LinkSources, 0)
+	for linkID, visits := range r.byLink {
+		perVisitor := map[string]int64{}
+		for _, visit := range visits {
+			if !visit.Bot && !visit.Repeat && !visit.VisitedAt.Before(from) && visit.VisitedAt.Before(to) {
+				perVisitor[visit.VisitorHash]++
+			}
+		}
+		s := &LinkSources{LinkID: linkID, Sources: int64(len(perVisitor))}
+		for _, clicks := range perVisitor {
+			s.Clicks += clicks
+			s.TopSourceClicks = max(s.TopSourceClicks, clicks)
+		}
+		if s.Sources > 0 && s.Clicks >= minClicks {
+			sources = append(sources, s)
+		}
+	}
+	return sources, nil
+}
+
+// Each copies the matching visits before calling fn, so fn may use the
+// repository without deadlocking.
+func (r *memoryVisitRepository) Each(ctx context.Context, q VisitQuery, fn func(*entity.Visit) error) error {
+	var visits []entity.Visit
+	r.scan(q, func(visit *entity.Visit) {
+		visits = append(visits, *visit)
+	})
+	sort.SliceStable(visits, func(i, j int) bool { return visits[i].VisitedAt.Before(visits[j].VisitedAt) })
+	for i := range visits {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(&visits[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *memoryVisitRepository) Rollup(ctx context.Context, from, to time.Time) ([]*entity.VisitRollup, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	builder := NewRollupBuilder(entity.IntervalHour)
+	for _, visits := range r.byLink {
+		for _, visit := range visits {
+			if !visit.VisitedAt.Before(from) && visit.VisitedAt.Before(to) {
+				builder.AddVisit(visit, 1)
+			}
+		}
+	}
+	return builder.Rollups(), nil
+}
+
+func (r *memoryVisitRepository) Oldest(ctx context.Context) (time.Time, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var oldest time.Time
+	found := false
+	for _, visits := range r.byLink {
+		for _, visit := range visits {
+			if !found || visit.VisitedAt.Before(oldest) {
+				oldest, found = visit.VisitedAt, true
+			}
+		}
+	}
+	if !found {
+		return time.Time{}, ErrNotFound
+	}
+	return oldest, nil
+}
+
+func (r *memoryVisitRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	for linkID, visits := range r.byLink {
+		kept := make([]*entity.Visit, 0, len(visits))
+		for _, visit := range visits {
+			if !visit.VisitedAt.Before(before) {
+				kept = append(kept, visit)
+			}
+		}
+		deleted += int64(len(visits) - len(kept))
+		if len(kept) == 0 {
+			delete(r.byLink, linkID)
+		} else {
+			r.byLink[linkID] = kept
+		}
+	}
+	return deleted, nil
+}
+
+// scan calls fn with every visit matching q while holding the read lock, so
+// fn must not call back into the repository.
+func (r *memoryVisitRepository) scan(q VisitQuery, fn func(*entity.Visit)) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, id := range uniqueStrings(q.LinkIDs) {
+		for _, visit := range r.byLink[id] {
+			if q.Matches(visit) {
+				fn(visit)
+			}
+		}
+	}
+}
+
+// memoryVisitRollupRepository keeps rollups keyed by link, granularity and
+// start, like the unique index of the Mongo collection.
+type memoryVisitRollupRepository struct {
+	mu        sync.RWMutex
+	rollups   map[rollupID]*entity.VisitRollup
+	watermark time.Time
+}
+
+type rollupID struct {
+	linkID      string
+	granularity entity.StatsInterval
+	start       int64
+}
+
+func NewMemoryVisitRollupRepository() VisitRollupRepository {
+	return &memoryVisitRollupRepository{rollups: make(map[rollupID]*entity.VisitRollup)}
+}
+
+func (r *memoryVisitRollupRepository) Save(ctx context.Context, rollups []*entity.VisitRollup) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rollup := range rollups {
+		stored := *rollup
+		stored.Values = make([]*entity.RollupValue, len(rollup.Values))
+		for i, value := range rollup.Values {
+			v := *value
+			stored.Values[i] = &v
+		}
+		r.rollups[rollupID{rollup.LinkID, rollup.Granularity, rollup.Start.UnixNano()}] = &stored
+	}
+	return nil
+}
+
+func (r *memoryVisitRollupRepository) CountByInterval(ctx context.Context, q RollupQuery, interval entity.StatsInterval, loc *time.Location) ([]*entity.StatsBucket, error) {
+	counts := map[time.Time]int64{}
+	r.scan(q, func(rollup *entity.VisitRollup) {
+		if rollup.Clicks > 0 {
+			counts[BucketStart(rollup.Start, interval, loc)] += rollup.Clicks
+		}
+	})
+	return sortedBuckets(counts), nil
+}
+
+func (r *memoryVisitRollupRepository) CountByLink(ctx context.Context, q RollupQuery) (map[string]int64, error) {
+	counts := map[string]int64{}
+	r.scan(q, func(rollup *entity.VisitRollup) {
+		if rollup.Clicks > 0 {
+			counts[rollup.LinkID] += rollup.Clicks
+		}
+	})
+	return counts, nil
+}
+
+func (r *memoryVisitRollupRepository) Count(ctx context.Context, q RollupQuery) (int64, int64, error) {
+	var clicks, bots int64
+	r.scan(q, func(rollup *entity.VisitRollup) {
+		clicks += rollup.Clicks
+		bots += rollup.BotHits
+	})
+	return clicks, bots, nil
+}
+
+func (r *memoryVisitRollupRepository) TopValues(ctx context.Context, q RollupQuery, dimension entity.BreakdownDimension, limit int) ([]*entity.BreakdownItem, error) {
+	counts := map[string]int64{}
+	r.scan(q, func(rollup *entity.VisitRollup) {
+		for _, value := range rollup.Values {
+			if value.Dimension == dimension {
+				counts[value.Value] += value.Clicks
+			}
+		}
+	})
+	return topItems(counts, limit), nil
+}
+
+func (r *memoryVisitRollupRepository) DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	for id, rollup := range r.rollups {
+		if slices.Contains(linkIDs, rollup.LinkID) {
+			delete(r.rollups, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (r *memoryVisitRollupRepository) Watermark(ctx context.Context) (time.Time, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.watermark, nil
+}
+
+func (r *memoryVisitRollupRepository) SetWatermark(ctx context.Context, t time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.watermark = t
+	return nil
+}
+
+// scan calls fn with every rollup matching q while holding the read lock.
+func (r *memoryVisitRollupRepository) scan(q RollupQuery, fn func(*entity.VisitRollup)) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, rollup := range r.rollups {
+		if q.Matches(rollup) {
+			fn(rollup)
+		}
+	}
+}
+
+// sortedBuckets turns counts per bucket start into buckets in chronological
+// order.
+func sortedBuckets(counts map[time.Time]int64) []*entity.StatsBucket {
+	buckets := make([]*entity.StatsBucket, 0, len(counts))
+	for start, clicks := range counts {
+		buckets = append(buckets, &entity.StatsBucket{Start: start, Clicks: clicks})
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Start.Before(buckets[j].Start) })
+	return buckets
+}
+
+// topItems returns the limit values with the most clicks, ties broken by
+// value.
+func topItems(counts map[string]int64, limit int) []*entity.BreakdownItem {
+	items := make([]*entity.BreakdownItem, 0, len(counts))
+	for value, clicks := range counts {
+		items = append(items, &entity.BreakdownItem{Value: value, Clicks: clicks})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Clicks != items[j].Clicks {
+			return items[i].Clicks > items[j].Clicks
+		}
+		return items[i].Value < items[j].Value
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
+
+// uniqueStrings returns values without duplicates, keeping the first
+// occurrence of each.
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
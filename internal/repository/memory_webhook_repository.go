@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+)
+
+type memoryWebhookRepository struct {
+	mu       sync.RWMutex
+	webhooks map[string]*entity.Webhook
+}
+
+func NewMemoryWebhookRepository() WebhookRepository {
+	return &memoryWebhookRepository{webhooks: make(map[string]*entity.Webhook)}
+}
+
+func (r *memoryWebhookRepository) Create(ctx context.Context, webhook *entity.Webhook) (*entity.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	webhook.ID = newID()
+	r.webhooks[webhook.ID] = cloneWebhook(webhook)
+	return webhook, nil
+}
+
+func (r *memoryWebhookRepository) GetByID(ctx context.Context, id string) (*entity.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneWebhook(webhook), nil
+}
+
+func (r *memoryWebhookRepository) ListByOwner(ctx context.Context, ownerID string) ([]*entity.Webhook, error) {
+	return r.find(func(webhook *entity.Webhook) bool { return webhook.OwnerID == ownerID }), nil
+}
+
+func (r *memoryWebhookRepository) ListSubscribed(ctx context.Context, ownerID string, event entity.WebhookEvent) ([]*entity.Webhook, error) {
+	return r.find(func(webhook *entity.Webhook) bool {
+		return webhook.OwnerID == ownerID && slices.Contains(webhook.Events, event)
+	}), nil
+}
+
+// find returns the matching webhooks, newest first.
+func (r *memoryWebhookRepository) find(matching func(*entity.Webhook) bool) []*entity.Webhook {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	webhooks := make([]*entity.Webhook, 0)
+	for _, webhook := range r.webhooks {
+		if matching(webhook) {
+			webhooks = append(webhooks, cloneWebhook(webhook))
+		}
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		if !webhooks[i].CreatedAt.Equal(webhooks[j].CreatedAt) {
+			return webhooks[i].CreatedAt.After(webhooks[j].CreatedAt)
+		}
+		return webhooks[i].ID > webhooks[j].ID
+	})
+	return webhooks
+}
+
+func (r *memoryWebhookRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.webhooks[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.webhooks, id)
+	return nil
+}
+
+func cloneWebhook(webhook *entity.Webhook) *entity.Webhook {
+	c := *webhook
+	c.Events = slices.Clone(webhook.Events)
+	return &c
+}
+
+type memoryWebhookDeliveryRepository struct {
+	mu         sync.Mutex
+	deliveries map[string]*entity.WebhookDelivery
+}
+
+func NewMemoryWebhookDeliveryRepository() WebhookDeliveryRepository {
+	return &memoryWebhookDeliveryRepository{deliveries: make(map[string]*entity.WebhookDelivery)}
+}
+
+func (r *memoryWebhookDeliveryRepository) Create(ctx context.Context, delivery *entity.WebhookDelivery) (*entity.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delivery.ID = newID()
+	r.deliveries[delivery.ID] = cloneDelivery(delivery)
+	return delivery, nil
+}
+
+func (r *memoryWebhookDeliveryRepository) GetByID(ctx context.Context, id string) (*entity.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delivery, ok := r.deliveries[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneDelivery(delivery), nil
+}
+
+func (r *memoryWebhookDeliveryRepository) ListByWebhook(ctx context.Context, webhookID string, limit int) ([]*entity.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	deliveries := make([]*entity.WebhookDelivery, 0)
+	for _, delivery := range r.deliveries {
+		if delivery.WebhookID == webhookID {
+			deliveries = append(deliveries, cloneDelivery(delivery))
+		}
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+		}
+		return deliveries[i].ID > deliveries[j].ID
+	})
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// ClaimDue holds the lock while choosing and postponing the delivery, so
+// concurrent workers never claim the same one.
+func (r *memoryWebhookDeliveryRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*entity.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var due *entity.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.Status != entity.DeliveryPending || delivery.NextAttemptAt == nil || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		if due == nil || delivery.NextAttemptAt.Before(*due.NextAttemptAt) {
+			due = delivery
+		}
+	}
+	if due == nil {
+		return nil, ErrNotFound
+	}
+	next := now.Add(lease)
+	due.NextAttemptAt = &next
+	return cloneDelivery(due), nil
+}
+
+func (r *memoryWebhookDeliveryRepository) Update(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.deliveries[delivery.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.Status = delivery.Status
+	stored.Attempts = delivery.Attempts
+	stored.NextAttemptAt = cloneTime(delivery.NextAttemptAt)
+	stored.LastAttemptAt = cloneTime(delivery.LastAttemptAt)
+	stored.ResponseStatus = delivery.ResponseStatus
+	stored.LastError = delivery.LastError
+	return nil
+}
+
+func (r *memoryWebhookDeliveryRepository) DeleteByWebhookID(ctx context.Context, webhookID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	for id, delivery := range r.deliveries {
+		if delivery.WebhookID == webhookID {
+			delete(r.deliveries, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func cloneDelivery(delivery *entity.WebhookDelivery) *entity.WebhookDelivery {
+	c := *delivery
+	c.Payload = slices.Clone(delivery.Payload)
+	c.NextAttemptAt = cloneTime(delivery.NextAttemptAt)
+	c.LastAttemptAt = cloneTime(delivery.LastAttemptAt)
+	return &c
+}
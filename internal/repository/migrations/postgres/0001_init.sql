@@ -0,0 +1,166 @@
+-- Times are milliseconds since the Unix epoch. Optional strings are stored
+-- as '' like the Mongo documents omit them. Link IDs use the C collation so
+-- they order bytewise, as cursors compare them in Go.
+
+CREATE TABLE users (
+	id         TEXT PRIMARY KEY,
+	name       TEXT NOT NULL,
+	email      TEXT NOT NULL UNIQUE,
+	created_at BIGINT NOT NULL
+);
+
+CREATE TABLE api_keys (
+	id           TEXT PRIMARY KEY,
+	user_id      TEXT NOT NULL,
+	name         TEXT NOT NULL,
+	prefix       TEXT NOT NULL,
+	key_hash     TEXT NOT NULL UNIQUE,
+	created_at   BIGINT NOT NULL,
+	last_used_at BIGINT,
+	revoked      BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+-- slug is NULL rather than '' when unset, so only real slugs must be unique.
+-- host and title_lower are derived from url and title for filtering.
+CREATE TABLE links (
+	id          TEXT COLLATE "C" PRIMARY KEY,
+	owner_id    TEXT NOT NULL,
+	slug        TEXT UNIQUE,
+	title       TEXT NOT NULL,
+	title_lower TEXT NOT NULL,
+	url         TEXT NOT NULL,
+	host        TEXT NOT NULL,
+	created_at  BIGINT NOT NULL,
+	active_from BIGINT,
+	expires_at  BIGINT,
+	archived_at BIGINT,
+	deleted_at  BIGINT,
+	clicks      BIGINT NOT NULL DEFAULT 0
+);
+CREATE INDEX links_owner_created ON links (owner_id, created_at);
+CREATE INDEX links_expires ON links (expires_at);
+CREATE INDEX links_archived ON links (archived_at);
+CREATE INDEX links_deleted ON links (deleted_at);
+
+CREATE TABLE link_tags (
+	link_id  TEXT NOT NULL,
+	position INTEGER NOT NULL,
+	tag      TEXT NOT NULL,
+	PRIMARY KEY (link_id, position)
+);
+CREATE INDEX link_tags_tag ON link_tags (tag, link_id);
+
+-- link_ids is a JSON array, kept in the owner's order.
+CREATE TABLE profiles (
+	id           TEXT PRIMARY KEY,
+	user_id      TEXT NOT NULL UNIQUE,
+	handle       TEXT NOT NULL UNIQUE,
+	display_name TEXT NOT NULL,
+	avatar_url   TEXT NOT NULL,
+	bio          TEXT NOT NULL,
+	theme        TEXT NOT NULL,
+	link_ids     TEXT NOT NULL,
+	created_at   BIGINT NOT NULL,
+	updated_at   BIGINT NOT NULL
+);
+
+CREATE TABLE visits (
+	id            TEXT PRIMARY KEY,
+	link_id       TEXT NOT NULL,
+	visited_at    BIGINT NOT NULL,
+	referrer      TEXT NOT NULL,
+	referrer_host TEXT NOT NULL,
+	browser       TEXT NOT NULL,
+	os            TEXT NOT NULL,
+	device        TEXT NOT NULL,
+	language      TEXT NOT NULL,
+	country       TEXT NOT NULL,
+	visitor_hash  TEXT NOT NULL,
+	is_bot        BOOLEAN NOT NULL,
+	bot_name      TEXT NOT NULL,
+	is_repeat     BOOLEAN NOT NULL
+);
+CREATE INDEX visits_link_visited ON visits (link_id, visited_at);
+CREATE INDEX visits_visited ON visits (visited_at);
+
+-- One row per raised register of a link's daily HyperLogLog sketch.
+CREATE TABLE visitor_sketch_registers (
+	link_id  TEXT NOT NULL,
+	day      BIGINT NOT NULL,
+	register INTEGER NOT NULL,
+	rank     INTEGER NOT NULL,
+	PRIMARY KEY (link_id, day, register)
+);
+
+CREATE TABLE click_windows (
+	id         TEXT PRIMARY KEY,
+	counted_at BIGINT NOT NULL,
+	expires_at BIGINT NOT NULL
+);
+CREATE INDEX click_windows_expires ON click_windows (expires_at);
+
+CREATE TABLE click_anomalies (
+	id                TEXT PRIMARY KEY,
+	link_id           TEXT NOT NULL,
+	window_start      BIGINT NOT NULL,
+	window_end        BIGINT NOT NULL,
+	clicks            BIGINT NOT NULL,
+	sources           BIGINT NOT NULL,
+	top_source_clicks BIGINT NOT NULL,
+	baseline          DOUBLE PRECISION NOT NULL,
+	detected_at       BIGINT NOT NULL,
+	UNIQUE (link_id, window_start)
+);
+
+CREATE TABLE visit_rollups (
+	link_id     TEXT NOT NULL,
+	granularity TEXT NOT NULL,
+	start       BIGINT NOT NULL,
+	clicks      BIGINT NOT NULL,
+	bot_hits    BIGINT NOT NULL,
+	PRIMARY KEY (link_id, granularity, start)
+);
+
+CREATE TABLE visit_rollup_values (
+	link_id     TEXT NOT NULL,
+	granularity TEXT NOT NULL,
+	start       BIGINT NOT NULL,
+	dimension   TEXT NOT NULL,
+	value       TEXT NOT NULL,
+	clicks      BIGINT NOT NULL,
+	PRIMARY KEY (link_id, granularity, start, dimension, value)
+);
+
+CREATE TABLE rollup_state (
+	name         TEXT PRIMARY KEY,
+	rolled_up_to BIGINT NOT NULL
+);
+
+-- events is a JSON array of event names.
+CREATE TABLE webhooks (
+	id         TEXT PRIMARY KEY,
+	owner_id   TEXT NOT NULL,
+	url        TEXT NOT NULL,
+	events     TEXT NOT NULL,
+	secret     TEXT NOT NULL,
+	created_at BIGINT NOT NULL
+);
+CREATE INDEX webhooks_owner ON webhooks (owner_id, created_at);
+
+CREATE TABLE webhook_deliveries (
+	id              TEXT PRIMARY KEY,
+	webhook_id      TEXT NOT NULL,
+	owner_id        TEXT NOT NULL,
+	event           TEXT NOT NULL,
+	payload         TEXT NOT NULL,
+	status          TEXT NOT NULL,
+	redelivery_of   TEXT NOT NULL,
+	attempts        INTEGER NOT NULL,
+	next_attempt_at BIGINT,
+	last_attempt_at BIGINT,
+	response_status INTEGER NOT NULL,
+	last_error      TEXT NOT NULL,
+	created_at      BIGINT NOT NULL
+);
+CREATE INDEX webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX webhook_deliveries_webhook ON webhook_deliveries (webhook_id, created_at);
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureMongoIndexes creates the indexes the Mongo repositories rely on.
+// It is safe to call on every start-up; existing indexes are left untouched.
+func EnsureMongoIndexes(ctx context.Context, db *mongo.Database) error {
+	indexes := map[string][]mongo.IndexModel{
+		"api_keys": {
+			{Keys: bson.D{{Key: "keyHash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"users": {
+			{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"links": {
+			{Keys: bson.D{{Key: "ownerId", Value: 1}, {Key: "createdAt", Value: -1}}},
+			{Keys: bson.D{{Key: "ownerId", Value: 1}, {Key: "tags", Value: 1}}},
+			{Keys: bson.D{{Key: "expiresAt", Value: 1}}},
+			{Keys: bson.D{{Key: "archivedAt", Value: 1}}},
+			{Keys: bson.D{{Key: "deletedAt", Value: 1}}},
+			// Partial so that links created before slugs existed do not collide.
+			{Keys: bson.D{{Key: "slug", Value: 1}}, Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"slug": bson.M{"$type": "string"}})},
+		},
+		"profiles": {
+			{Keys: bson.D{{Key: "handle", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "userId", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"click_anomalies": {
+			{Keys: bson.D{{Key: "linkId", Value: 1}, {Key: "windowStart", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		// Claims only matter for the dedup window; let MongoDB drop them after.
+		"click_windows": {
+			{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"visitor_sketches": {
+			{Keys: bson.D{{Key: "linkId", Value: 1}, {Key: "day", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"webhooks": {
+			{Keys: bson.D{{Key: "ownerId", Value: 1}, {Key: "events", Value: 1}}},
+		},
+		"webhook_deliveries": {
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}},
+			{Keys: bson.D{{Key: "webhookId", Value: 1}, {Key: "createdAt", Value: -1}}},
+		},
+		"visit_rollups": {
+			{Keys: bson.D{{Key: "linkId", Value: 1}, {Key: "granularity", Value: 1}, {Key: "start", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"visits": {
+			{Keys: bson.D{{Key: "linkId", Value: 1}, {Key: "visitedAt", Value: 1}}},
+			{Keys: bson.D{{Key: "visitedAt", Value: 1}}},
+		},
+	}
+	for collection, models := range indexes {
+		if _, err := db.Collection(collection).Indexes().CreateMany(ctx, models); err != nil {
+			return err
+		}
+	}
+	return nil
+}
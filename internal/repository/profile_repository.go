@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type ProfileRepository interface {
+	Create(ctx context.Context, profile *entity.Profile) (*entity.Profile, error)
+	GetByHandle(ctx context.Context, handle string) (*entity.Profile, error)
+	GetByUserID(ctx context.Context, userID string) (*entity.Profile, error)
+	Update(ctx context.Context, profile *entity.Profile) (*entity.Profile, error)
+}
+
+type mongoProfileRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoProfileRepository(db *mongo.Database) ProfileRepository {
+	return &mongoProfileRepository{
+		collection: db.Collection("profiles"),
+	}
+}
+
+func (r *mongoProfileRepository) Create(ctx context.Context, profile *entity.Profile) (*entity.Profile, error) {
+	res, err := r.collection.InsertOne(ctx, profile)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		profile.ID = oid.Hex()
+	}
+	return profile, nil
+}
+
+func (r *mongoProfileRepository) GetByHandle(ctx context.Context, handle string) (*entity.Profile, error) {
+	return r.findOne(ctx, bson.M{"handle": handle})
+}
+
+func (r *mongoProfileRepository) GetByUserID(ctx context.Context, userID string) (*entity.Profile, error) {
+	return r.findOne(ctx, bson.M{"userId": userID})
+}
+
+func (r *mongoProfileRepository) Update(ctx context.Context, profile *entity.Profile) (*entity.Profile, error) {
+	oid, err := primitive.ObjectIDFromHex(profile.ID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"handle":      profile.Handle,
+			"displayName": profile.DisplayName,
+			"avatarUrl":   profile.AvatarURL,
+			"bio":         profile.Bio,
+			"theme":       profile.Theme,
+			"linkIds":     profile.LinkIDs,
+			"updatedAt":   profile.UpdatedAt,
+		},
+	}
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": oid}, update)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, err
+	}
+	if res.MatchedCount == 0 {
+		return nil, ErrNotFound
+	}
+	return r.findOne(ctx, bson.M{"_id": oid})
+}
+
+func (r *mongoProfileRepository) findOne(ctx context.Context, filter bson.M) (*entity.Profile, error) {
+	var profile entity.Profile
+	err := r.collection.FindOne(ctx, filter).Decode(&profile)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Repositories bundles one implementation of every repository, so a storage
+// backend can be picked in one place.
+type Repositories struct {
+	Links          LinkRepository
+	Visits         VisitRepository
+	APIKeys        APIKeyRepository
+	Users          UserRepository
+	Profiles       ProfileRepository
+	Sketches       VisitorSketchRepository
+	ClickWindows   ClickWindowRepository
+	ClickAnomalies ClickAnomalyRepository
+	VisitRollups   VisitRollupRepository
+	Webhooks       WebhookRepository
+	Deliveries     WebhookDeliveryRepository
+}
+
+// NewMongoRepositories returns the repositories backed by db. Call
+// EnsureMongoIndexes first.
+func NewMongoRepositories(db *mongo.Database) *Repositories {
+	return &Repositories{
+		Links:          NewMongoLinkRepository(db),
+		Visits:         NewMongoVisitRepository(db),
+		APIKeys:        NewMongoAPIKeyRepository(db),
+		Users:          NewMongoUserRepository(db),
+		Profiles:       NewMongoProfileRepository(db),
+		Sketches:       NewMongoVisitorSketchRepository(db),
+		ClickWindows:   NewMongoClickWindowRepository(db),
+		ClickAnomalies: NewMongoClickAnomalyRepository(db),
+		VisitRollups:   NewMongoVisitRollupRepository(db),
+		Webhooks:       NewMongoWebhookRepository(db),
+		Deliveries:     NewMongoWebhookDeliveryRepository(db),
+	}
+}
+
+// NewMemoryRepositories returns repositories that keep everything in process
+// memory. Data is lost on exit, so they suit local runs and demos.
+func NewMemoryRepositories() *Repositories {
+	return &Repositories{
+		Links:          NewMemoryLinkRepository(),
+		Visits:         NewMemoryVisitRepository(),
+		APIKeys:        NewMemoryAPIKeyRepository(),
+		Users:          NewMemoryUserRepository(),
+		Profiles:       NewMemoryProfileRepository(),
+		Sketches:       NewMemoryVisitorSketchRepository(),
+		ClickWindows:   NewMemoryClickWindowRepository(),
+		ClickAnomalies: NewMemoryClickAnomalyRepository(),
+		VisitRollups:   NewMemoryVisitRollupRepository(),
+		Webhooks:       NewMemoryWebhookRepository(),
+		Deliveries:     NewMemoryWebhookDeliveryRepository(),
+	}
+}
+
+// NewSQLRepositories returns the repositories backed by db. Call MigrateSQL
+// first.
+func NewSQLRepositories(db *SQLDB) *Repositories {
+	return &Repositories{
+		Links:          NewSQLLinkRepository(db),
+		Visits:         NewSQLVisitRepository(db),
+		APIKeys:        NewSQLAPIKeyRepository(db),
+		Users:          NewSQLUserRepository(db),
+		Profiles:       NewSQLProfileRepository(db),
+		Sketches:       NewSQLVisitorSketchRepository(db),
+		ClickWindows:   NewSQLClickWindowRepository(db),
+		ClickAnomalies: NewSQLClickAnomalyRepository(db),
+		VisitRollups:   NewSQLVisitRollupRepository(db),
+		Webhooks:       NewSQLWebhookRepository(db),
+		Deliveries:     NewSQLWebhookDeliveryRepository(db),
+	}
+}
+
+// NewBoltRepositories returns the repositories backed by the bbolt data file
+// db. Open it with OpenBolt.
+func NewBoltRepositories(db *bbolt.DB) *Repositories {
+	return &Repositories{
+		Links:          NewBoltLinkRepository(db),
+		Visits:         NewBoltVisitRepository(db),
+		APIKeys:        NewBoltAPIKeyRepository(db),
+		Users:          NewBoltUserRepository(db),
+		Profiles:       NewBoltProfileRepository(db),
+		Sketches:       NewBoltVisitorSketchRepository(db),
+		ClickWindows:   NewBoltClickWindowRepository(db),
+		ClickAnomalies: NewBoltClickAnomalyRepository(db),
+		VisitRollups:   NewBoltVisitRollupRepository(db),
+		Webhooks:       NewBoltWebhookRepository(db),
+		Deliveries:     NewBoltWebhookDeliveryRepository(db),
+	}
+}
+
+// newID returns an ID for backends that do not assign their own, shaped like
+// the Mongo ones and sorting in creation order.
+func newID() string {
+	return primitive.NewObjectID().Hex()
+}
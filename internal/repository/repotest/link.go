@@ -0,0 +1,530 @@
+// Package repotest holds conformance suites for implementations of the
+// repository interfaces. They pin down the behavior the usecases rely on but
+// the interfaces cannot express, such as which errors missing records give
+// and whether time bounds are inclusive, so every storage backend and test
+// double behaves like the Mongo repositories.
+//
+// Backends may store times with millisecond precision, so the suites only use
+// whole milliseconds.
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	owner = "conformance-owner"
+	// unknownID is well formed for the Mongo repositories but never stored.
+	unknownID = "0123456789abcdef01234567"
+	// malformedID cannot be an ID in any backend.
+	malformedID = "not-an-id"
+)
+
+// TestLinkRepository runs the link repository conformance suite. newRepo must
+// return an empty repository each time it is called.
+func TestLinkRepository(t *testing.T, newRepo func(t *testing.T) repository.LinkRepository) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, repo repository.LinkRepository)
+	}{
+		{"CreateAssignsIDs", testCreateAssignsIDs},
+		{"GetMissing", testGetMissing},
+		{"GetByIDsSkipsMissing", testGetByIDsSkipsMissing},
+		{"UniqueSlugs", testUniqueSlugs},
+		{"UpdateMissing", testUpdateMissing},
+		{"UpdateKeepsServerFields", testUpdateKeepsServerFields},
+		{"ReturnsCopies", testReturnsCopies},
+		{"DeleteMissing", testDeleteMissing},
+		{"IncrementClicksMissing", testIncrementClicksMissing},
+		{"IncrementClicksConcurrently", testIncrementClicksConcurrently},
+		{"ArchiveExpired", testArchiveExpired},
+		{"PurgeArchived", testPurgeArchived},
+		{"PurgeDeleted", testPurgeDeleted},
+		{"List", testList},
+		{"ListArchivedAndDeleted", testListArchivedAndDeleted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepo(t))
+		})
+	}
+}
+
+func testCreateAssignsIDs(t *testing.T, repo repository.LinkRepository) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	expires := now.Add(time.Hour)
+
+	link := &entity.Link{OwnerID: owner, Slug: "launch", Title: "Launch", URL: "http://example.com", CreatedAt: now, ExpiresAt: &expires, Tags: []string{"b", "a"}}
+	created, err := repo.Create(ctx, link)
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.ID)
+	assert.Equal(t, created.ID, link.ID, "Create sets the ID on the link passed in")
+	other := create(t, repo, &entity.Link{OwnerID: owner, URL: "http://example.org"})
+	assert.NotEqual(t, created.ID, other.ID)
+
+	stored, err := repo.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, owner, stored.OwnerID)
+	assert.Equal(t, "launch", stored.Slug)
+	assert.Equal(t, "Launch", stored.Title)
+	assert.Equal(t, "http://example.com", stored.URL)
+	assert.Equal(t, []string{"b", "a"}, stored.Tags, "tags keep their order")
+	assert.Zero(t, stored.Clicks)
+	assertTime(t, now, &stored.CreatedAt)
+	assertTime(t, expires, stored.ExpiresAt)
+	assert.Nil(t, stored.ActiveFrom)
+	assert.Nil(t, stored.ArchivedAt)
+	assert.Nil(t, stored.DeletedAt)
+}
+
+func testGetMissing(t *testing.T, repo repository.LinkRepository) {
+	ctx := context.Background()
+	create(t, repo, &entity.Link{OwnerID: owner, URL: "http://example.com"})
+
+	for _, id := range []string{unknownID, malformedID, ""} {
+		_, err := repo.GetByID(ctx, id)
+		assert.ErrorIs(t, err, repository.ErrNotFound, "GetByID(%q)", id)
+	}
+	_, err := repo.GetBySlug(ctx, "missing")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	_, err = repo.GetBySlug(ctx, "")
+	assert.ErrorIs(t, err, repository.ErrNotFound, "links without a slug are not found by the empty slug")
+}
+
+func testGetByIDsSkipsMissing(t *testing.T, repo repository.LinkRepository) {
+	ctx := context.Background()
+	first := create(t, repo, &entity.Link{OwnerID: owner, URL: "http://example.com"})
+	second := create(t, repo, &entity.Link{OwnerID: owner, URL: "http://example.org"})
+
+	links, err := repo.GetByIDs(ctx, []string{second.ID, unknownID, first.ID, malformedID, first.ID})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{first.ID, second.ID}, linkIDs(links), "each link is returned once")
+
+	links, err = repo.GetByIDs(ctx, nil)
+	require.NoError(t, err)
+	assert.NotNil(t, links)
+	assert.Empty(t, links)
+}
+
+func testUniqueSlugs(t *testing.T, repo repository.LinkRepository) {
+	ctx := context.Background()
+	first := create(t, repo, &entity.Link{OwnerID: owner, Slug: "launch", URL: "http://example.com"})
+	_, err := repo.Create(ctx, &entity.Link{OwnerID: "someone-else", Slug: "launch", URL: "http://example.org"})
+	assert.ErrorIs(t, err, repository.ErrDuplicate, "slugs are unique across owners")
+	// Links without a slug never collide, however they were saved.
+	for i := 0; i < 2; i++ {
+		link := create(t, repo, &entity.Link{OwnerID: owner, URL: "http://example.org"})
+		link.Title = "Untitled"
+		_, err = repo.Update(ctx, link)
+		require.NoError(t, err)
+	}
+
+	// Renaming frees the old slug and claims the new one.
+	first.Slug = "spring"
+	_, err = repo.Update(ctx, first)
+	require.NoError(t, err)
+	_, err = repo.GetBySlug(ctx, "launch")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	found, err := repo.GetBySlug(ctx, "spring")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, found.ID)
+
+	second := create(t, repo, &entity.Link{OwnerID: owner, Slug: "launch", URL: "http://example.org"})
+	second.Slug = "spring"
+	_, err = repo.Update(ctx, second)
+	assert.ErrorIs(t, err, repository.ErrDuplicate)
+	found, err = repo.GetBySlug(ctx, "launch")
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, found.ID, "a rejected update changes nothing")
+
+	// Saving a link under its own slug is not a conflict.
+	first.Title = "Spring"
+	_, err = repo.Update(ctx, first)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Delete(ctx, first.ID))
+	_, err = repo.GetBySlug(ctx, "spring")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	create(t, repo, &entity.Link{OwnerID: owner, Slug: "spring", URL: "http://example.net"})
+}
+
+func testUpdateMissing(t *testing.T, repo repository.LinkRepository) {
+	ctx := context.Background()
+
+	_, err := repo.Update(ctx, &entity.Link{OwnerID: owner, URL: "http://example.com"})
+	assert.Error(t, err, "updating a link without an ID fails")
+	assert.NotErrorIs(t, err, repository.ErrNotFound)
+
+	for _, id := range []string{unknownID, malformedID} {
+		_, err := repo.Update(ctx, &entity.Link{ID: id, OwnerID: owner, Slug: "ghost", URL: "http://example.com"})
+		assert.ErrorIs(t, err, repository.ErrNotFound, "Update(%q)", id)
+		_, err = repo.GetByID(ctx, id)
+		assert.ErrorIs(t, err, repository.ErrNotFound, "Update(%q) does not create the link", id)
+	}
+	_, err = repo.GetBySlug(ctx, "ghost")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func testUpdateKeepsServerFields(t *testing.T, repo repository.LinkRepository) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	link := create(t, repo, &entity.Link{OwnerID: owner, URL: "http://example.com", CreatedAt: now, Tags: []string{"promo"}})
+	require.NoError(t, repo.IncrementClicks(ctx, link.ID))
+
+	// Update saves the fields a client may change and leaves the owner,
+	// creation time and clicks alone.
+	activeFrom, expires, archived, deleted := now.Add(time.Hour), now.Add(2*time.Hour), now.Add(3*time.Hour), now.Add(4*time.Hour)
+	updated, err := repo.Update(ctx, &entity.Link{
+		ID: link.ID, OwnerID: "someone-else", Slug: "spring", Title: "Spring", URL: "http://example.org",
+		CreatedAt: now.Add(-time.Hour), ActiveFrom: &activeFrom, ExpiresAt: &expires, ArchivedAt: &archived, DeletedAt: &deleted,
+		Tags: []string{"b", "a"}, Clicks: 100,
+	})
+	require.NoError(t, err)
+	stored, err := repo.GetByID(ctx, link.ID)
+	require.NoError(t, err)
+	for _, got := range []*entity.Link{updated, stored} {
+		assert.Equal(t, link.ID, got.ID)
+		assert.Equal(t, owner, got.OwnerID)
+		assert.Equal(t, 1, got.Clicks)
+		assertTime(t, now, &got.CreatedAt)
+		assert.Equal(t, "spring", got.Slug)
+		assert.Equal(t, "Spring", got.Title)
+		assert.Equal(t, "http://example.org", got.URL)
+		assert.Equal(t, []string{"b", "a"}, got.Tags)
+		assertTime(t, activeFrom, got.ActiveFrom)
+		assertTime(t, expires, got.ExpiresAt)
+		assertTime(t, archived, got.ArchivedAt)
+		assertTime(t, deleted, got.DeletedAt)
+	}
+
+	// Clearing optional fields clears them.
+	stored.Slug, stored.Tags = "", nil
+	stored.ActiveFrom, stored.ExpiresAt, stored.ArchivedAt, stored.DeletedAt = nil, nil, nil, nil
+	_, err = repo.Update(ctx, stored)
+	require.NoError(t, err)
+	cleared, err := repo.GetByID(ctx, link.ID)
+	require.NoError(t, err)
+	assert.Empty(t, cleared.Slug)
+	assert.Empty(t, cleared.Tags)
+	assert.Nil(t, cleared.ActiveFrom)
+	assert.Nil(t, cleared.ExpiresAt)
+	assert.Nil(t, cleared.ArchivedAt)
+	assert.Nil(t, cleared.DeletedAt)
+	_, err = repo.GetBySlug(ctx, "spring")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func testReturnsCopies(t *testing.T, repo repository.LinkRepository) {
+	ctx := context.Background()
+	link := create(t, repo, &entity.Link{OwnerID: owner, Slug: "launch", URL: "http://example.com", Tags: []string{"promo"}})
+
+	// Changes to links passed in or handed out only reach the store through
+	// Update.
+	link.Title = "changed"
+	link.Tags[0] = "changed"
+	fetched, err := repo.GetByID(ctx, link.ID)
+	require.NoError(t, err)
+	fetched.Clicks = 100
+	fetched.Tags[0] = "changed"
+	bySlug, err := repo.GetBySlug(ctx, "launch")
+	require.NoError(t, err)
+	bySlug.URL = "http://changed.example"
+	require.NoError(t, repo.IncrementClicks(ctx, link.ID))
+
+	stored, err := repo.GetByID(ctx, link.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.Title)
+	assert.Equal(t, "http://example.com", stored.URL)
+	assert.Equal(t, []string{"promo"}, stored.Tags)
+	assert.Equal(t, 1, stored.Clicks)
+	assert.Equal(t, 100, fetched.Clicks, "links handed out are not updated behind the caller's back")
+}
+
+func testDeleteMissing(t *testing.T, repo repository.LinkRepository) {
+	ctx := context.Background()
+	link := create(t, repo, &entity.Link{OwnerID: owner, Slug: "launch", URL: "http://example.com"})
+
+	// Deleting is idempotent: a link that is already gone is not an error.
+	for _, id := range []string{unknownID, malformedID} {
+		assert.NoError(t, repo.Delete(ctx, id), "Delete(%q)", id)
+	}
+	require.NoError(t, repo.Delete(ctx, link.ID))
+	assert.NoError(t, repo.Delete(ctx, link.ID))
+	_, err := repo.GetByID(ctx, link.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	_, err = repo.GetBySlug(ctx, "launch")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func testIncrementClicksMissing(t *testing.T, repo repository.LinkRepository) {
+	ctx := context.Background()
+
+	// A click can race the link's deletion, so counting it on a missing link
+	// is not an error.
+	for _, id := range []string{unknownID, malformedID} {
+		assert.NoError(t, repo.IncrementClicks(ctx, id), "IncrementClicks(%q)", id)
+		_, err := repo.GetByID(ctx, id)
+		assert.ErrorIs(t, err, repository.ErrNotFound, "IncrementClicks(%q) does not create the link", id)
+	}
+}
+
+func testIncrementClicksConcurrently(t *testing.T, repo repository.LinkRepository) {
+	ctx := context.Background()
+	link := create(t, repo, &entity.Link{OwnerID: owner, URL: "http://example.com"})
+	other := create(t, repo, &entity.Link{OwnerID: owner, URL: "http://example.org"})
+
+	const workers, clicks = 20, 10
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := 0; c < clicks; c++ {
+				assert.NoError(t, repo.IncrementClicks(ctx, link.ID))
+			}
+		}()
+	}
+	wg.Wait()
+
+	stored, err := repo.GetByID(ctx, link.ID)
+	require.NoError(t, err)
+	assert.Equal(t, workers*clicks, stored.Clicks, "no click is lost")
+	untouched, err := repo.GetByID(ctx, other.ID)
+	require.NoError(t, err)
+	assert.Zero(t, untouched.Clicks)
+}
+
+func testArchiveExpired(t *testing.T, repo repository.LinkRepository) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	past, future, earlier := now.Add(-time.Minute), now.Add(time.Minute), now.Add(-time.Hour)
+
+	expired := create(t, repo, &entity.Link{OwnerID: owner, URL: "http://example.com", ExpiresAt: &past})
+	// A link expiring exactly now has not expired before now.
+	expiringNow := create(t, repo, &entity.Link{OwnerID: owner, URL: "http://example.com", ExpiresAt: &now})
+	create(t, repo, &entity.Link{OwnerID: owner, URL: "http://example.com", ExpiresAt: &future})
+	create(t, repo, &entity.Link{OwnerID: owner, URL: "http://example.com"})
+	alreadyArchived := create(t, repo, &entity.Link{OwnerID: owner, URL: "http://example.com", ExpiresAt: &past, ArchivedAt: &earlier})
+	// Links in the trash are left alone until they are restored.
+	trashed := create(t, repo, &entity.Link{OwnerID: owner, URL: "http://example.com", ExpiresAt: &past, DeletedAt: &earlier})
+
+	archived, err := repo.ArchiveExpired(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{expired.ID}, linkIDs(archived))
+	for _, link := range archived {
+		assertTime(t, now, link.ArchivedAt)
+	}
+	stored, err := repo.GetByID(ctx, expired.ID)
+	require.NoError(t, err)
+	assertTime(t, now, stored.ArchivedAt)
+	stored, err = repo.GetByID(ctx, alreadyArchived.ID)
+	require.NoError(t, err)
+	assertTime(t, earlier, stored.ArchivedAt, "archived links keep their archive time")
+
+	archived, err = repo.ArchiveExpired(ctx, now)
+	require.NoError(t, err)
+	assert.Empty(t, archived, "links are archived once")
+
+	archived, err = repo.ArchiveExpired(ctx, now.Add(time.Millisecond))
+	require.NoError(t, err)
+	assert.Equal(t, []string{expiringNow.ID}, linkIDs(archived))
+
+	// Restoring an archived link by clearing ArchivedAt lets it expire again.
+	stored.ArchivedAt = nil
+	_, err = repo.Update(ctx, stored)
+	require.NoError(t, err)
+	archived, err = repo.ArchiveExpired(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{alreadyArchived.ID}, linkIDs(archived))
+
+	// So does restoring a link from the trash.
+	trashed.DeletedAt = nil
+	_, err = repo.Update(ctx, trashed)
+	require.NoError(t, err)
+	archived, err = repo.ArchiveExpired(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{trashed.ID}, linkIDs(archived))
+}
+
+func testPurgeArchived(t *testing.T, repo repository.LinkRepository) {
+	testPurge(t, repo, func(link *entity.Link, at *time.Time) { link.ArchivedAt = at }, repo.PurgeArchived)
+}
+
+func testPurgeDeleted(t *testing.T, repo repository.LinkRepository) {
+	testPurge(t, repo, func(link *entity.Link, at *time.Time) { link.DeletedAt = at }, repo.PurgeDeleted)
+}
+
+// testPurge checks that purge permanently deletes the links whose timestamp,
+// set by mark, lies strictly before the cutoff.
+func testPurge(t *testing.T, repo repository.LinkRepository, mark func(*entity.Link, *time.Time), purge func(context.Context, time.Time) ([]string, error)) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	old := now.Add(-time.Hour)
+
+	var stale *entity.Link
+	for i, at := range []*time.Time{&old, &now, nil} {
+		link := &entity.Link{OwnerID: owner, Slug: fmt.Sprintf("slug-%d", i), URL: "http://example.com"}
+		mark(link, at)
+		created := create(t, repo, link)
+		if i == 0 {
+			stale = created
+		}
+	}
+
+	purged, err := purge(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{stale.ID}, purged)
+	_, err = repo.GetByID(ctx, stale.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	create(t, repo, &entity.Link{OwnerID: owner, Slug: "slug-0", URL: "http://example.com"})
+	for _, slug := range []string{"slug-1", "slug-2"} {
+		_, err := repo.GetBySlug(ctx, slug)
+		assert.NoError(t, err, "links marked at or after the cutoff are kept")
+	}
+
+	purged, err = purge(ctx, now)
+	require.NoError(t, err)
+	assert.Empty(t, purged)
+}
+
+// testList pages through the repository's listings under a range of
+// filters and compares them with ApplyLinkFilter.
+func testList(t *testing.T, repo repository.LinkRepository) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	hosts := []string{"http://Example.com/a", "https://example.com:8443/b", "http://other.org"}
+	titles := []string{"Spring Launch", "100% off_sale", "Newsletter"}
+	var all []*entity.Link
+	for i := 0; i < 12; i++ {
+		link := &entity.Link{
+			OwnerID:   owner,
+			Title:     titles[i%3],
+			URL:       hosts[i%3],
+			CreatedAt: now.Add(-time.Duration(i/2) * time.Hour),
+			Tags:      []string{fmt.Sprintf("t%d", i%2)},
+		}
+		switch i % 4 {
+		case 1:
+			expires := now.Add(time.Duration(i%3) * time.Hour)
+			link.ExpiresAt = &expires
+		case 2:
+			expires := now.Add(-time.Hour)
+			link.ExpiresAt = &expires
+			link.ArchivedAt = &now
+		case 3:
+			if i == 11 {
+				link.DeletedAt = &now
+			}
+			from := now.Add(time.Hour)
+			link.ActiveFrom = &from
+		}
+		created, err := repo.Create(ctx, link)
+		require.NoError(t, err)
+		for c := 0; c < i%5; c++ {
+			require.NoError(t, repo.IncrementClicks(ctx, created.ID))
+		}
+	}
+	_, err := repo.Create(ctx, &entity.Link{OwnerID: "someone-else", URL: "http://example.com"})
+	require.NoError(t, err)
+	all, err = repo.List(ctx, repository.LinkFilter{OwnerID: owner, Statuses: []entity.LinkStatus{
+		entity.LinkScheduled, entity.LinkActive, entity.LinkExpired, entity.LinkArchived, entity.LinkDeleted}, Now: now})
+	require.NoError(t, err)
+	require.Len(t, all, 12)
+
+	from := now.Add(-3 * time.Hour)
+	filters := []repository.LinkFilter{
+		{OwnerID: owner},
+		{OwnerID: owner, Statuses: []entity.LinkStatus{entity.LinkActive}},
+		{OwnerID: owner, Statuses: []entity.LinkStatus{entity.LinkScheduled, entity.LinkArchived}},
+		{OwnerID: owner, Statuses: []entity.LinkStatus{entity.LinkDeleted}},
+		{OwnerID: owner, CreatedFrom: &from, Tag: "t1"},
+		{OwnerID: owner, Host: "EXAMPLE.com"},
+		{OwnerID: owner, TitleContains: "LAUNCH"},
+		{OwnerID: owner, TitleContains: "0% off_"},
+		{OwnerID: owner, SortBy: repository.LinkSortClicks, Descending: true},
+		{OwnerID: owner, SortBy: repository.LinkSortExpiresAt},
+		{OwnerID: owner, SortBy: repository.LinkSortExpiresAt, Descending: true},
+	}
+	for i, f := range filters {
+		f.Now = now
+		want := linkIDs(repository.ApplyLinkFilter(all, f))
+
+		// Page through two links at a time.
+		var got []string
+		f.Limit = 2
+		for {
+			page, err := repo.List(ctx, f)
+			require.NoError(t, err)
+			got = append(got, linkIDs(page)...)
+			if len(page) < f.Limit {
+				break
+			}
+			f.After = repository.CursorFor(page[len(page)-1])
+		}
+		assert.Equal(t, want, got, "filter %d", i)
+	}
+}
+
+func testListArchivedAndDeleted(t *testing.T, repo repository.LinkRepository) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	times := []time.Time{now.Add(-2 * time.Hour), now, now.Add(-time.Hour)}
+
+	var archived, deleted []string
+	for _, at := range times {
+		at := at
+		link := create(t, repo, &entity.Link{OwnerID: owner, URL: "http://example.com", ArchivedAt: &at})
+		archived = append(archived, link.ID)
+		link = create(t, repo, &entity.Link{OwnerID: owner, URL: "http://example.com", DeletedAt: &at})
+		deleted = append(deleted, link.ID)
+	}
+	create(t, repo, &entity.Link{OwnerID: owner, URL: "http://example.com"})
+	create(t, repo, &entity.Link{OwnerID: "someone-else", URL: "http://example.com", ArchivedAt: &now, DeletedAt: &now})
+
+	// Both list the owner's links, most recently archived or deleted first.
+	links, err := repo.ListArchived(ctx, owner)
+	require.NoError(t, err)
+	assert.Equal(t, []string{archived[1], archived[2], archived[0]}, linkIDs(links))
+	links, err = repo.ListDeleted(ctx, owner)
+	require.NoError(t, err)
+	assert.Equal(t, []string{deleted[1], deleted[2], deleted[0]}, linkIDs(links))
+
+	links, err = repo.ListArchived(ctx, "nobody")
+	require.NoError(t, err)
+	assert.NotNil(t, links)
+	assert.Empty(t, links)
+}
+
+func create(t *testing.T, repo repository.LinkRepository, link *entity.Link) *entity.Link {
+	t.Helper()
+	created, err := repo.Create(context.Background(), link)
+	require.NoError(t, err)
+	return created
+}
+
+// assertTime asserts that got is set to the same instant as want, whatever
+// its location.
+func assertTime(t *testing.T, want time.Time, got *time.Time, msgAndArgs ...any) {
+	t.Helper()
+	if assert.NotNil(t, got, msgAndArgs...) {
+		assert.True(t, want.Equal(*got), "want %v, got %v", want, *got)
+	}
+}
+
+func linkIDs(links []*entity.Link) []string {
+	ids := make([]string, len(links))
+	for i, link := range links {
+		ids[i] = link.ID
+	}
+	return ids
+}
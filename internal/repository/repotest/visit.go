@@ -0,0 +1,334 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestVisitRepository runs the visit repository conformance suite. newRepo
+// must return an empty repository each time it is called.
+func TestVisitRepository(t *testing.T, newRepo func(t *testing.T) repository.VisitRepository) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, repo repository.VisitRepository)
+	}{
+		{"CreateAssignsIDs", testCreateVisitAssignsIDs},
+		{"Count", testCount},
+		{"CountByInterval", testCountByInterval},
+		{"TopValues", testTopValues},
+		{"Each", testEach},
+		{"SourceCounts", testSourceCounts},
+		{"Rollup", testRollup},
+		{"OldestAndDeleteBefore", testOldestAndDeleteBefore},
+		{"DeleteByLinkIDs", testDeleteByLinkIDs},
+		{"CreateConcurrently", testCreateConcurrently},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newRepo(t))
+		})
+	}
+}
+
+// visitBase is the start of the window the visit tests query, a Monday.
+var visitBase = time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+// visitQuery selects the human visits of links a and b in the three hours
+// from visitBase.
+var visitQuery = repository.VisitQuery{LinkIDs: []string{"link-a", "link-b"}, From: visitBase, To: visitBase.Add(3 * time.Hour)}
+
+// seedVisits records visits around the edges of visitQuery: one a
+// millisecond before it and one at its end, which it leaves out, and bot and
+// repeat visits within it.
+func seedVisits(t *testing.T, repo repository.VisitRepository) {
+	t.Helper()
+	visits := []*entity.Visit{
+		{LinkID: "link-a", VisitedAt: visitBase.Add(-time.Millisecond), Country: "DE", VisitorHash: "x"},
+		{LinkID: "link-a", VisitedAt: visitBase, Country: "DE", Browser: "Firefox", VisitorHash: "x"},
+		{LinkID: "link-a", VisitedAt: visitBase.Add(30 * time.Minute), Country: "DE", VisitorHash: "x"},
+		{LinkID: "link-a", VisitedAt: visitBase.Add(90 * time.Minute), VisitorHash: "y"},
+		{LinkID: "link-a", VisitedAt: visitBase.Add(2 * time.Hour), Country: "US", Bot: true, BotName: "Googlebot"},
+		{LinkID: "link-a", VisitedAt: visitBase.Add(2*time.Hour + time.Minute), Country: "US", VisitorHash: "x", Repeat: true},
+		{LinkID: "link-a", VisitedAt: visitBase.Add(3 * time.Hour), Country: "DE", VisitorHash: "x"},
+		{LinkID: "link-b", VisitedAt: visitBase.Add(10 * time.Minute), Country: "FR", VisitorHash: "z"},
+		{LinkID: "link-c", VisitedAt: visitBase.Add(10 * time.Minute), Country: "FR", VisitorHash: "w"},
+	}
+	for _, visit := range visits {
+		_, err := repo.Create(context.Background(), visit)
+		require.NoError(t, err)
+	}
+}
+
+func testCreateVisitAssignsIDs(t *testing.T, repo repository.VisitRepository) {
+	ctx := context.Background()
+
+	visit := &entity.Visit{LinkID: "link-a", VisitedAt: visitBase}
+	created, err := repo.Create(ctx, visit)
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.ID)
+	assert.Equal(t, created.ID, visit.ID, "Create sets the ID on the visit passed in")
+	other, err := repo.Create(ctx, &entity.Visit{LinkID: "link-a", VisitedAt: visitBase})
+	require.NoError(t, err)
+	assert.NotEmpty(t, other.ID)
+	assert.NotEqual(t, created.ID, other.ID)
+
+	// The stored visits keep the IDs Create returned.
+	var ids []string
+	err = repo.Each(ctx, visitQuery, func(visit *entity.Visit) error {
+		ids = append(ids, visit.ID)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{created.ID, other.ID}, ids)
+}
+
+func testCount(t *testing.T, repo repository.VisitRepository) {
+	ctx := context.Background()
+	seedVisits(t, repo)
+
+	// Queries cover [From, To) and leave bots and repeats out by default.
+	for _, tc := range []struct {
+		q      repository.VisitQuery
+		counts map[string]int64
+	}{
+		{visitQuery, map[string]int64{"link-a": 3, "link-b": 1}},
+		{withFilters(visitQuery, repository.BotsOnly, false), map[string]int64{"link-a": 1}},
+		{withFilters(visitQuery, repository.BotsIncluded, true), map[string]int64{"link-a": 5, "link-b": 1}},
+		{repository.VisitQuery{LinkIDs: []string{"link-c"}, From: visitBase, To: visitBase}, map[string]int64{}},
+		{repository.VisitQuery{From: visitBase, To: visitBase.Add(3 * time.Hour)}, map[string]int64{}},
+	} {
+		counts, err := repo.CountByLink(ctx, tc.q)
+		require.NoError(t, err)
+		assert.Equal(t, tc.counts, counts, "%+v", tc.q)
+
+		var total int64
+		for _, n := range tc.counts {
+			total += n
+		}
+		count, err := repo.Count(ctx, tc.q)
+		require.NoError(t, err)
+		assert.Equal(t, total, count, "%+v", tc.q)
+	}
+}
+
+func testCountByInterval(t *testing.T, repo repository.VisitRepository) {
+	ctx := context.Background()
+	seedVisits(t, repo)
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+
+	// Only non-empty buckets are returned, in chronological order.
+	buckets, err := repo.CountByInterval(ctx, visitQuery, entity.IntervalHour, time.UTC)
+	require.NoError(t, err)
+	assertBuckets(t, []*entity.StatsBucket{
+		{Start: visitBase, Clicks: 3},
+		{Start: visitBase.Add(time.Hour), Clicks: 1},
+	}, buckets)
+
+	// Days start at midnight in the given location.
+	buckets, err = repo.CountByInterval(ctx, withFilters(visitQuery, repository.BotsIncluded, true), entity.IntervalDay, kolkata)
+	require.NoError(t, err)
+	assertBuckets(t, []*entity.StatsBucket{
+		{Start: time.Date(2026, 3, 2, 0, 0, 0, 0, kolkata), Clicks: 6},
+	}, buckets)
+
+	buckets, err = repo.CountByInterval(ctx, withFilters(visitQuery, repository.BotsOnly, false), entity.IntervalWeek, time.UTC)
+	require.NoError(t, err)
+	assertBuckets(t, []*entity.StatsBucket{
+		{Start: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Clicks: 1},
+	}, buckets)
+}
+
+func testTopValues(t *testing.T, repo repository.VisitRepository) {
+	ctx := context.Background()
+	seedVisits(t, repo)
+
+	// Visits without a value count under "", and ties are broken by value.
+	items, err := repo.TopValues(ctx, visitQuery, entity.DimensionCountry, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []*entity.BreakdownItem{
+		{Value: "DE", Clicks: 2},
+		{Value: "", Clicks: 1},
+		{Value: "FR", Clicks: 1},
+	}, items)
+
+	items, err = repo.TopValues(ctx, visitQuery, entity.DimensionCountry, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []*entity.BreakdownItem{{Value: "DE", Clicks: 2}, {Value: "", Clicks: 1}}, items)
+
+	items, err = repo.TopValues(ctx, withFilters(visitQuery, repository.BotsOnly, false), entity.DimensionCountry, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []*entity.BreakdownItem{{Value: "US", Clicks: 1}}, items)
+}
+
+func testEach(t *testing.T, repo repository.VisitRepository) {
+	ctx := context.Background()
+	seedVisits(t, repo)
+
+	// Each yields visits oldest first, with all their fields.
+	var visits []*entity.Visit
+	err := repo.Each(ctx, withFilters(visitQuery, repository.BotsIncluded, true), func(visit *entity.Visit) error {
+		visits = append(visits, visit)
+		return nil
+	})
+	require.NoError(t, err)
+	offsets := []time.Duration{0, 10 * time.Minute, 30 * time.Minute, 90 * time.Minute, 2 * time.Hour, 2*time.Hour + time.Minute}
+	if assert.Len(t, visits, len(offsets)) {
+		for i, offset := range offsets {
+			assert.True(t, visitBase.Add(offset).Equal(visits[i].VisitedAt), "visit %d at %v", i, visits[i].VisitedAt)
+		}
+		first := visits[0]
+		assert.Equal(t, "link-a", first.LinkID)
+		assert.Equal(t, "DE", first.Country)
+		assert.Equal(t, "Firefox", first.Browser)
+		assert.Equal(t, "x", first.VisitorHash)
+		assert.True(t, visits[4].Bot)
+		assert.Equal(t, "Googlebot", visits[4].BotName)
+		assert.True(t, visits[5].Repeat)
+	}
+
+	// The first error from fn stops the walk and is returned.
+	stop := errors.New("stop")
+	calls := 0
+	err = repo.Each(ctx, visitQuery, func(*entity.Visit) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
+
+func testSourceCounts(t *testing.T, repo repository.VisitRepository) {
+	ctx := context.Background()
+	seedVisits(t, repo)
+
+	// Source counts cover every link's counted clicks in [from, to).
+	sources, err := repo.SourceCounts(ctx, visitQuery.From, visitQuery.To, 1)
+	require.NoError(t, err)
+	sort.Slice(sources, func(i, j int) bool { return sources[i].LinkID < sources[j].LinkID })
+	assert.Equal(t, []*repository.LinkSources{
+		{LinkID: "link-a", Clicks: 3, Sources: 2, TopSourceClicks: 2},
+		{LinkID: "link-b", Clicks: 1, Sources: 1, TopSourceClicks: 1},
+		{LinkID: "link-c", Clicks: 1, Sources: 1, TopSourceClicks: 1},
+	}, sources)
+
+	sources, err = repo.SourceCounts(ctx, visitQuery.From, visitQuery.To, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []*repository.LinkSources{{LinkID: "link-a", Clicks: 3, Sources: 2, TopSourceClicks: 2}}, sources)
+}
+
+func testRollup(t *testing.T, repo repository.VisitRepository) {
+	ctx := context.Background()
+	seedVisits(t, repo)
+
+	// Rollups sum [from, to) per link and hour, leaving repeats out.
+	rollups, err := repo.Rollup(ctx, visitQuery.From, visitQuery.To)
+	require.NoError(t, err)
+	type sums struct{ clicks, botHits int64 }
+	got := map[string]sums{}
+	for i, rollup := range rollups {
+		assert.Equal(t, entity.IntervalHour, rollup.Granularity)
+		assert.True(t, rollup.Start.Equal(repository.BucketStart(rollup.Start, entity.IntervalHour, time.UTC)), "rollup starts on the hour")
+		if i > 0 {
+			assert.False(t, rollup.Start.Before(rollups[i-1].Start), "rollups are in chronological order")
+		}
+		s := got[rollup.LinkID]
+		got[rollup.LinkID] = sums{s.clicks + rollup.Clicks, s.botHits + rollup.BotHits}
+	}
+	assert.Equal(t, map[string]sums{"link-a": {3, 1}, "link-b": {1, 0}, "link-c": {1, 0}}, got)
+}
+
+func testOldestAndDeleteBefore(t *testing.T, repo repository.VisitRepository) {
+	ctx := context.Background()
+	_, err := repo.Oldest(ctx)
+	assert.ErrorIs(t, err, repository.ErrNotFound, "an empty repository has no oldest visit")
+	seedVisits(t, repo)
+
+	oldest, err := repo.Oldest(ctx)
+	require.NoError(t, err)
+	assert.True(t, visitBase.Add(-time.Millisecond).Equal(oldest), "oldest %v", oldest)
+
+	// DeleteBefore keeps visits made at the cutoff.
+	deleted, err := repo.DeleteBefore(ctx, visitBase)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	deleted, err = repo.DeleteBefore(ctx, visitBase)
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+	oldest, err = repo.Oldest(ctx)
+	require.NoError(t, err)
+	assert.True(t, visitBase.Equal(oldest), "oldest %v", oldest)
+
+	deleted, err = repo.DeleteBefore(ctx, visitBase.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), deleted)
+	_, err = repo.Oldest(ctx)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func testDeleteByLinkIDs(t *testing.T, repo repository.VisitRepository) {
+	ctx := context.Background()
+	seedVisits(t, repo)
+
+	deleted, err := repo.DeleteByLinkIDs(ctx, []string{"link-a", "link-missing", "link-a"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), deleted)
+	deleted, err = repo.DeleteByLinkIDs(ctx, nil)
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+
+	counts, err := repo.CountByLink(ctx, withFilters(repository.VisitQuery{
+		LinkIDs: []string{"link-a", "link-b", "link-c"}, From: visitBase.Add(-time.Hour), To: visitBase.Add(24 * time.Hour),
+	}, repository.BotsIncluded, true))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"link-b": 1, "link-c": 1}, counts)
+}
+
+func testCreateConcurrently(t *testing.T, repo repository.VisitRepository) {
+	ctx := context.Background()
+
+	const workers, visits = 20, 10
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for v := 0; v < visits; v++ {
+				_, err := repo.Create(ctx, &entity.Visit{LinkID: "link-a", VisitedAt: visitBase.Add(time.Duration(v) * time.Second)})
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	count, err := repo.Count(ctx, visitQuery)
+	require.NoError(t, err)
+	assert.Equal(t, int64(workers*visits), count, "no visit is lost")
+}
+
+func withFilters(q repository.VisitQuery, bots repository.BotFilter, repeats bool) repository.VisitQuery {
+	q.Bots = bots
+	q.IncludeRepeats = repeats
+	return q
+}
+
+// assertBuckets compares buckets by instant, whatever their location.
+func assertBuckets(t *testing.T, want, got []*entity.StatsBucket) {
+	t.Helper()
+	if !assert.Len(t, got, len(want)) {
+		return
+	}
+	for i := range want {
+		assert.True(t, want[i].Start.Equal(got[i].Start), "bucket %d starts at %v, want %v", i, got[i].Start, want[i].Start)
+		assert.Equal(t, want[i].Clicks, got[i].Clicks, "bucket %d", i)
+	}
+}
@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+)
+
+type sqlClickWindowRepository struct {
+	db     *SQLDB
+	claims atomic.Int64
+}
+
+func NewSQLClickWindowRepository(db *SQLDB) ClickWindowRepository {
+	return &sqlClickWindowRepository{db: db}
+}
+
+func (r *sqlClickWindowRepository) Claim(ctx context.Context, linkID, visitor string, now time.Time, window time.Duration) (bool, error) {
+	if r.claims.Add(1)%clickWindowSweepEvery == 0 {
+		if _, err := r.db.exec(ctx, "DELETE FROM click_windows WHERE expires_at < ?", toMillis(now)); err != nil {
+			return false, err
+		}
+	}
+	// The conditional upsert only touches a row whose window has passed, so
+	// of two concurrent claims at most one sees a row affected.
+	n, err := affected(r.db.exec(ctx, `INSERT INTO click_windows (id, counted_at, expires_at) VALUES (?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET counted_at = excluded.counted_at, expires_at = excluded.expires_at
+		WHERE click_windows.counted_at <= ?`,
+		linkID+":"+visitor, toMillis(now), toMillis(now.Add(window)), toMillis(now.Add(-window))))
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+type sqlClickAnomalyRepository struct {
+	db *SQLDB
+}
+
+func NewSQLClickAnomalyRepository(db *SQLDB) ClickAnomalyRepository {
+	return &sqlClickAnomalyRepository{db: db}
+}
+
+func (r *sqlClickAnomalyRepository) Create(ctx context.Context, anomaly *entity.ClickAnomaly) (*entity.ClickAnomaly, error) {
+	id := newID()
+	_, err := r.db.exec(ctx, `INSERT INTO click_anomalies (id, link_id, window_start, window_end, clicks, sources,
+		top_source_clicks, baseline, detected_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, anomaly.LinkID, toMillis(anomaly.WindowStart), toMillis(anomaly.WindowEnd), anomaly.Clicks, anomaly.Sources,
+		anomaly.TopSourceClicks, anomaly.Baseline, toMillis(anomaly.DetectedAt))
+	if isDuplicateKey(err) {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, err
+	}
+	anomaly.ID = id
+	return anomaly, nil
+}
+
+func (r *sqlClickAnomalyRepository) List(ctx context.Context, linkIDs []string, from, to time.Time) ([]*entity.ClickAnomaly, error) {
+	anomalies := []*entity.ClickAnomaly{}
+	if len(linkIDs) == 0 {
+		return anomalies, nil
+	}
+	query := `SELECT id, link_id, window_start, window_end, clicks, sources, top_source_clicks, baseline, detected_at
+		FROM click_anomalies WHERE link_id IN (` + placeholders(len(linkIDs)) + `) AND window_start >= ? AND window_start < ?
+		ORDER BY window_start`
+	err := scanRows(ctx, r.db.sqlRunner, query, append(stringArgs(linkIDs), toMillis(from), toMillis(to)), func(rows *sql.Rows) error {
+		var anomaly entity.ClickAnomaly
+		var windowStart, windowEnd, detectedAt int64
+		err := rows.Scan(&anomaly.ID, &anomaly.LinkID, &windowStart, &windowEnd, &anomaly.Clicks, &anomaly.Sources,
+			&anomaly.TopSourceClicks, &anomaly.Baseline, &detectedAt)
+		if err != nil {
+			return err
+		}
+		anomaly.WindowStart = fromMillis(windowStart)
+		anomaly.WindowEnd = fromMillis(windowEnd)
+		anomaly.DetectedAt = fromMillis(detectedAt)
+		anomalies = append(anomalies, &anomaly)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return anomalies, nil
+}
+
+func (r *sqlClickAnomalyRepository) DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error) {
+	if len(linkIDs) == 0 {
+		return 0, nil
+	}
+	return affected(r.db.exec(ctx, "DELETE FROM click_anomalies WHERE link_id IN ("+placeholders(len(linkIDs))+")", stringArgs(linkIDs)...))
+}
+
+// sqlVisitorSketchRepository stores a row per raised register, so a sketch
+// is the rows sharing a link and day.
+type sqlVisitorSketchRepository struct {
+	db *SQLDB
+}
+
+func NewSQLVisitorSketchRepository(db *SQLDB) VisitorSketchRepository {
+	return &sqlVisitorSketchRepository{db: db}
+}
+
+func (r *sqlVisitorSketchRepository) Raise(ctx context.Context, linkID string, day time.Time, index uint16, rank uint8) error {
+	_, err := r.db.exec(ctx, `INSERT INTO visitor_sketch_registers (link_id, day, register, rank) VALUES (?, ?, ?, ?)
+		ON CONFLICT (link_id, day, register) DO UPDATE SET rank = excluded.rank
+		WHERE excluded.rank > visitor_sketch_registers.rank`,
+		linkID, toMillis(day), int(index), int(rank))
+	return err
+}
+
+func (r *sqlVisitorSketchRepository) List(ctx context.Context, linkIDs []string, from, to time.Time) ([]*entity.VisitorSketch, error) {
+	sketches := make([]*entity.VisitorSketch, 0)
+	if len(linkIDs) == 0 {
+		return sketches, nil
+	}
+	type key struct {
+		linkID string
+		day    int64
+	}
+	byKey := make(map[key]*entity.VisitorSketch)
+	query := "SELECT link_id, day, register, rank FROM visitor_sketch_registers WHERE link_id IN (" +
+		placeholders(len(linkIDs)) + ") AND day >= ? AND day < ?"
+	err := scanRows(ctx, r.db.sqlRunner, query, append(stringArgs(linkIDs), toMillis(from), toMillis(to)), func(rows *sql.Rows) error {
+		var k key
+		var index, rank int
+		if err := rows.Scan(&k.linkID, &k.day, &index, &rank); err != nil {
+			return err
+		}
+		sketch, ok := byKey[k]
+		if !ok {
+			sketch = &entity.VisitorSketch{LinkID: k.linkID, Day: fromMillis(k.day), Registers: make(map[uint16]uint8)}
+			byKey[k] = sketch
+			sketches = append(sketches, sketch)
+		}
+		sketch.Registers[uint16(index)] = uint8(rank)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sketches, nil
+}
+
+// DeleteByLinkIDs reports the number of sketches deleted, not registers.
+func (r *sqlVisitorSketchRepository) DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error) {
+	if len(linkIDs) == 0 {
+		return 0, nil
+	}
+	var deleted int64
+	err := r.db.inTx(ctx, func(tx sqlRunner) error {
+		in := " WHERE link_id IN (" + placeholders(len(linkIDs)) + ")"
+		err := tx.queryRow(ctx, "SELECT COUNT(*) FROM (SELECT DISTINCT link_id, day FROM visitor_sketch_registers"+in+") sketches",
+			stringArgs(linkIDs)...).Scan(&deleted)
+		if err != nil {
+			return err
+		}
+		_, err = tx.exec(ctx, "DELETE FROM visitor_sketch_registers"+in, stringArgs(linkIDs)...)
+		return err
+	})
+	return deleted, err
+}
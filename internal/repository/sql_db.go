@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib" // registers the "pgx" driver
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQL dialects accepted by OpenSQL.
+const (
+	DialectSQLite   = "sqlite"
+	DialectPostgres = "postgres"
+)
+
+//go:embed migrations
+var migrations embed.FS
+
+// SQLDB is a database/sql handle together with the dialect it speaks. The
+// SQL repositories write queries with ? placeholders, which are rewritten
+// for PostgreSQL.
+type SQLDB struct {
+	sqlRunner
+	db *sql.DB
+}
+
+// OpenSQL connects to a SQLite or PostgreSQL database. For SQLite, dsn is a
+// file path or a file: URI; for PostgreSQL, a connection URL or keyword/value
+// string. Call MigrateSQL before using the repositories.
+func OpenSQL(ctx context.Context, dialect, dsn string) (*SQLDB, error) {
+	var driver string
+	switch dialect {
+	case DialectSQLite:
+		driver, dsn = "sqlite", sqliteDSN(dsn)
+	case DialectPostgres:
+		driver = "pgx"
+	default:
+		return nil, fmt.Errorf("unsupported SQL dialect %q", dialect)
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLDB{sqlRunner: sqlRunner{q: db, dialect: dialect}, db: db}, nil
+}
+
+// sqliteDSN waits for locks instead of failing, lets readers run alongside
+// the writer and takes the write lock when a transaction begins, so
+// concurrent transactions queue rather than deadlock.
+func sqliteDSN(dsn string) string {
+	params := []string{"_pragma=busy_timeout(5000)", "_pragma=journal_mode(WAL)", "_txlock=immediate"}
+	if !strings.HasPrefix(dsn, "file:") {
+		dsn = "file:" + dsn
+	}
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	return dsn + sep + strings.Join(params, "&")
+}
+
+func (db *SQLDB) Close() error {
+	return db.db.Close()
+}
+
+// MigrateSQL applies the embedded schema migrations of the database's dialect
+// that have not run yet. It is safe to call on every start-up, also from
+// several instances at once.
+func MigrateSQL(ctx context.Context, db *SQLDB) error {
+	dir := path.Join("migrations", db.dialect)
+	entries, err := fs.ReadDir(migrations, dir)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	if _, err := db.exec(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, applied_at BIGINT NOT NULL)"); err != nil {
+		return err
+	}
+	return db.inTx(ctx, func(tx sqlRunner) error {
+		if db.dialect == DialectPostgres {
+			// SQLite transactions already hold the write lock.
+			if _, err := tx.exec(ctx, "LOCK TABLE schema_migrations IN EXCLUSIVE MODE"); err != nil {
+				return err
+			}
+		}
+		var current int
+		if err := tx.queryRow(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current); err != nil {
+			return err
+		}
+		for _, name := range names {
+			version, err := strconv.Atoi(strings.SplitN(name, "_", 2)[0])
+			if err != nil {
+				return fmt.Errorf("migration %s: name must start with a version number", name)
+			}
+			if version <= current {
+				continue
+			}
+			script, err := migrations.ReadFile(path.Join(dir, name))
+			if err != nil {
+				return err
+			}
+			if _, err := tx.exec(ctx, string(script)); err != nil {
+				return fmt.Errorf("migration %s: %w", name, err)
+			}
+			if _, err := tx.exec(ctx, "INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)", version, toMillis(time.Now())); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// inTx runs fn in a transaction, committing when it returns nil.
+func (db *SQLDB) inTx(ctx context.Context, fn func(tx sqlRunner) error) error {
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(sqlRunner{q: tx, dialect: db.dialect}); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// sqlQuerier is implemented by *sql.DB and *sql.Tx.
+type sqlQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// sqlRunner runs queries written with ? placeholders in either dialect.
+type sqlRunner struct {
+	q       sqlQuerier
+	dialect string
+}
+
+func (r sqlRunner) exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return r.q.ExecContext(ctx, r.rebind(query), args...)
+}
+
+func (r sqlRunner) query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return r.q.QueryContext(ctx, r.rebind(query), args...)
+}
+
+func (r sqlRunner) queryRow(ctx context.Context, query string, args ...any) *sql.Row {
+	return r.q.QueryRowContext(ctx, r.rebind(query), args...)
+}
+
+// rebind numbers the placeholders for PostgreSQL. Queries must not contain a
+// literal question mark.
+func (r sqlRunner) rebind(query string) string {
+	if r.dialect != DialectPostgres || !strings.Contains(query, "?") {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// affected returns the number of rows a statement changed.
+func affected(res sql.Result, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// isDuplicateKey reports whether err is a unique constraint violation.
+func isDuplicateKey(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505"
+	}
+	var liteErr *sqlite.Error
+	if errors.As(err, &liteErr) {
+		return liteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || liteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+	}
+	return false
+}
+
+// placeholders returns n comma-separated placeholders for an IN list.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// stringArgs converts values to query arguments.
+func stringArgs(values []string) []any {
+	args := make([]any, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return args
+}
+
+// Times are stored as milliseconds since the Unix epoch, the precision MongoDB
+// keeps, so both dialects compare and bucket them the same way.
+func toMillis(t time.Time) int64 {
+	return t.UnixMilli()
+}
+
+func fromMillis(ms int64) time.Time {
+	return time.UnixMilli(ms).UTC()
+}
+
+func optionalMillis(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UnixMilli()
+}
+
+func optionalTime(ms sql.NullInt64) *time.Time {
+	if !ms.Valid {
+		return nil
+	}
+	t := fromMillis(ms.Int64)
+	return &t
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
@@ -0,0 +1,407 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+)
+
+type sqlLinkRepository struct {
+	db *SQLDB
+}
+
+func NewSQLLinkRepository(db *SQLDB) LinkRepository {
+	return &sqlLinkRepository{db: db}
+}
+
+const linkColumns = "id, owner_id, slug, title, url, created_at, active_from, expires_at, archived_at, deleted_at, clicks"
+
+func (r *sqlLinkRepository) Create(ctx context.Context, link *entity.Link) (*entity.Link, error) {
+	id := newID()
+	err := r.db.inTx(ctx, func(tx sqlRunner) error {
+		_, err := tx.exec(ctx, `INSERT INTO links (id, owner_id, slug, title, title_lower, url, host, created_at,
+			active_from, expires_at, archived_at, deleted_at, clicks) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, link.OwnerID, optionalString(link.Slug), link.Title, strings.ToLower(link.Title), link.URL, urlHost(link.URL),
+			toMillis(link.CreatedAt), optionalMillis(link.ActiveFrom), optionalMillis(link.ExpiresAt),
+			optionalMillis(link.ArchivedAt), optionalMillis(link.DeletedAt), link.Clicks)
+		if err != nil {
+			return err
+		}
+		return saveLinkTags(ctx, tx, id, link.Tags)
+	})
+	if isDuplicateKey(err) {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, err
+	}
+	link.ID = id
+	return link, nil
+}
+
+func (r *sqlLinkRepository) GetByID(ctx context.Context, id string) (*entity.Link, error) {
+	return r.findOne(ctx, "id = ?", id)
+}
+
+func (r *sqlLinkRepository) GetByIDs(ctx context.Context, ids []string) ([]*entity.Link, error) {
+	if len(ids) == 0 {
+		return []*entity.Link{}, nil
+	}
+	return r.find(ctx, "SELECT "+linkColumns+" FROM links WHERE id IN ("+placeholders(len(ids))+")", stringArgs(ids)...)
+}
+
+func (r *sqlLinkRepository) GetBySlug(ctx context.Context, slug string) (*entity.Link, error) {
+	return r.findOne(ctx, "slug = ?", slug)
+}
+
+func (r *sqlLinkRepository) Update(ctx context.Context, link *entity.Link) (*entity.Link, error) {
+	if link.ID == "" {
+		return nil, errors.New("missing link ID")
+	}
+	err := r.db.inTx(ctx, func(tx sqlRunner) error {
+		n, err := affected(tx.exec(ctx, `UPDATE links SET title = ?, title_lower = ?, url = ?, host = ?, slug = ?,
+			active_from = ?, expires_at = ?, archived_at = ?, deleted_at = ? WHERE id = ?`,
+			link.Title, strings.ToLower(link.Title), link.URL, urlHost(link.URL), optionalString(link.Slug),
+			optionalMillis(link.ActiveFrom), optionalMillis(link.ExpiresAt), optionalMillis(link.ArchivedAt),
+			optionalMillis(link.DeletedAt), link.ID))
+		if err != nil || n == 0 {
+			return err
+		}
+		if _, err := tx.exec(ctx, "DELETE FROM link_tags WHERE link_id = ?", link.ID); err != nil {
+			return err
+		}
+		return saveLinkTags(ctx, tx, link.ID, link.Tags)
+	})
+	if isDuplicateKey(err) {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, link.ID)
+}
+
+func (r *sqlLinkRepository) Delete(ctx context.Context, id string) error {
+	return r.db.inTx(ctx, func(tx sqlRunner) error {
+		return deleteLinks(ctx, tx, []string{id})
+	})
+}
+
+func (r *sqlLinkRepository) IncrementClicks(ctx context.Context, id string) error {
+	_, err := r.db.exec(ctx, "UPDATE links SET clicks = clicks + 1 WHERE id = ?", id)
+	return err
+}
+
+func (r *sqlLinkRepository) List(ctx context.Context, f LinkFilter) ([]*entity.Link, error) {
+	where, args := sqlLinkFilter(f)
+	query := "SELECT " + linkColumns + " FROM links WHERE " + where + " ORDER BY " + sqlLinkOrder(f)
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+	return r.find(ctx, query, args...)
+}
+
+func (r *sqlLinkRepository) ListArchived(ctx context.Context, ownerID string) ([]*entity.Link, error) {
+	return r.find(ctx, "SELECT "+linkColumns+" FROM links WHERE owner_id = ? AND archived_at IS NOT NULL ORDER BY archived_at DESC, id DESC", ownerID)
+}
+
+func (r *sqlLinkRepository) ListDeleted(ctx context.Context, ownerID string) ([]*entity.Link, error) {
+	return r.find(ctx, "SELECT "+linkColumns+" FROM links WHERE owner_id = ? AND deleted_at IS NOT NULL ORDER BY deleted_at DESC, id DESC", ownerID)
+}
+
+// ArchiveExpired archives each expired link with its own conditional update,
+// so a link is reported once even when several instances run the job.
+func (r *sqlLinkRepository) ArchiveExpired(ctx context.Context, now time.Time) ([]*entity.Link, error) {
+	candidates, err := r.find(ctx, "SELECT "+linkColumns+" FROM links WHERE expires_at < ? AND archived_at IS NULL AND deleted_at IS NULL", toMillis(now))
+	if err != nil {
+		return nil, err
+	}
+	archived := make([]*entity.Link, 0, len(candidates))
+	for _, link := range candidates {
+		n, err := affected(r.db.exec(ctx, "UPDATE links SET archived_at = ? WHERE id = ? AND archived_at IS NULL AND deleted_at IS NULL", toMillis(now), link.ID))
+		if err != nil {
+			return archived, err
+		}
+		if n == 1 {
+			at := fromMillis(toMillis(now))
+			link.ArchivedAt = &at
+			archived = append(archived, link)
+		}
+	}
+	return archived, nil
+}
+
+func (r *sqlLinkRepository) PurgeArchived(ctx context.Context, before time.Time) ([]string, error) {
+	return r.purge(ctx, "archived_at < ?", toMillis(before))
+}
+
+func (r *sqlLinkRepository) PurgeDeleted(ctx context.Context, before time.Time) ([]string, error) {
+	return r.purge(ctx, "deleted_at < ?", toMillis(before))
+}
+
+// purge deletes the links matching where and returns their IDs, so callers
+// can delete their visits too.
+func (r *sqlLinkRepository) purge(ctx context.Context, where string, args ...any) ([]string, error) {
+	var ids []string
+	err := r.db.inTx(ctx, func(tx sqlRunner) error {
+		rows, err := tx.query(ctx, "SELECT id FROM links WHERE "+where, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return deleteLinks(ctx, tx, ids)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *sqlLinkRepository) findOne(ctx context.Context, where string, args ...any) (*entity.Link, error) {
+	links, err := r.find(ctx, "SELECT "+linkColumns+" FROM links WHERE "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(links) == 0 {
+		return nil, ErrNotFound
+	}
+	return links[0], nil
+}
+
+// find runs a query selecting linkColumns and loads the tags of the links.
+func (r *sqlLinkRepository) find(ctx context.Context, query string, args ...any) ([]*entity.Link, error) {
+	rows, err := r.db.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	links := make([]*entity.Link, 0)
+	byID := make(map[string]*entity.Link)
+	for rows.Next() {
+		link, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+		byID[link.ID] = link
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	if len(links) == 0 {
+		return links, nil
+	}
+
+	ids := make([]string, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	tagRows, err := r.db.query(ctx, "SELECT link_id, tag FROM link_tags WHERE link_id IN ("+placeholders(len(ids))+") ORDER BY link_id, position", stringArgs(ids)...)
+	if err != nil {
+		return nil, err
+	}
+	defer tagRows.Close()
+	for tagRows.Next() {
+		var linkID, tag string
+		if err := tagRows.Scan(&linkID, &tag); err != nil {
+			return nil, err
+		}
+		byID[linkID].Tags = append(byID[linkID].Tags, tag)
+	}
+	return links, tagRows.Err()
+}
+
+func scanLink(row rowScanner) (*entity.Link, error) {
+	var link entity.Link
+	var slug sql.NullString
+	var createdAt int64
+	var activeFrom, expiresAt, archivedAt, deletedAt sql.NullInt64
+	err := row.Scan(&link.ID, &link.OwnerID, &slug, &link.Title, &link.URL, &createdAt,
+		&activeFrom, &expiresAt, &archivedAt, &deletedAt, &link.Clicks)
+	if err != nil {
+		return nil, err
+	}
+	link.Slug = slug.String
+	link.CreatedAt = fromMillis(createdAt)
+	link.ActiveFrom = optionalTime(activeFrom)
+	link.ExpiresAt = optionalTime(expiresAt)
+	link.ArchivedAt = optionalTime(archivedAt)
+	link.DeletedAt = optionalTime(deletedAt)
+	return &link, nil
+}
+
+func saveLinkTags(ctx context.Context, tx sqlRunner, linkID string, tags []string) error {
+	for i, tag := range tags {
+		if _, err := tx.exec(ctx, "INSERT INTO link_tags (link_id, position, tag) VALUES (?, ?, ?)", linkID, i, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteLinks(ctx context.Context, tx sqlRunner, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	in := "(" + placeholders(len(ids)) + ")"
+	if _, err := tx.exec(ctx, "DELETE FROM link_tags WHERE link_id IN "+in, stringArgs(ids)...); err != nil {
+		return err
+	}
+	_, err := tx.exec(ctx, "DELETE FROM links WHERE id IN "+in, stringArgs(ids)...)
+	return err
+}
+
+// sqlLinkFilter translates f into a WHERE clause and its arguments.
+func sqlLinkFilter(f LinkFilter) (string, []any) {
+	var conds []string
+	var args []any
+	add := func(cond string, values ...any) {
+		conds = append(conds, cond)
+		args = append(args, values...)
+	}
+	if f.OwnerID != "" {
+		add("owner_id = ?", f.OwnerID)
+	}
+	if len(f.Statuses) == 0 {
+		add("deleted_at IS NULL")
+	} else {
+		statuses := make([]string, len(f.Statuses))
+		for i, status := range f.Statuses {
+			cond, values := sqlStatusFilter(status, f.Now)
+			statuses[i] = "(" + cond + ")"
+			args = append(args, values...)
+		}
+		conds = append(conds, "("+strings.Join(statuses, " OR ")+")")
+	}
+	if f.CreatedFrom != nil {
+		add("created_at >= ?", toMillis(*f.CreatedFrom))
+	}
+	if f.CreatedTo != nil {
+		add("created_at < ?", toMillis(*f.CreatedTo))
+	}
+	if f.Tag != "" {
+		add("EXISTS (SELECT 1 FROM link_tags WHERE link_tags.link_id = links.id AND link_tags.tag = ?)", f.Tag)
+	}
+	if f.Host != "" {
+		add("host = ?", strings.ToLower(f.Host))
+	}
+	if f.TitleContains != "" {
+		add(`title_lower LIKE ? ESCAPE '\'`, "%"+escapeLike(strings.ToLower(f.TitleContains))+"%")
+	}
+	if f.After != nil {
+		cond, values := sqlCursorFilter(f)
+		add(cond, values...)
+	}
+	if len(conds) == 0 {
+		return "1 = 1", nil
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// sqlStatusFilter selects the links with status at now, like
+// entity.Link.StatusAt.
+func sqlStatusFilter(status entity.LinkStatus, now time.Time) (string, []any) {
+	const live = "deleted_at IS NULL AND archived_at IS NULL"
+	const notExpired = "(expires_at IS NULL OR expires_at > ?)"
+	at := toMillis(now)
+	switch status {
+	case entity.LinkDeleted:
+		return "deleted_at IS NOT NULL", nil
+	case entity.LinkArchived:
+		return "deleted_at IS NULL AND archived_at IS NOT NULL", nil
+	case entity.LinkExpired:
+		return live + " AND expires_at <= ?", []any{at}
+	case entity.LinkScheduled:
+		return live + " AND " + notExpired + " AND active_from > ?", []any{at, at}
+	case entity.LinkActive:
+		return live + " AND " + notExpired + " AND (active_from IS NULL OR active_from <= ?)", []any{at, at}
+	}
+	return "1 = 0", nil
+}
+
+// sqlCursorFilter selects the links after f.After in the filter's order.
+func sqlCursorFilter(f LinkFilter) (string, []any) {
+	next := ">"
+	if f.Descending {
+		next = "<"
+	}
+	id := f.After.ID
+	switch f.SortBy {
+	case LinkSortClicks:
+		return "(clicks " + next + " ? OR (clicks = ? AND id " + next + " ?))", []any{f.After.Clicks, f.After.Clicks, id}
+	case LinkSortExpiresAt:
+		// Links without an expiry sort first ascending and last descending.
+		if f.After.ExpiresAt == nil {
+			if f.Descending {
+				return "(expires_at IS NULL AND id < ?)", []any{id}
+			}
+			return "((expires_at IS NULL AND id > ?) OR expires_at IS NOT NULL)", []any{id}
+		}
+		at := toMillis(*f.After.ExpiresAt)
+		cond := "(expires_at " + next + " ? OR (expires_at = ? AND id " + next + " ?)"
+		if f.Descending {
+			cond += " OR expires_at IS NULL"
+		}
+		return cond + ")", []any{at, at, id}
+	default:
+		at := toMillis(f.After.CreatedAt)
+		return "(created_at " + next + " ? OR (created_at = ? AND id " + next + " ?))", []any{at, at, id}
+	}
+}
+
+// sqlLinkOrder returns the ORDER BY clause for f, sorting links without an
+// expiry first ascending and last descending in both dialects.
+func sqlLinkOrder(f LinkFilter) string {
+	dir := " ASC"
+	if f.Descending {
+		dir = " DESC"
+	}
+	switch f.SortBy {
+	case LinkSortClicks:
+		return "clicks" + dir + ", id" + dir
+	case LinkSortExpiresAt:
+		return "CASE WHEN expires_at IS NULL THEN 0 ELSE 1 END" + dir + ", expires_at" + dir + ", id" + dir
+	default:
+		return "created_at" + dir + ", id" + dir
+	}
+}
+
+// urlHost returns the lower-cased host of rawURL without its port, which the
+// Host filter matches.
+func urlHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// escapeLike escapes the LIKE wildcards in s, using \ as the escape character.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// optionalString stores "" as NULL.
+func optionalString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+)
+
+type sqlProfileRepository struct {
+	db *SQLDB
+}
+
+func NewSQLProfileRepository(db *SQLDB) ProfileRepository {
+	return &sqlProfileRepository{db: db}
+}
+
+const profileColumns = "id, user_id, handle, display_name, avatar_url, bio, theme, link_ids, created_at, updated_at"
+
+func (r *sqlProfileRepository) Create(ctx context.Context, profile *entity.Profile) (*entity.Profile, error) {
+	linkIDs, err := json.Marshal(profile.LinkIDs)
+	if err != nil {
+		return nil, err
+	}
+	id := newID()
+	_, err = r.db.exec(ctx, "INSERT INTO profiles ("+profileColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		id, profile.UserID, profile.Handle, profile.DisplayName, profile.AvatarURL, profile.Bio, profile.Theme,
+		string(linkIDs), toMillis(profile.CreatedAt), toMillis(profile.UpdatedAt))
+	if isDuplicateKey(err) {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, err
+	}
+	profile.ID = id
+	return profile, nil
+}
+
+func (r *sqlProfileRepository) GetByHandle(ctx context.Context, handle string) (*entity.Profile, error) {
+	return r.findOne(ctx, "handle = ?", handle)
+}
+
+func (r *sqlProfileRepository) GetByUserID(ctx context.Context, userID string) (*entity.Profile, error) {
+	return r.findOne(ctx, "user_id = ?", userID)
+}
+
+func (r *sqlProfileRepository) Update(ctx context.Context, profile *entity.Profile) (*entity.Profile, error) {
+	linkIDs, err := json.Marshal(profile.LinkIDs)
+	if err != nil {
+		return nil, err
+	}
+	n, err := affected(r.db.exec(ctx, `UPDATE profiles SET handle = ?, display_name = ?, avatar_url = ?, bio = ?, theme = ?,
+		link_ids = ?, updated_at = ? WHERE id = ?`,
+		profile.Handle, profile.DisplayName, profile.AvatarURL, profile.Bio, profile.Theme,
+		string(linkIDs), toMillis(profile.UpdatedAt), profile.ID))
+	if isDuplicateKey(err) {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrNotFound
+	}
+	return r.findOne(ctx, "id = ?", profile.ID)
+}
+
+func (r *sqlProfileRepository) findOne(ctx context.Context, where string, args ...any) (*entity.Profile, error) {
+	var profile entity.Profile
+	var linkIDs string
+	var createdAt, updatedAt int64
+	err := r.db.queryRow(ctx, "SELECT "+profileColumns+" FROM profiles WHERE "+where, args...).Scan(
+		&profile.ID, &profile.UserID, &profile.Handle, &profile.DisplayName, &profile.AvatarURL, &profile.Bio,
+		&profile.Theme, &linkIDs, &createdAt, &updatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(linkIDs), &profile.LinkIDs); err != nil {
+		return nil, err
+	}
+	profile.CreatedAt = fromMillis(createdAt)
+	profile.UpdatedAt = fromMillis(updatedAt)
+	return &profile, nil
+}
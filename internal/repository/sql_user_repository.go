@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+)
+
+type sqlUserRepository struct {
+	db *SQLDB
+}
+
+func NewSQLUserRepository(db *SQLDB) UserRepository {
+	return &sqlUserRepository{db: db}
+}
+
+func (r *sqlUserRepository) Create(ctx context.Context, user *entity.User) (*entity.User, error) {
+	id := newID()
+	_, err := r.db.exec(ctx, "INSERT INTO users (id, name, email, created_at) VALUES (?, ?, ?, ?)",
+		id, user.Name, user.Email, toMillis(user.CreatedAt))
+	if isDuplicateKey(err) {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, err
+	}
+	user.ID = id
+	return user, nil
+}
+
+func (r *sqlUserRepository) GetByID(ctx context.Context, id string) (*entity.User, error) {
+	user, err := scanUser(r.db.queryRow(ctx, "SELECT id, name, email, created_at FROM users WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return user, err
+}
+
+// List returns all users, newest first.
+func (r *sqlUserRepository) List(ctx context.Context) ([]*entity.User, error) {
+	users := make([]*entity.User, 0)
+	err := scanRows(ctx, r.db.sqlRunner, "SELECT id, name, email, created_at FROM users ORDER BY created_at DESC, id DESC", nil,
+		func(rows *sql.Rows) error {
+			user, err := scanUser(rows)
+			if err != nil {
+				return err
+			}
+			users = append(users, user)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func scanUser(row rowScanner) (*entity.User, error) {
+	var user entity.User
+	var createdAt int64
+	if err := row.Scan(&user.ID, &user.Name, &user.Email, &createdAt); err != nil {
+		return nil, err
+	}
+	user.CreatedAt = fromMillis(createdAt)
+	return &user, nil
+}
+
+type sqlAPIKeyRepository struct {
+	db *SQLDB
+}
+
+func NewSQLAPIKeyRepository(db *SQLDB) APIKeyRepository {
+	return &sqlAPIKeyRepository{db: db}
+}
+
+const apiKeyColumns = "id, user_id, name, prefix, key_hash, created_at, last_used_at, revoked"
+
+func (r *sqlAPIKeyRepository) Create(ctx context.Context, key *entity.APIKey) (*entity.APIKey, error) {
+	id := newID()
+	_, err := r.db.exec(ctx, "INSERT INTO api_keys ("+apiKeyColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		id, key.UserID, key.Name, key.Prefix, key.KeyHash, toMillis(key.CreatedAt), optionalMillis(key.LastUsedAt), key.Revoked)
+	if isDuplicateKey(err) {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, err
+	}
+	key.ID = id
+	return key, nil
+}
+
+func (r *sqlAPIKeyRepository) GetByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	key, err := scanAPIKey(r.db.queryRow(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = ?", hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return key, err
+}
+
+// List returns all keys, newest first.
+func (r *sqlAPIKeyRepository) List(ctx context.Context) ([]*entity.APIKey, error) {
+	keys := make([]*entity.APIKey, 0)
+	err := scanRows(ctx, r.db.sqlRunner, "SELECT "+apiKeyColumns+" FROM api_keys ORDER BY created_at DESC, id DESC", nil,
+		func(rows *sql.Rows) error {
+			key, err := scanAPIKey(rows)
+			if err != nil {
+				return err
+			}
+			keys = append(keys, key)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *sqlAPIKeyRepository) Revoke(ctx context.Context, id string) error {
+	n, err := affected(r.db.exec(ctx, "UPDATE api_keys SET revoked = ? WHERE id = ?", true, id))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *sqlAPIKeyRepository) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	_, err := r.db.exec(ctx, "UPDATE api_keys SET last_used_at = ? WHERE id = ?", toMillis(at), id)
+	return err
+}
+
+func scanAPIKey(row rowScanner) (*entity.APIKey, error) {
+	var key entity.APIKey
+	var createdAt int64
+	var lastUsedAt sql.NullInt64
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.KeyHash, &createdAt, &lastUsedAt, &key.Revoked)
+	if err != nil {
+		return nil, err
+	}
+	key.CreatedAt = fromMillis(createdAt)
+	key.LastUsedAt = optionalTime(lastUsedAt)
+	return &key, nil
+}
@@ -0,0 +1,411 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+)
+
+// sqlVisitColumns maps breakdown dimensions to the visits column holding
+// them.
+var sqlVisitColumns = map[entity.BreakdownDimension]string{
+	entity.DimensionReferrer: "referrer_host",
+	entity.DimensionCountry:  "country",
+	entity.DimensionDevice:   "device",
+	entity.DimensionBrowser:  "browser",
+	entity.DimensionOS:       "os",
+	entity.DimensionLanguage: "language",
+}
+
+// visitSlot is the span visits are grouped by before being bucketed in Go.
+// Every timezone offset in use is a multiple of it, so each slot falls in a
+// single hour, day or week bucket.
+const visitSlot = 15 * time.Minute
+
+type sqlVisitRepository struct {
+	db *SQLDB
+}
+
+func NewSQLVisitRepository(db *SQLDB) VisitRepository {
+	return &sqlVisitRepository{db: db}
+}
+
+const visitColumns = "id, link_id, visited_at, referrer, referrer_host, browser, os, device, language, country, visitor_hash, is_bot, bot_name, is_repeat"
+
+func (r *sqlVisitRepository) Create(ctx context.Context, visit *entity.Visit) (*entity.Visit, error) {
+	id := newID()
+	_, err := r.db.exec(ctx, "INSERT INTO visits ("+visitColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		id, visit.LinkID, toMillis(visit.VisitedAt), visit.Referrer, visit.ReferrerHost, visit.Browser, visit.OS,
+		visit.Device, visit.Language, visit.Country, visit.VisitorHash, visit.Bot, visit.BotName, visit.Repeat)
+	if err != nil {
+		return nil, err
+	}
+	visit.ID = id
+	return visit, nil
+}
+
+func (r *sqlVisitRepository) DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error) {
+	if len(linkIDs) == 0 {
+		return 0, nil
+	}
+	return affected(r.db.exec(ctx, "DELETE FROM visits WHERE link_id IN ("+placeholders(len(linkIDs))+")", stringArgs(linkIDs)...))
+}
+
+func (r *sqlVisitRepository) CountByInterval(ctx context.Context, q VisitQuery, interval entity.StatsInterval, loc *time.Location) ([]*entity.StatsBucket, error) {
+	if len(q.LinkIDs) == 0 {
+		return []*entity.StatsBucket{}, nil
+	}
+	where, args := sqlVisitMatch(q)
+	slot := visitSlot.Milliseconds()
+	counts := map[time.Time]int64{}
+	err := r.scanGroups(ctx, "SELECT visited_at / ?, COUNT(*) FROM visits WHERE "+where+" GROUP BY visited_at / ?",
+		append(append([]any{slot}, args...), slot), func(rows *sql.Rows) error {
+			var n, clicks int64
+			if err := rows.Scan(&n, &clicks); err != nil {
+				return err
+			}
+			counts[BucketStart(fromMillis(n*slot), interval, loc)] += clicks
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return sortedBuckets(counts), nil
+}
+
+func (r *sqlVisitRepository) CountByLink(ctx context.Context, q VisitQuery) (map[string]int64, error) {
+	counts := map[string]int64{}
+	if len(q.LinkIDs) == 0 {
+		return counts, nil
+	}
+	where, args := sqlVisitMatch(q)
+	err := r.scanGroups(ctx, "SELECT link_id, COUNT(*) FROM visits WHERE "+where+" GROUP BY link_id", args, func(rows *sql.Rows) error {
+		var linkID string
+		var clicks int64
+		if err := rows.Scan(&linkID, &clicks); err != nil {
+			return err
+		}
+		counts[linkID] = clicks
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (r *sqlVisitRepository) Count(ctx context.Context, q VisitQuery) (int64, error) {
+	if len(q.LinkIDs) == 0 {
+		return 0, nil
+	}
+	where, args := sqlVisitMatch(q)
+	var count int64
+	err := r.db.queryRow(ctx, "SELECT COUNT(*) FROM visits WHERE "+where, args...).Scan(&count)
+	return count, err
+}
+
+// TopValues ranks the values in Go, so ties are broken bytewise whatever the
+// database collation.
+func (r *sqlVisitRepository) TopValues(ctx context.Context, q VisitQuery, dimension entity.BreakdownDimension, limit int) ([]*entity.BreakdownItem, error) {
+	column, ok := sqlVisitColumns[dimension]
+	if !ok || len(q.LinkIDs) == 0 {
+		return []*entity.BreakdownItem{}, nil
+	}
+	where, args := sqlVisitMatch(q)
+	counts := map[string]int64{}
+	err := r.scanGroups(ctx, "SELECT "+column+", COUNT(*) FROM visits WHERE "+where+" GROUP BY "+column, args, func(rows *sql.Rows) error {
+		var value string
+		var clicks int64
+		if err := rows.Scan(&value, &clicks); err != nil {
+			return err
+		}
+		counts[value] = clicks
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return topItems(counts, limit), nil
+}
+
+func (r *sqlVisitRepository) SourceCounts(ctx context.Context, from, to time.Time, minClicks int64) ([]*LinkSources, error) {
+	query := `SELECT link_id, CAST(SUM(clicks) AS BIGINT), COUNT(*), MAX(clicks) FROM (
+			SELECT link_id, visitor_hash, COUNT(*) AS clicks FROM visits
+			WHERE visited_at >= ? AND visited_at < ? AND is_bot = ? AND is_repeat = ?
+			GROUP BY link_id, visitor_hash
+		) per_visitor GROUP BY link_id HAVING SUM(clicks) >= ?`
+	sources := make([]*LinkSources, 0)
+	err := r.scanGroups(ctx, query, []any{toMillis(from), toMillis(to), false, false, minClicks}, func(rows *sql.Rows) error {
+		var s LinkSources
+		if err := rows.Scan(&s.LinkID, &s.Clicks, &s.Sources, &s.TopSourceClicks); err != nil {
+			return err
+		}
+		sources = append(sources, &s)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+func (r *sqlVisitRepository) Each(ctx context.Context, q VisitQuery, fn func(*entity.Visit) error) error {
+	if len(q.LinkIDs) == 0 {
+		return nil
+	}
+	where, args := sqlVisitMatch(q)
+	return r.scanGroups(ctx, "SELECT "+visitColumns+" FROM visits WHERE "+where+" ORDER BY visited_at", args, func(rows *sql.Rows) error {
+		var visit entity.Visit
+		var visitedAt int64
+		err := rows.Scan(&visit.ID, &visit.LinkID, &visitedAt, &visit.Referrer, &visit.ReferrerHost, &visit.Browser, &visit.OS,
+			&visit.Device, &visit.Language, &visit.Country, &visit.VisitorHash, &visit.Bot, &visit.BotName, &visit.Repeat)
+		if err != nil {
+			return err
+		}
+		visit.VisitedAt = fromMillis(visitedAt)
+		return fn(&visit)
+	})
+}
+
+func (r *sqlVisitRepository) Rollup(ctx context.Context, from, to time.Time) ([]*entity.VisitRollup, error) {
+	// Group by every column a rollup keeps, so only the distinct
+	// combinations leave the database.
+	hour := time.Hour.Milliseconds()
+	dimensions := "referrer_host, country, device, browser, os, language"
+	query := "SELECT link_id, visited_at / ?, is_bot, " + dimensions + ", COUNT(*) FROM visits" +
+		" WHERE visited_at >= ? AND visited_at < ? AND is_repeat = ?" +
+		" GROUP BY link_id, visited_at / ?, is_bot, " + dimensions
+	builder := NewRollupBuilder(entity.IntervalHour)
+	err := r.scanGroups(ctx, query, []any{hour, toMillis(from), toMillis(to), false, hour}, func(rows *sql.Rows) error {
+		var visit entity.Visit
+		var n, count int64
+		err := rows.Scan(&visit.LinkID, &n, &visit.Bot, &visit.ReferrerHost, &visit.Country, &visit.Device,
+			&visit.Browser, &visit.OS, &visit.Language, &count)
+		if err != nil {
+			return err
+		}
+		visit.VisitedAt = fromMillis(n * hour)
+		builder.AddVisit(&visit, count)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return builder.Rollups(), nil
+}
+
+func (r *sqlVisitRepository) Oldest(ctx context.Context) (time.Time, error) {
+	var oldest sql.NullInt64
+	if err := r.db.queryRow(ctx, "SELECT MIN(visited_at) FROM visits").Scan(&oldest); err != nil {
+		return time.Time{}, err
+	}
+	if !oldest.Valid {
+		return time.Time{}, ErrNotFound
+	}
+	return fromMillis(oldest.Int64), nil
+}
+
+func (r *sqlVisitRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	return affected(r.db.exec(ctx, "DELETE FROM visits WHERE visited_at < ?", toMillis(before)))
+}
+
+// scanGroups runs query and calls fn with each row, stopping at the first
+// error.
+func (r *sqlVisitRepository) scanGroups(ctx context.Context, query string, args []any, fn func(*sql.Rows) error) error {
+	return scanRows(ctx, r.db.sqlRunner, query, args, fn)
+}
+
+// sqlVisitMatch translates q into a WHERE clause that can use the
+// (link_id, visited_at) index.
+func sqlVisitMatch(q VisitQuery) (string, []any) {
+	conds := []string{"link_id IN (" + placeholders(len(q.LinkIDs)) + ")", "visited_at >= ?", "visited_at < ?"}
+	args := append(stringArgs(q.LinkIDs), toMillis(q.From), toMillis(q.To))
+	switch q.Bots {
+	case BotsExcluded:
+		conds = append(conds, "is_bot = ?")
+		args = append(args, false)
+	case BotsOnly:
+		conds = append(conds, "is_bot = ?")
+		args = append(args, true)
+	}
+	if !q.IncludeRepeats {
+		conds = append(conds, "is_repeat = ?")
+		args = append(args, false)
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+type sqlVisitRollupRepository struct {
+	db *SQLDB
+}
+
+func NewSQLVisitRollupRepository(db *SQLDB) VisitRollupRepository {
+	return &sqlVisitRollupRepository{db: db}
+}
+
+// rollupWatermark names the row of rollup_state holding the watermark.
+const rollupWatermark = "visits"
+
+func (r *sqlVisitRollupRepository) Save(ctx context.Context, rollups []*entity.VisitRollup) error {
+	return r.db.inTx(ctx, func(tx sqlRunner) error {
+		for _, rollup := range rollups {
+			key := []any{rollup.LinkID, string(rollup.Granularity), toMillis(rollup.Start)}
+			const match = " WHERE link_id = ? AND granularity = ? AND start = ?"
+			if _, err := tx.exec(ctx, "DELETE FROM visit_rollup_values"+match, key...); err != nil {
+				return err
+			}
+			if _, err := tx.exec(ctx, "DELETE FROM visit_rollups"+match, key...); err != nil {
+				return err
+			}
+			_, err := tx.exec(ctx, "INSERT INTO visit_rollups (link_id, granularity, start, clicks, bot_hits) VALUES (?, ?, ?, ?, ?)",
+				append(key, rollup.Clicks, rollup.BotHits)...)
+			if err != nil {
+				return err
+			}
+			for _, value := range rollup.Values {
+				_, err := tx.exec(ctx, "INSERT INTO visit_rollup_values (link_id, granularity, start, dimension, value, clicks) VALUES (?, ?, ?, ?, ?, ?)",
+					append(key, string(value.Dimension), value.Value, value.Clicks)...)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func (r *sqlVisitRollupRepository) CountByInterval(ctx context.Context, q RollupQuery, interval entity.StatsInterval, loc *time.Location) ([]*entity.StatsBucket, error) {
+	if len(q.LinkIDs) == 0 {
+		return []*entity.StatsBucket{}, nil
+	}
+	where, args := sqlRollupMatch(q)
+	counts := map[time.Time]int64{}
+	err := scanRows(ctx, r.db.sqlRunner, "SELECT start, CAST(SUM(clicks) AS BIGINT) FROM visit_rollups WHERE "+where+" AND clicks > 0 GROUP BY start", args,
+		func(rows *sql.Rows) error {
+			var start, clicks int64
+			if err := rows.Scan(&start, &clicks); err != nil {
+				return err
+			}
+			counts[BucketStart(fromMillis(start), interval, loc)] += clicks
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return sortedBuckets(counts), nil
+}
+
+func (r *sqlVisitRollupRepository) CountByLink(ctx context.Context, q RollupQuery) (map[string]int64, error) {
+	counts := map[string]int64{}
+	if len(q.LinkIDs) == 0 {
+		return counts, nil
+	}
+	where, args := sqlRollupMatch(q)
+	err := scanRows(ctx, r.db.sqlRunner, "SELECT link_id, CAST(SUM(clicks) AS BIGINT) FROM visit_rollups WHERE "+where+" AND clicks > 0 GROUP BY link_id", args,
+		func(rows *sql.Rows) error {
+			var linkID string
+			var clicks int64
+			if err := rows.Scan(&linkID, &clicks); err != nil {
+				return err
+			}
+			counts[linkID] = clicks
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+func (r *sqlVisitRollupRepository) Count(ctx context.Context, q RollupQuery) (int64, int64, error) {
+	if len(q.LinkIDs) == 0 {
+		return 0, 0, nil
+	}
+	where, args := sqlRollupMatch(q)
+	var clicks, bots int64
+	err := r.db.queryRow(ctx, "SELECT CAST(COALESCE(SUM(clicks), 0) AS BIGINT), CAST(COALESCE(SUM(bot_hits), 0) AS BIGINT) FROM visit_rollups WHERE "+where, args...).
+		Scan(&clicks, &bots)
+	return clicks, bots, err
+}
+
+func (r *sqlVisitRollupRepository) TopValues(ctx context.Context, q RollupQuery, dimension entity.BreakdownDimension, limit int) ([]*entity.BreakdownItem, error) {
+	if len(q.LinkIDs) == 0 {
+		return []*entity.BreakdownItem{}, nil
+	}
+	where, args := sqlRollupMatch(q)
+	counts := map[string]int64{}
+	err := scanRows(ctx, r.db.sqlRunner, "SELECT value, CAST(SUM(clicks) AS BIGINT) FROM visit_rollup_values WHERE "+where+" AND dimension = ? GROUP BY value",
+		append(args, string(dimension)), func(rows *sql.Rows) error {
+			var value string
+			var clicks int64
+			if err := rows.Scan(&value, &clicks); err != nil {
+				return err
+			}
+			counts[value] = clicks
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return topItems(counts, limit), nil
+}
+
+func (r *sqlVisitRollupRepository) DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error) {
+	if len(linkIDs) == 0 {
+		return 0, nil
+	}
+	var deleted int64
+	err := r.db.inTx(ctx, func(tx sqlRunner) error {
+		in := " WHERE link_id IN (" + placeholders(len(linkIDs)) + ")"
+		if _, err := tx.exec(ctx, "DELETE FROM visit_rollup_values"+in, stringArgs(linkIDs)...); err != nil {
+			return err
+		}
+		var err error
+		deleted, err = affected(tx.exec(ctx, "DELETE FROM visit_rollups"+in, stringArgs(linkIDs)...))
+		return err
+	})
+	return deleted, err
+}
+
+func (r *sqlVisitRollupRepository) Watermark(ctx context.Context) (time.Time, error) {
+	var rolledUpTo int64
+	err := r.db.queryRow(ctx, "SELECT rolled_up_to FROM rollup_state WHERE name = ?", rollupWatermark).Scan(&rolledUpTo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fromMillis(rolledUpTo), nil
+}
+
+func (r *sqlVisitRollupRepository) SetWatermark(ctx context.Context, t time.Time) error {
+	_, err := r.db.exec(ctx, `INSERT INTO rollup_state (name, rolled_up_to) VALUES (?, ?)
+		ON CONFLICT (name) DO UPDATE SET rolled_up_to = excluded.rolled_up_to`, rollupWatermark, toMillis(t))
+	return err
+}
+
+func sqlRollupMatch(q RollupQuery) (string, []any) {
+	where := "link_id IN (" + placeholders(len(q.LinkIDs)) + ") AND granularity = ? AND start >= ? AND start < ?"
+	return where, append(stringArgs(q.LinkIDs), string(q.Granularity), toMillis(q.From), toMillis(q.To))
+}
+
+// scanRows runs query and calls fn with each row, stopping at the first
+// error.
+func scanRows(ctx context.Context, db sqlRunner, query string, args []any, fn func(*sql.Rows) error) error {
+	rows, err := db.query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+)
+
+type sqlWebhookRepository struct {
+	db *SQLDB
+}
+
+func NewSQLWebhookRepository(db *SQLDB) WebhookRepository {
+	return &sqlWebhookRepository{db: db}
+}
+
+const webhookColumns = "id, owner_id, url, events, secret, created_at"
+
+func (r *sqlWebhookRepository) Create(ctx context.Context, webhook *entity.Webhook) (*entity.Webhook, error) {
+	events, err := json.Marshal(webhook.Events)
+	if err != nil {
+		return nil, err
+	}
+	id := newID()
+	_, err = r.db.exec(ctx, "INSERT INTO webhooks ("+webhookColumns+") VALUES (?, ?, ?, ?, ?, ?)",
+		id, webhook.OwnerID, webhook.URL, string(events), webhook.Secret, toMillis(webhook.CreatedAt))
+	if err != nil {
+		return nil, err
+	}
+	webhook.ID = id
+	return webhook, nil
+}
+
+func (r *sqlWebhookRepository) GetByID(ctx context.Context, id string) (*entity.Webhook, error) {
+	webhook, err := scanWebhook(r.db.queryRow(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return webhook, err
+}
+
+func (r *sqlWebhookRepository) ListByOwner(ctx context.Context, ownerID string) ([]*entity.Webhook, error) {
+	return r.listOwned(ctx, ownerID, func(*entity.Webhook) bool { return true })
+}
+
+// ListSubscribed filters the owner's webhooks in Go, as events are stored as
+// JSON and an owner has few webhooks.
+func (r *sqlWebhookRepository) ListSubscribed(ctx context.Context, ownerID string, event entity.WebhookEvent) ([]*entity.Webhook, error) {
+	return r.listOwned(ctx, ownerID, func(webhook *entity.Webhook) bool { return slices.Contains(webhook.Events, event) })
+}
+
+// listOwned returns the owner's matching webhooks, newest first.
+func (r *sqlWebhookRepository) listOwned(ctx context.Context, ownerID string, matching func(*entity.Webhook) bool) ([]*entity.Webhook, error) {
+	webhooks := make([]*entity.Webhook, 0)
+	query := "SELECT " + webhookColumns + " FROM webhooks WHERE owner_id = ? ORDER BY created_at DESC, id DESC"
+	err := scanRows(ctx, r.db.sqlRunner, query, []any{ownerID}, func(rows *sql.Rows) error {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return err
+		}
+		if matching(webhook) {
+			webhooks = append(webhooks, webhook)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *sqlWebhookRepository) Delete(ctx context.Context, id string) error {
+	n, err := affected(r.db.exec(ctx, "DELETE FROM webhooks WHERE id = ?", id))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func scanWebhook(row rowScanner) (*entity.Webhook, error) {
+	var webhook entity.Webhook
+	var events string
+	var createdAt int64
+	if err := row.Scan(&webhook.ID, &webhook.OwnerID, &webhook.URL, &events, &webhook.Secret, &createdAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(events), &webhook.Events); err != nil {
+		return nil, err
+	}
+	webhook.CreatedAt = fromMillis(createdAt)
+	return &webhook, nil
+}
+
+type sqlWebhookDeliveryRepository struct {
+	db *SQLDB
+}
+
+func NewSQLWebhookDeliveryRepository(db *SQLDB) WebhookDeliveryRepository {
+	return &sqlWebhookDeliveryRepository{db: db}
+}
+
+const deliveryColumns = `id, webhook_id, owner_id, event, payload, status, redelivery_of, attempts,
+	next_attempt_at, last_attempt_at, response_status, last_error, created_at`
+
+func (r *sqlWebhookDeliveryRepository) Create(ctx context.Context, delivery *entity.WebhookDelivery) (*entity.WebhookDelivery, error) {
+	id := newID()
+	_, err := r.db.exec(ctx, "INSERT INTO webhook_deliveries ("+deliveryColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		id, delivery.WebhookID, delivery.OwnerID, string(delivery.Event), string(delivery.Payload), string(delivery.Status),
+		delivery.RedeliveryOf, delivery.Attempts, optionalMillis(delivery.NextAttemptAt), optionalMillis(delivery.LastAttemptAt),
+		delivery.ResponseStatus, delivery.LastError, toMillis(delivery.CreatedAt))
+	if err != nil {
+		return nil, err
+	}
+	delivery.ID = id
+	return delivery, nil
+}
+
+func (r *sqlWebhookDeliveryRepository) GetByID(ctx context.Context, id string) (*entity.WebhookDelivery, error) {
+	return r.findOne(ctx, id)
+}
+
+func (r *sqlWebhookDeliveryRepository) ListByWebhook(ctx context.Context, webhookID string, limit int) ([]*entity.WebhookDelivery, error) {
+	deliveries := make([]*entity.WebhookDelivery, 0)
+	query := "SELECT " + deliveryColumns + " FROM webhook_deliveries WHERE webhook_id = ? ORDER BY created_at DESC, id DESC LIMIT ?"
+	err := scanRows(ctx, r.db.sqlRunner, query, []any{webhookID, limit}, func(rows *sql.Rows) error {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return err
+		}
+		deliveries = append(deliveries, delivery)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ClaimDue postpones the earliest due delivery only if it is still due, and
+// tries the next one when another worker got there first.
+func (r *sqlWebhookDeliveryRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*entity.WebhookDelivery, error) {
+	for {
+		var id string
+		var due int64
+		err := r.db.queryRow(ctx, `SELECT id, next_attempt_at FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at LIMIT 1`,
+			string(entity.DeliveryPending), toMillis(now)).Scan(&id, &due)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		n, err := affected(r.db.exec(ctx, `UPDATE webhook_deliveries SET next_attempt_at = ?
+			WHERE id = ? AND status = ? AND next_attempt_at = ?`,
+			toMillis(now.Add(lease)), id, string(entity.DeliveryPending), due))
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			return r.findOne(ctx, id)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (r *sqlWebhookDeliveryRepository) Update(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	n, err := affected(r.db.exec(ctx, `UPDATE webhook_deliveries SET status = ?, attempts = ?, next_attempt_at = ?,
+		last_attempt_at = ?, response_status = ?, last_error = ? WHERE id = ?`,
+		string(delivery.Status), delivery.Attempts, optionalMillis(delivery.NextAttemptAt), optionalMillis(delivery.LastAttemptAt),
+		delivery.ResponseStatus, delivery.LastError, delivery.ID))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *sqlWebhookDeliveryRepository) DeleteByWebhookID(ctx context.Context, webhookID string) (int64, error) {
+	return affected(r.db.exec(ctx, "DELETE FROM webhook_deliveries WHERE webhook_id = ?", webhookID))
+}
+
+func (r *sqlWebhookDeliveryRepository) findOne(ctx context.Context, id string) (*entity.WebhookDelivery, error) {
+	delivery, err := scanDelivery(r.db.queryRow(ctx, "SELECT "+deliveryColumns+" FROM webhook_deliveries WHERE id = ?", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return delivery, err
+}
+
+func scanDelivery(row rowScanner) (*entity.WebhookDelivery, error) {
+	var delivery entity.WebhookDelivery
+	var payload string
+	var nextAttemptAt, lastAttemptAt sql.NullInt64
+	var createdAt int64
+	err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.OwnerID, &delivery.Event, &payload, &delivery.Status,
+		&delivery.RedeliveryOf, &delivery.Attempts, &nextAttemptAt, &lastAttemptAt, &delivery.ResponseStatus,
+		&delivery.LastError, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	if payload != "" {
+		delivery.Payload = json.RawMessage(payload)
+	}
+	delivery.NextAttemptAt = optionalTime(nextAttemptAt)
+	delivery.LastAttemptAt = optionalTime(lastAttemptAt)
+	delivery.CreatedAt = fromMillis(createdAt)
+	return &delivery, nil
+}
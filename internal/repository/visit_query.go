@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+)
+
+// BotFilter says whether a VisitQuery selects bot hits.
+type BotFilter int
+
+const (
+	// BotsExcluded selects human visits only. It is the zero value, so
+	// analytics leave bots out unless asked otherwise.
+	BotsExcluded BotFilter = iota
+	// BotsIncluded selects all visits.
+	BotsIncluded
+	// BotsOnly selects bot hits only.
+	BotsOnly
+)
+
+// Matches reports whether a visit with the given bot flag passes the filter.
+func (f BotFilter) Matches(bot bool) bool {
+	switch f {
+	case BotsIncluded:
+		return true
+	case BotsOnly:
+		return bot
+	default:
+		return !bot
+	}
+}
+
+// VisitQuery selects the visits of the given links within [From, To).
+// Repeat visits are left out unless IncludeRepeats is set.
+type VisitQuery struct {
+	LinkIDs        []string
+	From           time.Time
+	To             time.Time
+	Bots           BotFilter
+	IncludeRepeats bool
+}
+
+// Matches reports whether visit is selected by q.
+func (q VisitQuery) Matches(visit *entity.Visit) bool {
+	if visit.VisitedAt.Before(q.From) || !visit.VisitedAt.Before(q.To) {
+		return false
+	}
+	if !q.Bots.Matches(visit.Bot) || (visit.Repeat && !q.IncludeRepeats) {
+		return false
+	}
+	for _, id := range q.LinkIDs {
+		if id == visit.LinkID {
+			return true
+		}
+	}
+	return false
+}
+
+// LinkSources summarises where a link's clicks in a window came from.
+type LinkSources struct {
+	LinkID string
+	Clicks int64
+	// Sources is the number of distinct visitors and TopSourceClicks the
+	// clicks of the busiest one.
+	Sources         int64
+	TopSourceClicks int64
+}
+
+// VisitFields maps breakdown dimensions to the visit field holding them.
+var VisitFields = map[entity.BreakdownDimension]string{
+	entity.DimensionReferrer: "referrerHost",
+	entity.DimensionCountry:  "country",
+	entity.DimensionDevice:   "device",
+	entity.DimensionBrowser:  "browser",
+	entity.DimensionOS:       "os",
+	entity.DimensionLanguage: "language",
+}
+
+// VisitDimension returns the value of dimension recorded on visit.
+func VisitDimension(visit *entity.Visit, dimension entity.BreakdownDimension) string {
+	switch dimension {
+	case entity.DimensionReferrer:
+		return visit.ReferrerHost
+	case entity.DimensionCountry:
+		return visit.Country
+	case entity.DimensionDevice:
+		return visit.Device
+	case entity.DimensionBrowser:
+		return visit.Browser
+	case entity.DimensionOS:
+		return visit.OS
+	case entity.DimensionLanguage:
+		return visit.Language
+	}
+	return ""
+}
+
+// BucketStart truncates t to the start of its interval in loc. Weeks start on
+// Monday.
+func BucketStart(t time.Time, interval entity.StatsInterval, loc *time.Location) time.Time {
+	t = t.In(loc)
+	switch interval {
+	case entity.IntervalHour:
+		return t.Truncate(time.Minute).Add(-time.Duration(t.Minute()) * time.Minute)
+	case entity.IntervalWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+}
+
+// NextBucket returns the start of the interval following the one starting at
+// start. Days and weeks follow the calendar, so they may be 23 or 25 hours
+// long across a daylight saving change.
+func NextBucket(start time.Time, interval entity.StatsInterval) time.Time {
+	switch interval {
+	case entity.IntervalHour:
+		return start.Add(time.Hour)
+	case entity.IntervalWeek:
+		return start.AddDate(0, 0, 7)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
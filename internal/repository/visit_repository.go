@@ -2,13 +2,45 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// VisitRepository stores visits. Implementations must pass the conformance
+// suite in package repotest.
 type VisitRepository interface {
 	Create(ctx context.Context, visit *entity.Visit) (*entity.Visit, error)
+	DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error)
+	// CountByInterval counts matching visits per interval in loc, returning
+	// only non-empty buckets in chronological order.
+	CountByInterval(ctx context.Context, q VisitQuery, interval entity.StatsInterval, loc *time.Location) ([]*entity.StatsBucket, error)
+	// CountByLink counts matching visits per link ID.
+	CountByLink(ctx context.Context, q VisitQuery) (map[string]int64, error)
+	// Count counts matching visits.
+	Count(ctx context.Context, q VisitQuery) (int64, error)
+	// TopValues returns the limit most common values of dimension among
+	// matching visits, most common first and ties broken by value. Visits
+	// without a value are grouped under "".
+	TopValues(ctx context.Context, q VisitQuery, dimension entity.BreakdownDimension, limit int) ([]*entity.BreakdownItem, error)
+	// SourceCounts summarises the counted clicks in [from, to) per link and
+	// visitor, returning the links with at least minClicks clicks.
+	SourceCounts(ctx context.Context, from, to time.Time, minClicks int64) ([]*LinkSources, error)
+	// Each calls fn with every matching visit, oldest first, without loading
+	// them all into memory. It stops at the first error fn returns.
+	Each(ctx context.Context, q VisitQuery, fn func(*entity.Visit) error) error
+	// Rollup sums the visits in [from, to) into hourly rollups per link.
+	Rollup(ctx context.Context, from, to time.Time) ([]*entity.VisitRollup, error)
+	// Oldest returns the time of the oldest visit, or ErrNotFound if there
+	// are none.
+	Oldest(ctx context.Context) (time.Time, error)
+	// DeleteBefore deletes the visits made before the given time.
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
 }
 
 type mongoVisitRepository struct {
@@ -22,9 +54,243 @@ func NewMongoVisitRepository(db *mongo.Database) VisitRepository {
 }
 
 func (r *mongoVisitRepository) Create(ctx context.Context, visit *entity.Visit) (*entity.Visit, error) {
-	_, err := r.collection.InsertOne(ctx, visit)
+	res, err := r.collection.InsertOne(ctx, visit)
 	if err != nil {
 		return nil, err
 	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		visit.ID = oid.Hex()
+	}
 	return visit, nil
 }
+
+func (r *mongoVisitRepository) DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error) {
+	if len(linkIDs) == 0 {
+		return 0, nil
+	}
+	res, err := r.collection.DeleteMany(ctx, bson.M{"linkId": bson.M{"$in": linkIDs}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+func (r *mongoVisitRepository) CountByInterval(ctx context.Context, q VisitQuery, interval entity.StatsInterval, loc *time.Location) ([]*entity.StatsBucket, error) {
+	if len(q.LinkIDs) == 0 {
+		return []*entity.StatsBucket{}, nil
+	}
+	trunc := bson.M{"date": "$visitedAt", "unit": string(interval), "timezone": loc.String()}
+	if interval == entity.IntervalWeek {
+		trunc["startOfWeek"] = "monday"
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: visitMatch(q)}},
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"$dateTrunc": trunc}, "clicks": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+	var rows []struct {
+		Start  time.Time `bson:"_id"`
+		Clicks int64     `bson:"clicks"`
+	}
+	if err := r.aggregate(ctx, pipeline, &rows); err != nil {
+		return nil, err
+	}
+	buckets := make([]*entity.StatsBucket, len(rows))
+	for i, row := range rows {
+		buckets[i] = &entity.StatsBucket{Start: row.Start.In(loc), Clicks: row.Clicks}
+	}
+	return buckets, nil
+}
+
+func (r *mongoVisitRepository) CountByLink(ctx context.Context, q VisitQuery) (map[string]int64, error) {
+	if len(q.LinkIDs) == 0 {
+		return map[string]int64{}, nil
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: visitMatch(q)}},
+		{{Key: "$group", Value: bson.M{"_id": "$linkId", "clicks": bson.M{"$sum": 1}}}},
+	}
+	var rows []struct {
+		LinkID string `bson:"_id"`
+		Clicks int64  `bson:"clicks"`
+	}
+	if err := r.aggregate(ctx, pipeline, &rows); err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.LinkID] = row.Clicks
+	}
+	return counts, nil
+}
+
+func (r *mongoVisitRepository) Count(ctx context.Context, q VisitQuery) (int64, error) {
+	if len(q.LinkIDs) == 0 {
+		return 0, nil
+	}
+	return r.collection.CountDocuments(ctx, visitMatch(q))
+}
+
+func (r *mongoVisitRepository) TopValues(ctx context.Context, q VisitQuery, dimension entity.BreakdownDimension, limit int) ([]*entity.BreakdownItem, error) {
+	field, ok := VisitFields[dimension]
+	if !ok || len(q.LinkIDs) == 0 {
+		return []*entity.BreakdownItem{}, nil
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: visitMatch(q)}},
+		// Missing fields group as null; fold them into "" like the other backends.
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"$ifNull": bson.A{"$" + field, ""}}, "clicks": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "clicks", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	var rows []struct {
+		Value  string `bson:"_id"`
+		Clicks int64  `bson:"clicks"`
+	}
+	if err := r.aggregate(ctx, pipeline, &rows); err != nil {
+		return nil, err
+	}
+	items := make([]*entity.BreakdownItem, len(rows))
+	for i, row := range rows {
+		items[i] = &entity.BreakdownItem{Value: row.Value, Clicks: row.Clicks}
+	}
+	return items, nil
+}
+
+func (r *mongoVisitRepository) SourceCounts(ctx context.Context, from, to time.Time, minClicks int64) ([]*LinkSources, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"visitedAt": bson.M{"$gte": from, "$lt": to},
+			"bot":       bson.M{"$ne": true},
+			"repeat":    bson.M{"$ne": true},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    bson.M{"linkId": "$linkId", "visitor": bson.M{"$ifNull": bson.A{"$visitorHash", ""}}},
+			"clicks": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     "$_id.linkId",
+			"clicks":  bson.M{"$sum": "$clicks"},
+			"sources": bson.M{"$sum": 1},
+			"top":     bson.M{"$max": "$clicks"},
+		}}},
+		{{Key: "$match", Value: bson.M{"clicks": bson.M{"$gte": minClicks}}}},
+	}
+	var rows []struct {
+		LinkID  string `bson:"_id"`
+		Clicks  int64  `bson:"clicks"`
+		Sources int64  `bson:"sources"`
+		Top     int64  `bson:"top"`
+	}
+	if err := r.aggregate(ctx, pipeline, &rows); err != nil {
+		return nil, err
+	}
+	sources := make([]*LinkSources, len(rows))
+	for i, row := range rows {
+		sources[i] = &LinkSources{LinkID: row.LinkID, Clicks: row.Clicks, Sources: row.Sources, TopSourceClicks: row.Top}
+	}
+	return sources, nil
+}
+
+func (r *mongoVisitRepository) Each(ctx context.Context, q VisitQuery, fn func(*entity.Visit) error) error {
+	if len(q.LinkIDs) == 0 {
+		return nil
+	}
+	cursor, err := r.collection.Find(ctx, visitMatch(q), options.Find().SetSort(bson.D{{Key: "visitedAt", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var visit entity.Visit
+		if err := cursor.Decode(&visit); err != nil {
+			return err
+		}
+		if err := fn(&visit); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func (r *mongoVisitRepository) Rollup(ctx context.Context, from, to time.Time) ([]*entity.VisitRollup, error) {
+	// Group by every field a rollup keeps, so only the distinct combinations
+	// leave the database.
+	group := bson.M{
+		"linkId": "$linkId",
+		"hour":   bson.M{"$dateTrunc": bson.M{"date": "$visitedAt", "unit": "hour"}},
+		"bot":    bson.M{"$ifNull": bson.A{"$bot", false}},
+	}
+	for _, field := range VisitFields {
+		group[field] = bson.M{"$ifNull": bson.A{"$" + field, ""}}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"visitedAt": bson.M{"$gte": from, "$lt": to}, "repeat": bson.M{"$ne": true}}}},
+		{{Key: "$group", Value: bson.M{"_id": group, "count": bson.M{"$sum": 1}}}},
+	}
+	var rows []struct {
+		Visit struct {
+			entity.Visit `bson:",inline"`
+			Hour         time.Time `bson:"hour"`
+		} `bson:"_id"`
+		Count int64 `bson:"count"`
+	}
+	if err := r.aggregate(ctx, pipeline, &rows); err != nil {
+		return nil, err
+	}
+	builder := NewRollupBuilder(entity.IntervalHour)
+	for _, row := range rows {
+		visit := row.Visit.Visit
+		visit.VisitedAt = row.Visit.Hour
+		builder.AddVisit(&visit, row.Count)
+	}
+	return builder.Rollups(), nil
+}
+
+func (r *mongoVisitRepository) Oldest(ctx context.Context) (time.Time, error) {
+	var visit entity.Visit
+	err := r.collection.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.D{{Key: "visitedAt", Value: 1}})).Decode(&visit)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, ErrNotFound
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return visit.VisitedAt, nil
+}
+
+func (r *mongoVisitRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.collection.DeleteMany(ctx, bson.M{"visitedAt": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+func (r *mongoVisitRepository) aggregate(ctx context.Context, pipeline mongo.Pipeline, results interface{}) error {
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return cursor.All(ctx, results)
+}
+
+// visitMatch translates q into a $match stage that can use the
+// {linkId, visitedAt} index.
+func visitMatch(q VisitQuery) bson.M {
+	match := bson.M{
+		"linkId":    bson.M{"$in": q.LinkIDs},
+		"visitedAt": bson.M{"$gte": q.From, "$lt": q.To},
+	}
+	// Human visits are stored without the bot field, so match on $ne.
+	switch q.Bots {
+	case BotsExcluded:
+		match["bot"] = bson.M{"$ne": true}
+	case BotsOnly:
+		match["bot"] = true
+	}
+	if !q.IncludeRepeats {
+		match["repeat"] = bson.M{"$ne": true}
+	}
+	return match
+}
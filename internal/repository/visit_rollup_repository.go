@@ -0,0 +1,332 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// VisitRollupRepository stores hourly and daily sums of visits. Its
+// aggregates mirror those of VisitRepository for the counted clicks.
+type VisitRollupRepository interface {
+	// Save stores rollups, replacing any with the same link, granularity and
+	// start.
+	Save(ctx context.Context, rollups []*entity.VisitRollup) error
+	// CountByInterval sums the clicks of matching rollups per interval in
+	// loc, returning only non-empty buckets in chronological order.
+	CountByInterval(ctx context.Context, q RollupQuery, interval entity.StatsInterval, loc *time.Location) ([]*entity.StatsBucket, error)
+	// CountByLink sums the clicks of matching rollups per link ID.
+	CountByLink(ctx context.Context, q RollupQuery) (map[string]int64, error)
+	// Count sums the clicks and bot hits of matching rollups.
+	Count(ctx context.Context, q RollupQuery) (clicks, botHits int64, err error)
+	// TopValues returns the limit most clicked values of dimension among
+	// matching rollups, most clicked first and ties broken by value.
+	TopValues(ctx context.Context, q RollupQuery, dimension entity.BreakdownDimension, limit int) ([]*entity.BreakdownItem, error)
+	DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error)
+	// Watermark returns the time up to which visits have been rolled up, or
+	// the zero time if they never have been.
+	Watermark(ctx context.Context) (time.Time, error)
+	SetWatermark(ctx context.Context, t time.Time) error
+}
+
+// RollupQuery selects the rollups of the given granularity and links that
+// start within [From, To).
+type RollupQuery struct {
+	LinkIDs     []string
+	Granularity entity.StatsInterval
+	From        time.Time
+	To          time.Time
+}
+
+// Matches reports whether rollup is selected by q.
+func (q RollupQuery) Matches(rollup *entity.VisitRollup) bool {
+	if rollup.Granularity != q.Granularity || rollup.Start.Before(q.From) || !rollup.Start.Before(q.To) {
+		return false
+	}
+	for _, id := range q.LinkIDs {
+		if id == rollup.LinkID {
+			return true
+		}
+	}
+	return false
+}
+
+// RollupStart truncates t to the start of its UTC hour or day.
+func RollupStart(t time.Time, granularity entity.StatsInterval) time.Time {
+	if granularity == entity.IntervalDay {
+		return t.UTC().Truncate(24 * time.Hour)
+	}
+	return t.UTC().Truncate(time.Hour)
+}
+
+// RollupBuilder sums visits, or rollups of a finer granularity, into
+// rollups of one granularity.
+type RollupBuilder struct {
+	granularity entity.StatsInterval
+	rollups     map[rollupKey]*entity.VisitRollup
+	values      map[rollupValueKey]*entity.RollupValue
+}
+
+type rollupKey struct {
+	linkID string
+	start  int64
+}
+
+type rollupValueKey struct {
+	rollupKey
+	dimension entity.BreakdownDimension
+	value     string
+}
+
+func NewRollupBuilder(granularity entity.StatsInterval) *RollupBuilder {
+	return &RollupBuilder{
+		granularity: granularity,
+		rollups:     make(map[rollupKey]*entity.VisitRollup),
+		values:      make(map[rollupValueKey]*entity.RollupValue),
+	}
+}
+
+// AddVisit adds count visits like visit. Repeat clicks are skipped.
+func (b *RollupBuilder) AddVisit(visit *entity.Visit, count int64) {
+	if visit.Repeat {
+		return
+	}
+	rollup, key := b.rollup(visit.LinkID, visit.VisitedAt)
+	if visit.Bot {
+		rollup.BotHits += count
+		return
+	}
+	rollup.Clicks += count
+	for dimension := range VisitFields {
+		b.addValue(rollup, key, dimension, VisitDimension(visit, dimension), count)
+	}
+}
+
+// AddRollup adds the sums of a finer rollup.
+func (b *RollupBuilder) AddRollup(fine *entity.VisitRollup) {
+	rollup, key := b.rollup(fine.LinkID, fine.Start)
+	rollup.Clicks += fine.Clicks
+	rollup.BotHits += fine.BotHits
+	for _, value := range fine.Values {
+		b.addValue(rollup, key, value.Dimension, value.Value, value.Clicks)
+	}
+}
+
+// Rollups returns the sums, ordered by start and link ID.
+func (b *RollupBuilder) Rollups() []*entity.VisitRollup {
+	rollups := make([]*entity.VisitRollup, 0, len(b.rollups))
+	for _, rollup := range b.rollups {
+		sort.Slice(rollup.Values, func(i, j int) bool {
+			if rollup.Values[i].Dimension != rollup.Values[j].Dimension {
+				return rollup.Values[i].Dimension < rollup.Values[j].Dimension
+			}
+			return rollup.Values[i].Value < rollup.Values[j].Value
+		})
+		rollups = append(rollups, rollup)
+	}
+	sort.Slice(rollups, func(i, j int) bool {
+		if !rollups[i].Start.Equal(rollups[j].Start) {
+			return rollups[i].Start.Before(rollups[j].Start)
+		}
+		return rollups[i].LinkID < rollups[j].LinkID
+	})
+	return rollups
+}
+
+func (b *RollupBuilder) rollup(linkID string, t time.Time) (*entity.VisitRollup, rollupKey) {
+	start := RollupStart(t, b.granularity)
+	key := rollupKey{linkID: linkID, start: start.Unix()}
+	rollup := b.rollups[key]
+	if rollup == nil {
+		rollup = &entity.VisitRollup{LinkID: linkID, Granularity: b.granularity, Start: start, Values: []*entity.RollupValue{}}
+		b.rollups[key] = rollup
+	}
+	return rollup, key
+}
+
+func (b *RollupBuilder) addValue(rollup *entity.VisitRollup, key rollupKey, dimension entity.BreakdownDimension, value string, clicks int64) {
+	vk := rollupValueKey{rollupKey: key, dimension: dimension, value: value}
+	v := b.values[vk]
+	if v == nil {
+		v = &entity.RollupValue{Dimension: dimension, Value: value}
+		b.values[vk] = v
+		rollup.Values = append(rollup.Values, v)
+	}
+	v.Clicks += clicks
+}
+
+type mongoVisitRollupRepository struct {
+	collection *mongo.Collection
+	state      *mongo.Collection
+}
+
+func NewMongoVisitRollupRepository(db *mongo.Database) VisitRollupRepository {
+	return &mongoVisitRollupRepository{
+		collection: db.Collection("visit_rollups"),
+		state:      db.Collection("rollup_state"),
+	}
+}
+
+func (r *mongoVisitRollupRepository) Save(ctx context.Context, rollups []*entity.VisitRollup) error {
+	if len(rollups) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, len(rollups))
+	for i, rollup := range rollups {
+		models[i] = mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"linkId": rollup.LinkID, "granularity": rollup.Granularity, "start": rollup.Start}).
+			SetReplacement(rollup).
+			SetUpsert(true)
+	}
+	_, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+func (r *mongoVisitRollupRepository) CountByInterval(ctx context.Context, q RollupQuery, interval entity.StatsInterval, loc *time.Location) ([]*entity.StatsBucket, error) {
+	if len(q.LinkIDs) == 0 {
+		return []*entity.StatsBucket{}, nil
+	}
+	trunc := bson.M{"date": "$start", "unit": string(interval), "timezone": loc.String()}
+	if interval == entity.IntervalWeek {
+		trunc["startOfWeek"] = "monday"
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: rollupMatch(q)}},
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"$dateTrunc": trunc}, "clicks": bson.M{"$sum": "$clicks"}}}},
+		{{Key: "$match", Value: bson.M{"clicks": bson.M{"$gt": 0}}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+	var rows []struct {
+		Start  time.Time `bson:"_id"`
+		Clicks int64     `bson:"clicks"`
+	}
+	if err := r.aggregate(ctx, pipeline, &rows); err != nil {
+		return nil, err
+	}
+	buckets := make([]*entity.StatsBucket, len(rows))
+	for i, row := range rows {
+		buckets[i] = &entity.StatsBucket{Start: row.Start.In(loc), Clicks: row.Clicks}
+	}
+	return buckets, nil
+}
+
+func (r *mongoVisitRollupRepository) CountByLink(ctx context.Context, q RollupQuery) (map[string]int64, error) {
+	if len(q.LinkIDs) == 0 {
+		return map[string]int64{}, nil
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: rollupMatch(q)}},
+		{{Key: "$group", Value: bson.M{"_id": "$linkId", "clicks": bson.M{"$sum": "$clicks"}}}},
+	}
+	var rows []struct {
+		LinkID string `bson:"_id"`
+		Clicks int64  `bson:"clicks"`
+	}
+	if err := r.aggregate(ctx, pipeline, &rows); err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		if row.Clicks > 0 {
+			counts[row.LinkID] = row.Clicks
+		}
+	}
+	return counts, nil
+}
+
+func (r *mongoVisitRollupRepository) Count(ctx context.Context, q RollupQuery) (int64, int64, error) {
+	if len(q.LinkIDs) == 0 {
+		return 0, 0, nil
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: rollupMatch(q)}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "clicks": bson.M{"$sum": "$clicks"}, "botHits": bson.M{"$sum": "$botHits"}}}},
+	}
+	var rows []struct {
+		Clicks  int64 `bson:"clicks"`
+		BotHits int64 `bson:"botHits"`
+	}
+	if err := r.aggregate(ctx, pipeline, &rows); err != nil || len(rows) == 0 {
+		return 0, 0, err
+	}
+	return rows[0].Clicks, rows[0].BotHits, nil
+}
+
+func (r *mongoVisitRollupRepository) TopValues(ctx context.Context, q RollupQuery, dimension entity.BreakdownDimension, limit int) ([]*entity.BreakdownItem, error) {
+	if len(q.LinkIDs) == 0 {
+		return []*entity.BreakdownItem{}, nil
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: rollupMatch(q)}},
+		{{Key: "$unwind", Value: "$values"}},
+		{{Key: "$match", Value: bson.M{"values.dimension": dimension}}},
+		{{Key: "$group", Value: bson.M{"_id": "$values.value", "clicks": bson.M{"$sum": "$values.clicks"}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "clicks", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	var rows []struct {
+		Value  string `bson:"_id"`
+		Clicks int64  `bson:"clicks"`
+	}
+	if err := r.aggregate(ctx, pipeline, &rows); err != nil {
+		return nil, err
+	}
+	items := make([]*entity.BreakdownItem, len(rows))
+	for i, row := range rows {
+		items[i] = &entity.BreakdownItem{Value: row.Value, Clicks: row.Clicks}
+	}
+	return items, nil
+}
+
+func (r *mongoVisitRollupRepository) DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error) {
+	if len(linkIDs) == 0 {
+		return 0, nil
+	}
+	res, err := r.collection.DeleteMany(ctx, bson.M{"linkId": bson.M{"$in": linkIDs}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// rollupWatermarkID is the rollup_state document holding the watermark.
+const rollupWatermarkID = "visits"
+
+func (r *mongoVisitRollupRepository) Watermark(ctx context.Context) (time.Time, error) {
+	var doc struct {
+		RolledUpTo time.Time `bson:"rolledUpTo"`
+	}
+	err := r.state.FindOne(ctx, bson.M{"_id": rollupWatermarkID}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, nil
+	}
+	return doc.RolledUpTo.UTC(), err
+}
+
+func (r *mongoVisitRollupRepository) SetWatermark(ctx context.Context, t time.Time) error {
+	_, err := r.state.UpdateOne(ctx, bson.M{"_id": rollupWatermarkID},
+		bson.M{"$set": bson.M{"rolledUpTo": t}}, options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *mongoVisitRollupRepository) aggregate(ctx context.Context, pipeline mongo.Pipeline, results interface{}) error {
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	return cursor.All(ctx, results)
+}
+
+func rollupMatch(q RollupQuery) bson.M {
+	return bson.M{
+		"linkId":      bson.M{"$in": q.LinkIDs},
+		"granularity": q.Granularity,
+		"start":       bson.M{"$gte": q.From, "$lt": q.To},
+	}
+}
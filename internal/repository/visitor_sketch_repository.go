@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// VisitorSketchRepository stores daily HyperLogLog sketches of link visitors.
+type VisitorSketchRepository interface {
+	// Raise sets a register of the link's sketch for day to rank unless it
+	// already holds a higher value, creating the sketch if needed.
+	Raise(ctx context.Context, linkID string, day time.Time, index uint16, rank uint8) error
+	// List returns the sketches of the given links for days in [from, to).
+	List(ctx context.Context, linkIDs []string, from, to time.Time) ([]*entity.VisitorSketch, error)
+	DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error)
+}
+
+type mongoVisitorSketchRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoVisitorSketchRepository(db *mongo.Database) VisitorSketchRepository {
+	return &mongoVisitorSketchRepository{
+		collection: db.Collection("visitor_sketches"),
+	}
+}
+
+// sketchDocument stores registers as a sub-document keyed by register
+// index, so a single $max can raise one register atomically.
+type sketchDocument struct {
+	LinkID    string           `bson:"linkId"`
+	Day       time.Time        `bson:"day"`
+	Registers map[string]int32 `bson:"registers"`
+}
+
+func (r *mongoVisitorSketchRepository) Raise(ctx context.Context, linkID string, day time.Time, index uint16, rank uint8) error {
+	filter := bson.M{"linkId": linkID, "day": day}
+	update := bson.M{"$max": bson.M{"registers." + strconv.Itoa(int(index)): int32(rank)}}
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// Two visits raced to create the sketch; the document exists now.
+		_, err = r.collection.UpdateOne(ctx, filter, update)
+	}
+	return err
+}
+
+func (r *mongoVisitorSketchRepository) List(ctx context.Context, linkIDs []string, from, to time.Time) ([]*entity.VisitorSketch, error) {
+	if len(linkIDs) == 0 {
+		return []*entity.VisitorSketch{}, nil
+	}
+	filter := bson.M{"linkId": bson.M{"$in": linkIDs}, "day": bson.M{"$gte": from, "$lt": to}}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var docs []sketchDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	sketches := make([]*entity.VisitorSketch, 0, len(docs))
+	for _, doc := range docs {
+		sketch := &entity.VisitorSketch{LinkID: doc.LinkID, Day: doc.Day.UTC(), Registers: make(map[uint16]uint8, len(doc.Registers))}
+		for key, rank := range doc.Registers {
+			index, err := strconv.ParseUint(key, 10, 16)
+			if err != nil {
+				continue
+			}
+			sketch.Registers[uint16(index)] = uint8(rank)
+		}
+		sketches = append(sketches, sketch)
+	}
+	return sketches, nil
+}
+
+func (r *mongoVisitorSketchRepository) DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error) {
+	if len(linkIDs) == 0 {
+		return 0, nil
+	}
+	res, err := r.collection.DeleteMany(ctx, bson.M{"linkId": bson.M{"$in": linkIDs}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WebhookDeliveryRepository is the queue and log of webhook deliveries.
+type WebhookDeliveryRepository interface {
+	Create(ctx context.Context, delivery *entity.WebhookDelivery) (*entity.WebhookDelivery, error)
+	GetByID(ctx context.Context, id string) (*entity.WebhookDelivery, error)
+	// ListByWebhook returns up to limit of the webhook's deliveries, newest
+	// first.
+	ListByWebhook(ctx context.Context, webhookID string, limit int) ([]*entity.WebhookDelivery, error)
+	// ClaimDue returns the pending delivery whose next attempt is the most
+	// overdue, postponing that attempt by lease so no other worker picks it
+	// up meanwhile. It returns ErrNotFound when nothing is due.
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*entity.WebhookDelivery, error)
+	// Update saves the outcome of an attempt.
+	Update(ctx context.Context, delivery *entity.WebhookDelivery) error
+	DeleteByWebhookID(ctx context.Context, webhookID string) (int64, error)
+}
+
+type mongoWebhookDeliveryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoWebhookDeliveryRepository(db *mongo.Database) WebhookDeliveryRepository {
+	return &mongoWebhookDeliveryRepository{
+		collection: db.Collection("webhook_deliveries"),
+	}
+}
+
+func (r *mongoWebhookDeliveryRepository) Create(ctx context.Context, delivery *entity.WebhookDelivery) (*entity.WebhookDelivery, error) {
+	res, err := r.collection.InsertOne(ctx, delivery)
+	if err != nil {
+		return nil, err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		delivery.ID = oid.Hex()
+	}
+	return delivery, nil
+}
+
+func (r *mongoWebhookDeliveryRepository) GetByID(ctx context.Context, id string) (*entity.WebhookDelivery, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	return r.findOne(r.collection.FindOne(ctx, bson.M{"_id": oid}))
+}
+
+func (r *mongoWebhookDeliveryRepository) ListByWebhook(ctx context.Context, webhookID string, limit int) ([]*entity.WebhookDelivery, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, bson.M{"webhookId": webhookID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	deliveries := make([]*entity.WebhookDelivery, 0)
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (r *mongoWebhookDeliveryRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*entity.WebhookDelivery, error) {
+	filter := bson.M{"status": entity.DeliveryPending, "nextAttemptAt": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"nextAttemptAt": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}).
+		SetReturnDocument(options.After)
+	return r.findOne(r.collection.FindOneAndUpdate(ctx, filter, update, opts))
+}
+
+func (r *mongoWebhookDeliveryRepository) Update(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	oid, err := primitive.ObjectIDFromHex(delivery.ID)
+	if err != nil {
+		return ErrNotFound
+	}
+	set := bson.M{
+		"status":         delivery.Status,
+		"attempts":       delivery.Attempts,
+		"nextAttemptAt":  delivery.NextAttemptAt,
+		"lastAttemptAt":  delivery.LastAttemptAt,
+		"responseStatus": delivery.ResponseStatus,
+		"lastError":      delivery.LastError,
+	}
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoWebhookDeliveryRepository) DeleteByWebhookID(ctx context.Context, webhookID string) (int64, error) {
+	res, err := r.collection.DeleteMany(ctx, bson.M{"webhookId": webhookID})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+func (r *mongoWebhookDeliveryRepository) findOne(res *mongo.SingleResult) (*entity.WebhookDelivery, error) {
+	var delivery entity.WebhookDelivery
+	err := res.Decode(&delivery)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *entity.Webhook) (*entity.Webhook, error)
+	GetByID(ctx context.Context, id string) (*entity.Webhook, error)
+	// ListByOwner returns the owner's webhooks, newest first.
+	ListByOwner(ctx context.Context, ownerID string) ([]*entity.Webhook, error)
+	// ListSubscribed returns the owner's webhooks subscribed to event.
+	ListSubscribed(ctx context.Context, ownerID string, event entity.WebhookEvent) ([]*entity.Webhook, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type mongoWebhookRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMongoWebhookRepository(db *mongo.Database) WebhookRepository {
+	return &mongoWebhookRepository{
+		collection: db.Collection("webhooks"),
+	}
+}
+
+func (r *mongoWebhookRepository) Create(ctx context.Context, webhook *entity.Webhook) (*entity.Webhook, error) {
+	res, err := r.collection.InsertOne(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+	if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		webhook.ID = oid.Hex()
+	}
+	return webhook, nil
+}
+
+func (r *mongoWebhookRepository) GetByID(ctx context.Context, id string) (*entity.Webhook, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	var webhook entity.Webhook
+	err = r.collection.FindOne(ctx, bson.M{"_id": oid}).Decode(&webhook)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *mongoWebhookRepository) ListByOwner(ctx context.Context, ownerID string) ([]*entity.Webhook, error) {
+	return r.find(ctx, bson.M{"ownerId": ownerID})
+}
+
+func (r *mongoWebhookRepository) ListSubscribed(ctx context.Context, ownerID string, event entity.WebhookEvent) ([]*entity.Webhook, error) {
+	return r.find(ctx, bson.M{"ownerId": ownerID, "events": event})
+}
+
+func (r *mongoWebhookRepository) find(ctx context.Context, filter bson.M) ([]*entity.Webhook, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	webhooks := make([]*entity.Webhook, 0)
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *mongoWebhookRepository) Delete(ctx context.Context, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return ErrNotFound
+	}
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+)
+
+const (
+	apiKeyPrefix    = "lib_"
+	apiKeyBytes     = 32
+	apiKeyShownLen  = len(apiKeyPrefix) + 8
+	lastUsedGranule = time.Minute
+)
+
+var (
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	ErrAPIKeyName    = errors.New("API key name is required")
+)
+
+type APIKeyUsecase interface {
+	CreateKey(ctx context.Context, userID, name string) (*entity.APIKey, error)
+	ListKeys(ctx context.Context) ([]*entity.APIKey, error)
+	RevokeKey(ctx context.Context, id string) error
+	Authenticate(ctx context.Context, rawKey string) (*entity.APIKey, error)
+}
+
+type apiKeyUsecase struct {
+	repo     repository.APIKeyRepository
+	userRepo repository.UserRepository
+}
+
+func NewAPIKeyUsecase(repo repository.APIKeyRepository, userRepo repository.UserRepository) APIKeyUsecase {
+	return &apiKeyUsecase{repo: repo, userRepo: userRepo}
+}
+
+// CreateKey mints a new random key for userID. The plaintext is only available
+// on the returned entity's Key field; callers must hand it to the client right away.
+func (u *apiKeyUsecase) CreateKey(ctx context.Context, userID, name string) (*entity.APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrAPIKeyName
+	}
+	if _, err := u.userRepo.GetByID(ctx, userID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	secret := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	raw := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	key := &entity.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    raw[:apiKeyShownLen],
+		KeyHash:   hashAPIKey(raw),
+		CreatedAt: time.Now(),
+	}
+	created, err := u.repo.Create(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	created.Key = raw
+	return created, nil
+}
+
+func (u *apiKeyUsecase) ListKeys(ctx context.Context) ([]*entity.APIKey, error) {
+	return u.repo.List(ctx)
+}
+
+func (u *apiKeyUsecase) RevokeKey(ctx context.Context, id string) error {
+	return u.repo.Revoke(ctx, id)
+}
+
+// Authenticate resolves a plaintext key to its stored record, rejecting
+// unknown, revoked and ownerless keys alike so callers cannot tell them apart.
+func (u *apiKeyUsecase) Authenticate(ctx context.Context, rawKey string) (*entity.APIKey, error) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	key, err := u.repo.GetByHash(ctx, hashAPIKey(rawKey))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if key.Revoked || key.UserID == "" {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// Only record usage once per granule to avoid a write on every request.
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedGranule {
+		if err := u.repo.TouchLastUsed(ctx, key.ID, now); err != nil {
+			return nil, err
+		}
+		key.LastUsedAt = &now
+	}
+	return key, nil
+}
+
+// hashAPIKey returns the hex SHA-256 of a key. Keys carry 256 bits of
+// randomness, so a fast unsalted hash is sufficient for lookups.
+func hashAPIKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
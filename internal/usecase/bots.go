@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"net/http"
+	"strings"
+)
+
+// knownBots maps lower-cased User-Agent markers to the name recorded for the
+// bot. Link unfurlers come first because they are by far the most common
+// source of fake clicks on shared links.
+var knownBots = []struct {
+	marker, name string
+}{
+	// Link preview unfurlers.
+	{"slackbot", "Slack"},
+	{"slack-imgproxy", "Slack"},
+	{"twitterbot", "Twitter"},
+	{"facebookexternalhit", "Facebook"},
+	{"facebot", "Facebook"},
+	{"facebookcatalog", "Facebook"},
+	{"discordbot", "Discord"},
+	{"telegrambot", "Telegram"},
+	{"whatsapp", "WhatsApp"},
+	{"linkedinbot", "LinkedIn"},
+	{"skypeuripreview", "Skype"},
+	{"microsoftpreview", "Microsoft Teams"},
+	{"redditbot", "Reddit"},
+	{"pinterestbot", "Pinterest"},
+	{"embedly", "Embedly"},
+	{"iframely", "Iframely"},
+	{"vkshare", "VK"},
+	{"snapchat-link-preview", "Snapchat"},
+	{"mastodon", "Mastodon"},
+	{"bluesky", "Bluesky"},
+	{"google-pagerenderer", "Google"},
+	// Search and AI crawlers.
+	{"googlebot", "Googlebot"},
+	{"adsbot-google", "Googlebot"},
+	{"bingbot", "Bingbot"},
+	{"bingpreview", "Bingbot"},
+	{"applebot", "Applebot"},
+	{"duckduckbot", "DuckDuckBot"},
+	{"yandex", "Yandex"},
+	{"baiduspider", "Baidu"},
+	{"petalbot", "PetalBot"},
+	{"ahrefsbot", "Ahrefs"},
+	{"semrushbot", "Semrush"},
+	{"mj12bot", "Majestic"},
+	{"dotbot", "Moz"},
+	{"gptbot", "OpenAI"},
+	{"chatgpt-user", "OpenAI"},
+	{"claudebot", "Anthropic"},
+	{"perplexitybot", "Perplexity"},
+	{"bytespider", "ByteDance"},
+	{"ccbot", "Common Crawl"},
+	// Uptime monitors and health checks.
+	{"uptimerobot", "UptimeRobot"},
+	{"pingdom", "Pingdom"},
+	{"statuscake", "StatusCake"},
+	{"betteruptime", "Better Uptime"},
+	{"datadog", "Datadog"},
+	{"newrelicpinger", "New Relic"},
+	{"kube-probe", "Kubernetes probe"},
+	{"elb-healthchecker", "AWS ELB"},
+	{"googlehc", "Google health check"},
+	// Scripts and headless browsers.
+	{"headlesschrome", "Headless Chrome"},
+	{"phantomjs", "PhantomJS"},
+	{"puppeteer", "Puppeteer"},
+	{"playwright", "Playwright"},
+	{"curl/", "curl"},
+	{"wget/", "Wget"},
+	{"python-requests", "Python"},
+	{"python-urllib", "Python"},
+	{"aiohttp", "Python"},
+	{"go-http-client", "Go"},
+	{"java/", "Java"},
+	{"apache-httpclient", "Java"},
+	{"node-fetch", "Node.js"},
+	{"axios/", "Node.js"},
+	{"libwww-perl", "Perl"},
+	{"postmanruntime", "Postman"},
+	{"insomnia", "Insomnia"},
+}
+
+// genericBotMarkers catch self-declared crawlers not listed above.
+var genericBotMarkers = []string{"bot", "crawler", "spider", "crawling", "scraper", "preview", "fetcher", "monitor"}
+
+// classifyBot decides whether a visit was made by software rather than a
+// person following the link, and if so names the bot or the giveaway.
+func classifyBot(req VisitRequest) (bool, string) {
+	ua := strings.ToLower(strings.TrimSpace(req.UserAgent))
+	if ua == "" {
+		return true, "no user agent"
+	}
+	for _, bot := range knownBots {
+		if strings.Contains(ua, bot.marker) {
+			return true, bot.name
+		}
+	}
+	// Cubot is an Android phone brand, not a bot.
+	generic := strings.ReplaceAll(ua, "cubot", "")
+	for _, marker := range genericBotMarkers {
+		if strings.Contains(generic, marker) {
+			return true, "self-declared bot"
+		}
+	}
+
+	// Behavioural giveaways of clients that only look like browsers.
+	if req.Method == http.MethodHead {
+		return true, "HEAD request"
+	}
+	if isPrefetch(req.Purpose) {
+		return true, "prefetch"
+	}
+	if req.Accept == "" && req.AcceptLanguage == "" {
+		// Every browser sends both; scripts that fake the User-Agent rarely do.
+		return true, "missing browser headers"
+	}
+	return false, ""
+}
+
+// isPrefetch reports whether a Purpose or Sec-Purpose header marks the
+// request as speculative rather than a click.
+func isPrefetch(purpose string) bool {
+	purpose = strings.ToLower(purpose)
+	return strings.Contains(purpose, "prefetch") || strings.Contains(purpose, "prerender") || strings.Contains(purpose, "preview")
+}
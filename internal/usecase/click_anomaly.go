@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+)
+
+const (
+	// anomalyWindow is the width of the windows checked for click spikes.
+	anomalyWindow = 10 * time.Minute
+	// anomalyBaselineSpan is how far back a link's usual click rate is taken
+	// from.
+	anomalyBaselineSpan = 24 * time.Hour
+	// A window is flagged when it has at least anomalyMinClicks clicks,
+	// anomalySpikeFactor times the baseline, and the clicks come from few
+	// visitors: anomalyClicksPerSource or more per visitor on average, or
+	// anomalyTopSourceShare or more from a single visitor.
+	anomalyMinClicks       = 20
+	anomalySpikeFactor     = 5
+	anomalyClicksPerSource = 4
+	anomalyTopSourceShare  = 0.5
+)
+
+// DetectClickAnomalies checks the last complete window for links whose
+// clicks spiked from only a few visitors and records them. Running it again
+// for the same window records nothing new.
+func (u *statsUsecase) DetectClickAnomalies(ctx context.Context) error {
+	now := time.Now()
+	end := now.Truncate(anomalyWindow)
+	start := end.Add(-anomalyWindow)
+
+	candidates, err := u.visitRepo.SourceCounts(ctx, start, end, anomalyMinClicks)
+	if err != nil {
+		return err
+	}
+	suspicious := make([]*repository.LinkSources, 0, len(candidates))
+	ids := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		if c.Clicks >= anomalyClicksPerSource*c.Sources || float64(c.TopSourceClicks) >= anomalyTopSourceShare*float64(c.Clicks) {
+			suspicious = append(suspicious, c)
+			ids = append(ids, c.LinkID)
+		}
+	}
+	if len(suspicious) == 0 {
+		return nil
+	}
+
+	usual, err := u.visitRepo.CountByLink(ctx, repository.VisitQuery{LinkIDs: ids, From: start.Add(-anomalyBaselineSpan), To: start})
+	if err != nil {
+		return err
+	}
+	for _, c := range suspicious {
+		baseline := float64(usual[c.LinkID]) / float64(anomalyBaselineSpan/anomalyWindow)
+		if float64(c.Clicks) < anomalySpikeFactor*math.Max(baseline, 1) {
+			continue
+		}
+		_, err := u.anomalyRepo.Create(ctx, &entity.ClickAnomaly{
+			LinkID:          c.LinkID,
+			WindowStart:     start,
+			WindowEnd:       end,
+			Clicks:          c.Clicks,
+			Sources:         c.Sources,
+			TopSourceClicks: c.TopSourceClicks,
+			Baseline:        math.Round(baseline*100) / 100,
+			DetectedAt:      now,
+		})
+		if err != nil && !errors.Is(err, repository.ErrDuplicate) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+)
+
+// resolveExpiry turns the expiry fields of a create or update request into
+// the ExpiresAt to store. current is the stored expiry for updates; when the
+// request does not mention expiry at all, it is kept unchanged.
+func (u *linkUsecase) resolveExpiry(link *entity.Link, current *time.Time, isUpdate bool, now time.Time) (*time.Time, error) {
+	given := 0
+	for _, set := range []bool{link.ExpiresAt != nil, link.ExpiresIn != "", link.NeverExpires} {
+		if set {
+			given++
+		}
+	}
+	if given > 1 {
+		return nil, fmt.Errorf("%w: use only one of expiresAt, expiresIn or neverExpires", ErrInvalidLink)
+	}
+
+	var expiresAt time.Time
+	switch {
+	case link.NeverExpires:
+		if u.maxTTL > 0 {
+			return nil, fmt.Errorf("%w: links must expire within %s", ErrInvalidLink, u.maxTTL)
+		}
+		return nil, nil
+	case link.ExpiresIn != "":
+		ttl, err := parseLifetime(link.ExpiresIn)
+		if err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("%w: expiresIn must be a positive duration such as \"72h\" or \"30d\"", ErrInvalidLink)
+		}
+		expiresAt = now.Add(ttl)
+	case link.ExpiresAt != nil:
+		expiresAt = *link.ExpiresAt
+		if !expiresAt.After(now) {
+			return nil, fmt.Errorf("%w: expiresAt must be in the future", ErrInvalidLink)
+		}
+	case isUpdate:
+		return current, nil
+	case u.defaultTTL > 0:
+		expiresAt = now.Add(u.defaultTTL)
+	case u.maxTTL > 0:
+		expiresAt = now.Add(u.maxTTL)
+	default:
+		return nil, nil
+	}
+
+	if u.maxTTL > 0 && expiresAt.Sub(now) > u.maxTTL {
+		return nil, fmt.Errorf("%w: links must expire within %s", ErrInvalidLink, u.maxTTL)
+	}
+	return &expiresAt, nil
+}
+
+// maxLifetimeDays is the most days a time.Duration can hold.
+const maxLifetimeDays = math.MaxInt64 / uint64(24*time.Hour)
+
+// parseLifetime parses a Go duration, additionally accepting a whole number
+// of days such as "30d".
+func parseLifetime(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		// ParseUint rejects signs, so "+5d" and "-5d" are errors too.
+		n, err := strconv.ParseUint(days, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		if n > maxLifetimeDays {
+			return 0, fmt.Errorf("%s is out of range", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// validateWindow checks that a link's activation time precedes its expiry.
+func validateWindow(link *entity.Link) error {
+	if link.ActiveFrom != nil && link.ExpiresAt != nil && !link.ActiveFrom.Before(*link.ExpiresAt) {
+		return fmt.Errorf("%w: activeFrom must be before the expiry time", ErrInvalidLink)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"context"
+	"log"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+)
+
+// LinkEventEmitter is told about changes to links and counted clicks, e.g.
+// to notify the owner's webhooks.
+type LinkEventEmitter interface {
+	Emit(ctx context.Context, ownerID string, event entity.WebhookEvent, data entity.WebhookData) error
+}
+
+// WithLinkEvents reports link events to emitter.
+func WithLinkEvents(emitter LinkEventEmitter) LinkOption {
+	return func(u *linkUsecase) {
+		u.emitter = emitter
+	}
+}
+
+// emit reports an event about link. By then the change has been made, so a
+// failure to report it is logged rather than returned.
+func (u *linkUsecase) emit(ctx context.Context, event entity.WebhookEvent, link *entity.Link, visit *entity.Visit) {
+	if u.emitter == nil {
+		return
+	}
+	data := entity.WebhookData{Link: link}
+	if visit != nil {
+		data.Visit = entity.NewWebhookVisit(visit)
+	}
+	if err := u.emitter.Emit(ctx, link.OwnerID, event, data); err != nil {
+		log.Printf("Could not queue %s event for link %s: %v", event, link.ID, err)
+	}
+}
@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// ErrInvalidQuery is wrapped by validation errors on list parameters.
+var ErrInvalidQuery = errors.New("invalid query")
+
+// LinkQuery holds the parameters of a link listing.
+type LinkQuery struct {
+	Statuses    []entity.LinkStatus
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Tag         string
+	Host        string
+	Title       string
+	// Sort is one of createdAt, clicks or expiresAt, optionally prefixed with
+	// "-" for descending order. It defaults to newest first.
+	Sort string
+	// Cursor is the NextCursor of the previous page.
+	Cursor string
+	Limit  int
+}
+
+// pageCursor is the opaque cursor handed to clients. It records the ordering
+// it was issued for so that it cannot be replayed against a different one.
+type pageCursor struct {
+	Sort       repository.LinkSort    `json:"s"`
+	Descending bool                   `json:"d,omitempty"`
+	After      *repository.LinkCursor `json:"a"`
+}
+
+func (u *linkUsecase) ListLinks(ctx context.Context, ownerID string, q LinkQuery) (*entity.LinkPage, error) {
+	filter, err := linkFilter(ownerID, q)
+	if err != nil {
+		return nil, err
+	}
+	// Ask for one extra link to learn whether another page follows.
+	filter.Limit++
+	links, err := u.repo.List(ctx, filter)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	page := &entity.LinkPage{Links: links}
+	if len(links) == filter.Limit {
+		page.Links = links[:len(links)-1]
+		page.NextCursor = encodeCursor(pageCursor{
+			Sort:       filter.SortBy,
+			Descending: filter.Descending,
+			After:      repository.CursorFor(page.Links[len(page.Links)-1]),
+		})
+	}
+	withStatuses(page.Links)
+	return page, nil
+}
+
+// linkFilter validates q and turns it into a repository filter.
+func linkFilter(ownerID string, q LinkQuery) (repository.LinkFilter, error) {
+	filter := repository.LinkFilter{
+		OwnerID:       ownerID,
+		Now:           time.Now(),
+		CreatedFrom:   q.CreatedFrom,
+		CreatedTo:     q.CreatedTo,
+		Tag:           strings.ToLower(strings.TrimSpace(q.Tag)),
+		Host:          strings.ToLower(strings.TrimSpace(q.Host)),
+		TitleContains: strings.TrimSpace(q.Title),
+		SortBy:        repository.LinkSortCreatedAt,
+		Descending:    true,
+		Limit:         q.Limit,
+	}
+
+	for _, status := range q.Statuses {
+		switch status {
+		case entity.LinkScheduled, entity.LinkActive, entity.LinkExpired, entity.LinkArchived, entity.LinkDeleted:
+			filter.Statuses = append(filter.Statuses, status)
+		default:
+			return filter, fmt.Errorf("%w: unknown status %q", ErrInvalidQuery, status)
+		}
+	}
+	if filter.Host != "" && strings.ContainsAny(filter.Host, "/?#@ ") {
+		return filter, fmt.Errorf("%w: host must be a bare host name such as example.com", ErrInvalidQuery)
+	}
+
+	if q.Sort != "" {
+		key := strings.TrimPrefix(q.Sort, "-")
+		switch repository.LinkSort(key) {
+		case repository.LinkSortCreatedAt, repository.LinkSortClicks, repository.LinkSortExpiresAt:
+			filter.SortBy = repository.LinkSort(key)
+			filter.Descending = strings.HasPrefix(q.Sort, "-")
+		default:
+			return filter, fmt.Errorf("%w: sort must be createdAt, clicks or expiresAt, optionally prefixed with '-'", ErrInvalidQuery)
+		}
+	}
+
+	switch {
+	case filter.Limit == 0:
+		filter.Limit = defaultPageSize
+	case filter.Limit < 0 || filter.Limit > maxPageSize:
+		return filter, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, maxPageSize)
+	}
+
+	if q.Cursor != "" {
+		cursor, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return filter, err
+		}
+		if cursor.Sort != filter.SortBy || cursor.Descending != filter.Descending {
+			return filter, fmt.Errorf("%w: cursor was issued for a different sort order", ErrInvalidQuery)
+		}
+		filter.After = cursor.After
+	}
+	return filter, nil
+}
+
+func encodeCursor(c pageCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (pageCursor, error) {
+	var c pageCursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err == nil {
+		err = json.Unmarshal(raw, &c)
+	}
+	if err != nil || c.After == nil || c.After.ID == "" {
+		return c, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+	return c, nil
+}
@@ -2,66 +2,445 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/hussainr95/link-in-bio-service/internal/entity"
 	"github.com/hussainr95/link-in-bio-service/internal/repository"
 )
 
+var (
+	// ErrLinkNotFound is returned when a link does not exist or belongs to
+	// another owner; the two cases are deliberately indistinguishable.
+	ErrLinkNotFound = errors.New("link not found")
+	// ErrLinkExpired is returned when visiting a link past its expiry time.
+	ErrLinkExpired = errors.New("link has expired")
+	// ErrInvalidLink is wrapped by validation errors on link input.
+	ErrInvalidLink = errors.New("invalid link")
+	// ErrSlugTaken is returned when a requested slug is already in use.
+	ErrSlugTaken = errors.New("slug is already taken")
+)
+
+// LinkUsecase manages links. Methods taking a ref accept either the link ID
+// or its slug.
 type LinkUsecase interface {
 	CreateLink(ctx context.Context, link *entity.Link) (*entity.Link, error)
-	GetLink(ctx context.Context, id string) (*entity.Link, error)
-	UpdateLink(ctx context.Context, link *entity.Link) (*entity.Link, error)
-	DeleteLink(ctx context.Context, id string) error
-	VisitLink(ctx context.Context, id string) (*entity.Link, error)
+	GetLink(ctx context.Context, ownerID, ref string) (*entity.Link, error)
+	UpdateLink(ctx context.Context, ownerID string, link *entity.Link) (*entity.Link, error)
+	DeleteLink(ctx context.Context, ownerID, ref string) error
+	VisitLink(ctx context.Context, ref string, req VisitRequest) (*entity.Link, error)
+	ListLinks(ctx context.Context, ownerID string, query LinkQuery) (*entity.LinkPage, error)
+	ListArchivedLinks(ctx context.Context, ownerID string) ([]*entity.Link, error)
+	ListDeletedLinks(ctx context.Context, ownerID string) ([]*entity.Link, error)
+	RestoreLink(ctx context.Context, ownerID, ref string) (*entity.Link, error)
 	CleanupExpiredLinks(ctx context.Context) error
+	PurgeDeletedLinks(ctx context.Context) error
 }
 
 type linkUsecase struct {
-	repo      repository.LinkRepository
-	visitRepo repository.VisitRepository
+	repo       repository.LinkRepository
+	visitRepo  repository.VisitRepository
+	defaultTTL time.Duration
+	maxTTL     time.Duration
+	purgeAfter time.Duration
+	trashTTL   time.Duration
+
+	geo         CountryResolver
+	visitorSalt []byte
+	sketches    repository.VisitorSketchRepository
+
+	clickWindows repository.ClickWindowRepository
+	dedupWindow  time.Duration
+	anomalies    repository.ClickAnomalyRepository
+	rollups      repository.VisitRollupRepository
+	events       VisitPublisher
+	emitter      LinkEventEmitter
+}
+
+// LinkOption configures optional behaviour of the link usecase.
+type LinkOption func(*linkUsecase)
+
+// WithLinkLifetime sets the lifetime of links created without an explicit
+// expiry and the longest lifetime a client may ask for. A zero defaultTTL
+// means such links never expire; a zero maxTTL means there is no limit.
+func WithLinkLifetime(defaultTTL, maxTTL time.Duration) LinkOption {
+	return func(u *linkUsecase) {
+		u.defaultTTL = defaultTTL
+		u.maxTTL = maxTTL
+	}
+}
+
+// WithArchivePurge permanently deletes archived links, together with their
+// visits, once they have been archived for longer than purgeAfter. Zero keeps
+// archived links forever.
+func WithArchivePurge(purgeAfter time.Duration) LinkOption {
+	return func(u *linkUsecase) {
+		u.purgeAfter = purgeAfter
+	}
+}
+
+// WithTrashRetention permanently deletes links, together with their visits,
+// once they have been in the trash for longer than retention. Zero keeps
+// trashed links until they are restored.
+func WithTrashRetention(retention time.Duration) LinkOption {
+	return func(u *linkUsecase) {
+		u.trashTTL = retention
+	}
 }
 
-func NewLinkUsecase(repo repository.LinkRepository, visitRepo repository.VisitRepository) LinkUsecase {
-	return &linkUsecase{repo: repo, visitRepo: visitRepo}
+func NewLinkUsecase(repo repository.LinkRepository, visitRepo repository.VisitRepository, opts ...LinkOption) LinkUsecase {
+	u := &linkUsecase{repo: repo, visitRepo: visitRepo}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
 }
 
 func (u *linkUsecase) CreateLink(ctx context.Context, link *entity.Link) (*entity.Link, error) {
-	link.CreatedAt = time.Now()
-	link.ExpiresAt = time.Now().Add(2 * time.Minute)
-	link.Clicks = 0 // initialize clicks to zero
-	return u.repo.Create(ctx, link)
+	created, err := u.create(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+	u.emit(ctx, entity.EventLinkCreated, created, nil)
+	return created, nil
+}
+
+func (u *linkUsecase) create(ctx context.Context, link *entity.Link) (*entity.Link, error) {
+	now := time.Now()
+	expiresAt, err := u.resolveExpiry(link, nil, false, now)
+	if err != nil {
+		return nil, err
+	}
+	link.CreatedAt = now
+	link.ExpiresAt = expiresAt
+	link.ExpiresIn, link.NeverExpires = "", false
+	link.Clicks = 0       // initialize clicks to zero
+	link.ArchivedAt = nil // links are only archived once they expire
+	link.DeletedAt = nil  // and only trashed through DeleteLink
+	if err := validateWindow(link); err != nil {
+		return nil, err
+	}
+	if link.Tags, err = normalizeTags(link.Tags); err != nil {
+		return nil, err
+	}
+
+	if link.Slug != "" {
+		if err := validateSlug(link.Slug); err != nil {
+			return nil, err
+		}
+		created, err := u.repo.Create(ctx, link)
+		if errors.Is(err, repository.ErrDuplicate) {
+			return nil, ErrSlugTaken
+		}
+		return withStatus(created, err)
+	}
+
+	// Generated slugs are checked up front, but the unique index is what
+	// ultimately settles a race, so retry on a duplicate as well.
+	for attempt := 0; attempt < maxSlugGenAttempts; attempt++ {
+		slug, err := u.unusedSlug(ctx)
+		if err != nil {
+			return nil, err
+		}
+		link.Slug = slug
+		created, err := u.repo.Create(ctx, link)
+		if !errors.Is(err, repository.ErrDuplicate) {
+			return withStatus(created, err)
+		}
+	}
+	return nil, errors.New("could not generate a unique slug")
+}
+
+func (u *linkUsecase) GetLink(ctx context.Context, ownerID, ref string) (*entity.Link, error) {
+	return withStatus(u.getOwned(ctx, ownerID, ref))
 }
 
-func (u *linkUsecase) GetLink(ctx context.Context, id string) (*entity.Link, error) {
-	return u.repo.GetByID(ctx, id)
+func (u *linkUsecase) UpdateLink(ctx context.Context, ownerID string, link *entity.Link) (*entity.Link, error) {
+	existing, err := u.getOwned(ctx, ownerID, link.ID)
+	if err != nil {
+		return nil, err
+	}
+	if link.Slug == "" {
+		link.Slug = existing.Slug
+	} else if link.Slug != existing.Slug {
+		if err := validateSlug(link.Slug); err != nil {
+			return nil, err
+		}
+	}
+	expiresAt, err := u.resolveExpiry(link, existing.ExpiresAt, true, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	link.ID = existing.ID
+	link.OwnerID = ownerID
+	link.CreatedAt = existing.CreatedAt
+	link.Clicks = existing.Clicks // clicks only ever change through VisitLink
+	link.DeletedAt = existing.DeletedAt
+	link.ExpiresAt = expiresAt
+	link.ExpiresIn, link.NeverExpires = "", false
+	if link.ActiveFrom == nil {
+		link.ActiveFrom = existing.ActiveFrom
+	}
+	// Giving an archived link a new lifetime brings it back; otherwise it stays archived.
+	link.ArchivedAt = existing.ArchivedAt
+	if link.ArchivedAt != nil && !link.IsExpired(time.Now()) {
+		link.ArchivedAt = nil
+	}
+	if err := validateWindow(link); err != nil {
+		return nil, err
+	}
+	if link.Tags, err = normalizeTags(link.Tags); err != nil {
+		return nil, err
+	}
+
+	updated, err := withStatus(u.repo.Update(ctx, link))
+	if errors.Is(err, repository.ErrDuplicate) {
+		return nil, ErrSlugTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+	u.emit(ctx, entity.EventLinkUpdated, updated, nil)
+	return updated, nil
 }
 
-func (u *linkUsecase) UpdateLink(ctx context.Context, link *entity.Link) (*entity.Link, error) {
-	return u.repo.Update(ctx, link)
+// DeleteLink moves a link to the trash, from where it can be restored until
+// the trash retention runs out.
+func (u *linkUsecase) DeleteLink(ctx context.Context, ownerID, ref string) error {
+	link, err := u.getOwned(ctx, ownerID, ref)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	link.DeletedAt = &now
+	deleted, err := withStatus(u.repo.Update(ctx, link))
+	if err != nil {
+		return err
+	}
+	u.emit(ctx, entity.EventLinkDeleted, deleted, nil)
+	return nil
 }
 
-func (u *linkUsecase) DeleteLink(ctx context.Context, id string) error {
-	return u.repo.Delete(ctx, id)
+func (u *linkUsecase) RestoreLink(ctx context.Context, ownerID, ref string) (*entity.Link, error) {
+	link, err := u.find(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if link.OwnerID != ownerID || link.DeletedAt == nil {
+		return nil, ErrLinkNotFound
+	}
+	link.DeletedAt = nil
+	restored, err := withStatus(u.repo.Update(ctx, link))
+	if err != nil {
+		return nil, err
+	}
+	u.emit(ctx, entity.EventLinkUpdated, restored, nil)
+	return restored, nil
 }
 
-func (u *linkUsecase) VisitLink(ctx context.Context, id string) (*entity.Link, error) {
-	// Atomically increment clicks using MongoDB's $inc operator.
-	if err := u.repo.IncrementClicks(ctx, id); err != nil {
+func (u *linkUsecase) VisitLink(ctx context.Context, ref string, req VisitRequest) (*entity.Link, error) {
+	link, err := u.find(ctx, ref)
+	if err != nil {
 		return nil, err
 	}
-	// Record the visit for analytics.
-	visit := &entity.Visit{
-		LinkID:    id,
-		VisitedAt: time.Now(),
+	switch link.StatusAt(time.Now()) {
+	case entity.LinkScheduled, entity.LinkDeleted:
+		// Scheduled and trashed links must not reveal that they exist.
+		return nil, ErrLinkNotFound
+	case entity.LinkExpired, entity.LinkArchived:
+		return nil, ErrLinkExpired
 	}
-	if _, err := u.visitRepo.Create(ctx, visit); err != nil {
+	id := link.ID
+	visit := u.newVisit(id, req, time.Now())
+	if err := u.markRepeat(ctx, visit); err != nil {
+		return nil, err
+	}
+
+	// Atomically increment clicks using MongoDB's $inc operator. Bot hits
+	// and repeats are recorded below but never counted as clicks.
+	if !visit.Bot && !visit.Repeat {
+		if err := u.repo.IncrementClicks(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	// Record the visit for analytics. It is stamped as it is stored rather
+	// than when the request arrived, so a slow request cannot fall behind
+	// the rollup watermark.
+	visit.VisitedAt = time.Now()
+	visit, err = u.visitRepo.Create(ctx, visit)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.countVisitor(ctx, visit); err != nil {
 		return nil, err
 	}
 	// Return the updated link.
-	return u.repo.GetByID(ctx, id)
+	link, err = withStatus(u.repo.GetByID(ctx, id))
+	if err != nil {
+		return nil, err
+	}
+	if u.events != nil {
+		u.events.Publish(&entity.VisitEvent{Visit: visit, Clicks: link.Clicks})
+	}
+	if !visit.Bot && !visit.Repeat {
+		u.emit(ctx, entity.EventLinkVisited, link, visit)
+	}
+	return link, nil
+}
+
+func (u *linkUsecase) ListArchivedLinks(ctx context.Context, ownerID string) ([]*entity.Link, error) {
+	links, err := u.repo.ListArchived(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	// Archived links that were later trashed only show up in the trash.
+	visible := make([]*entity.Link, 0, len(links))
+	for _, link := range links {
+		if link.DeletedAt == nil {
+			visible = append(visible, link)
+		}
+	}
+	return withStatuses(visible), nil
+}
+
+func (u *linkUsecase) ListDeletedLinks(ctx context.Context, ownerID string) ([]*entity.Link, error) {
+	links, err := u.repo.ListDeleted(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	return withStatuses(links), nil
 }
 
+// CleanupExpiredLinks archives links that have expired, keeping their click
+// history, and purges archived links past the configured retention.
 func (u *linkUsecase) CleanupExpiredLinks(ctx context.Context) error {
-	return u.repo.DeleteExpired(ctx)
+	now := time.Now()
+	archived, err := u.repo.ArchiveExpired(ctx, now)
+	if err != nil {
+		return err
+	}
+	for _, link := range withStatuses(archived) {
+		u.emit(ctx, entity.EventLinkExpired, link, nil)
+	}
+	if u.purgeAfter <= 0 {
+		return nil
+	}
+	purged, err := u.repo.PurgeArchived(ctx, now.Add(-u.purgeAfter))
+	if err != nil {
+		return err
+	}
+	return u.deleteAnalytics(ctx, purged)
+}
+
+// PurgeDeletedLinks permanently deletes links that have been in the trash
+// for longer than the configured retention.
+func (u *linkUsecase) PurgeDeletedLinks(ctx context.Context) error {
+	if u.trashTTL <= 0 {
+		return nil
+	}
+	purged, err := u.repo.PurgeDeleted(ctx, time.Now().Add(-u.trashTTL))
+	if err != nil {
+		return err
+	}
+	return u.deleteAnalytics(ctx, purged)
+}
+
+// deleteAnalytics removes the visits, visit rollups, visitor sketches and
+// click anomalies of purged links.
+func (u *linkUsecase) deleteAnalytics(ctx context.Context, linkIDs []string) error {
+	if _, err := u.visitRepo.DeleteByLinkIDs(ctx, linkIDs); err != nil {
+		return err
+	}
+	if u.rollups != nil {
+		if _, err := u.rollups.DeleteByLinkIDs(ctx, linkIDs); err != nil {
+			return err
+		}
+	}
+	if u.sketches != nil {
+		if _, err := u.sketches.DeleteByLinkIDs(ctx, linkIDs); err != nil {
+			return err
+		}
+	}
+	if u.anomalies != nil {
+		if _, err := u.anomalies.DeleteByLinkIDs(ctx, linkIDs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withStatus fills in the computed Status of a link returned by the repository.
+func withStatus(link *entity.Link, err error) (*entity.Link, error) {
+	if err != nil {
+		return nil, err
+	}
+	link.Status = link.StatusAt(time.Now())
+	return link, nil
+}
+
+// withStatuses fills in the computed Status of every link in links.
+func withStatuses(links []*entity.Link) []*entity.Link {
+	now := time.Now()
+	for _, link := range links {
+		link.Status = link.StatusAt(now)
+	}
+	return links
+}
+
+func (u *linkUsecase) find(ctx context.Context, ref string) (*entity.Link, error) {
+	return findLink(ctx, u.repo, ref)
+}
+
+func (u *linkUsecase) getOwned(ctx context.Context, ownerID, ref string) (*entity.Link, error) {
+	return findOwnedLink(ctx, u.repo, ownerID, ref)
+}
+
+// findLink resolves ref as a link ID first and falls back to treating it as a slug.
+func findLink(ctx context.Context, repo repository.LinkRepository, ref string) (*entity.Link, error) {
+	link, err := repo.GetByID(ctx, ref)
+	if errors.Is(err, repository.ErrNotFound) {
+		link, err = repo.GetBySlug(ctx, ref)
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrLinkNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// findOwnedLink fetches a link and hides it unless it belongs to ownerID and
+// is not in the trash.
+func findOwnedLink(ctx context.Context, repo repository.LinkRepository, ownerID, ref string) (*entity.Link, error) {
+	link, err := findLink(ctx, repo, ref)
+	if err != nil {
+		return nil, err
+	}
+	if link.OwnerID != ownerID || link.DeletedAt != nil {
+		return nil, ErrLinkNotFound
+	}
+	return link, nil
+}
+
+// unusedSlug generates slugs until it finds one that is neither reserved nor taken.
+func (u *linkUsecase) unusedSlug(ctx context.Context) (string, error) {
+	for attempt := 0; attempt < maxSlugGenAttempts; attempt++ {
+		slug, err := generateSlug()
+		if err != nil {
+			return "", err
+		}
+		if reservedSlugs[strings.ToLower(slug)] {
+			continue
+		}
+		_, err = u.repo.GetBySlug(ctx, slug)
+		if errors.Is(err, repository.ErrNotFound) {
+			return slug, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", errors.New("could not generate a unique slug")
 }
@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/hussainr95/link-in-bio-service/internal/pubsub"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+)
+
+// LiveUsecase lets owners watch visits to their links as they happen.
+// Callers must close the returned subscriptions.
+type LiveUsecase interface {
+	WatchLink(ctx context.Context, ownerID, ref string) (*pubsub.Subscription, error)
+	// WatchProfile covers the links on the owner's profile when it is
+	// called; links added later are not included.
+	WatchProfile(ctx context.Context, ownerID string) (*pubsub.Subscription, error)
+}
+
+type liveUsecase struct {
+	linkRepo    repository.LinkRepository
+	profileRepo repository.ProfileRepository
+	broker      *pubsub.Broker
+}
+
+func NewLiveUsecase(linkRepo repository.LinkRepository, profileRepo repository.ProfileRepository, broker *pubsub.Broker) LiveUsecase {
+	return &liveUsecase{linkRepo: linkRepo, profileRepo: profileRepo, broker: broker}
+}
+
+func (u *liveUsecase) WatchLink(ctx context.Context, ownerID, ref string) (*pubsub.Subscription, error) {
+	link, err := findOwnedLink(ctx, u.linkRepo, ownerID, ref)
+	if err != nil {
+		return nil, err
+	}
+	return u.broker.Subscribe([]string{link.ID}), nil
+}
+
+func (u *liveUsecase) WatchProfile(ctx context.Context, ownerID string) (*pubsub.Subscription, error) {
+	_, links, err := profileLinks(ctx, u.profileRepo, u.linkRepo, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	return u.broker.Subscribe(linkIDs(links)), nil
+}
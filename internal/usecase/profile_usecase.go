@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+)
+
+var (
+	ErrProfileNotFound = errors.New("profile not found")
+	ErrHandleTaken     = errors.New("handle is already taken")
+	ErrInvalidProfile  = errors.New("invalid profile")
+)
+
+var handlePattern = regexp.MustCompile(`^[a-z0-9_.]{3,30}$`)
+
+type ProfileUsecase interface {
+	GetProfile(ctx context.Context, userID string) (*entity.Profile, error)
+	SaveProfile(ctx context.Context, userID string, profile *entity.Profile) (*entity.Profile, error)
+	GetPublicProfile(ctx context.Context, handle string) (*entity.PublicProfile, error)
+}
+
+type profileUsecase struct {
+	repo     repository.ProfileRepository
+	linkRepo repository.LinkRepository
+}
+
+func NewProfileUsecase(repo repository.ProfileRepository, linkRepo repository.LinkRepository) ProfileUsecase {
+	return &profileUsecase{repo: repo, linkRepo: linkRepo}
+}
+
+func (u *profileUsecase) GetProfile(ctx context.Context, userID string) (*entity.Profile, error) {
+	profile, err := u.repo.GetByUserID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrProfileNotFound
+	}
+	return profile, err
+}
+
+// SaveProfile creates the user's profile on first use and updates it afterwards.
+func (u *profileUsecase) SaveProfile(ctx context.Context, userID string, profile *entity.Profile) (*entity.Profile, error) {
+	profile.Handle = strings.ToLower(strings.TrimSpace(profile.Handle))
+	if !handlePattern.MatchString(profile.Handle) {
+		return nil, fmt.Errorf("%w: handle must be 3-30 characters of a-z, 0-9, '_' or '.'", ErrInvalidProfile)
+	}
+	if profile.Theme == "" {
+		profile.Theme = entity.DefaultTheme
+	}
+	if _, ok := entity.LookupTheme(profile.Theme); !ok {
+		return nil, fmt.Errorf("%w: unknown theme %q, choose one of %s", ErrInvalidProfile, profile.Theme, strings.Join(entity.ThemeNames(), ", "))
+	}
+	if err := u.checkLinkOwnership(ctx, userID, profile.LinkIDs); err != nil {
+		return nil, err
+	}
+	if profile.LinkIDs == nil {
+		profile.LinkIDs = []string{}
+	}
+
+	now := time.Now()
+	profile.UserID = userID
+	profile.UpdatedAt = now
+
+	existing, err := u.repo.GetByUserID(ctx, userID)
+	var saved *entity.Profile
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		profile.CreatedAt = now
+		saved, err = u.repo.Create(ctx, profile)
+	case err != nil:
+		return nil, err
+	default:
+		profile.ID = existing.ID
+		profile.CreatedAt = existing.CreatedAt
+		saved, err = u.repo.Update(ctx, profile)
+	}
+	if errors.Is(err, repository.ErrDuplicate) {
+		return nil, ErrHandleTaken
+	}
+	return saved, err
+}
+
+// GetPublicProfile returns the profile for handle with its live links, in
+// the order chosen by the owner. Scheduled and expired links are silently
+// left out.
+func (u *profileUsecase) GetPublicProfile(ctx context.Context, handle string) (*entity.PublicProfile, error) {
+	profile, err := u.repo.GetByHandle(ctx, strings.ToLower(handle))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrProfileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := u.linkRepo.GetByIDs(ctx, profile.LinkIDs)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*entity.Link, len(links))
+	for _, link := range links {
+		byID[link.ID] = link
+	}
+
+	now := time.Now()
+	public := &entity.PublicProfile{
+		Handle:      profile.Handle,
+		DisplayName: profile.DisplayName,
+		AvatarURL:   profile.AvatarURL,
+		Bio:         profile.Bio,
+		Theme:       profile.Theme,
+		Links:       make([]*entity.PublicLink, 0, len(profile.LinkIDs)),
+	}
+	for _, id := range profile.LinkIDs {
+		link, ok := byID[id]
+		if !ok || link.OwnerID != profile.UserID || link.StatusAt(now) != entity.LinkActive {
+			continue
+		}
+		public.Links = append(public.Links, &entity.PublicLink{ID: link.ID, Slug: link.Slug, Title: link.Title, URL: link.URL})
+	}
+	return public, nil
+}
+
+func (u *profileUsecase) checkLinkOwnership(ctx context.Context, userID string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	links, err := u.linkRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+	owned := make(map[string]bool, len(links))
+	for _, link := range links {
+		owned[link.ID] = link.OwnerID == userID
+	}
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if !owned[id] {
+			return fmt.Errorf("%w: unknown link %q", ErrInvalidProfile, id)
+		}
+		if seen[id] {
+			return fmt.Errorf("%w: link %q is listed twice", ErrInvalidProfile, id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
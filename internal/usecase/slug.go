@@ -0,0 +1,61 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+const (
+	slugAlphabet       = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	generatedSlugLen   = 7
+	maxSlugGenAttempts = 5
+)
+
+var (
+	slugPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{2,63}$`)
+	// objectIDPattern matches raw link IDs, which slugs must not be mistaken for.
+	objectIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{24}$`)
+)
+
+// reservedSlugs cannot be chosen by users because they collide with routes or
+// could be used to impersonate the service. Routes shorter than three
+// characters, such as /p and /r, are already ruled out by slugPattern.
+var reservedSlugs = map[string]bool{
+	"about": true, "admin": true, "api": true, "app": true, "archived": true, "assets": true,
+	"auth": true, "dashboard": true, "docs": true, "health": true, "help": true,
+	"link": true, "links": true, "login": true, "logout": true, "privacy": true,
+	"profile": true, "settings": true, "signup": true, "static": true,
+	"status": true, "support": true, "swagger": true, "terms": true,
+	"trash": true, "visit": true, "www": true,
+}
+
+// validateSlug checks a user-chosen slug.
+func validateSlug(slug string) error {
+	if !slugPattern.MatchString(slug) {
+		return fmt.Errorf("%w: slug must be 3-64 characters of letters, digits, '-' or '_'", ErrInvalidLink)
+	}
+	if objectIDPattern.MatchString(slug) {
+		return fmt.Errorf("%w: slug must not look like a link ID", ErrInvalidLink)
+	}
+	if reservedSlugs[strings.ToLower(slug)] {
+		return fmt.Errorf("%w: slug %q is reserved", ErrInvalidLink, slug)
+	}
+	return nil
+}
+
+// generateSlug returns a random base62 slug.
+func generateSlug() (string, error) {
+	max := big.NewInt(int64(len(slugAlphabet)))
+	b := make([]byte, generatedSlugLen)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = slugAlphabet[n.Int64()]
+	}
+	return string(b), nil
+}
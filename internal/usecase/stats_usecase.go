@@ -0,0 +1,375 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/hll"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+)
+
+const (
+	// maxStatsBuckets bounds the size of a time series, e.g. about six weeks
+	// of hourly buckets.
+	maxStatsBuckets = 1000
+
+	defaultBreakdownSpan  = 30 * 24 * time.Hour
+	defaultBreakdownLimit = 10
+	maxBreakdownLimit     = 100
+)
+
+// StatsQuery holds the raw parameters of a stats request. From and To are
+// RFC 3339 times or YYYY-MM-DD dates in Timezone; a date-only To includes
+// that whole day. Empty values fall back to defaults.
+type StatsQuery struct {
+	From     string
+	To       string
+	Interval string
+	Timezone string
+}
+
+// BreakdownQuery selects the dimension and number of values of a breakdown
+// over the range described by StatsQuery; Interval is ignored. Limit
+// defaults to 10.
+type BreakdownQuery struct {
+	StatsQuery
+	Dimension string
+	Limit     int
+}
+
+// StatsUsecase reports click counts over time.
+type StatsUsecase interface {
+	LinkStats(ctx context.Context, ownerID, ref string, q StatsQuery) (*entity.LinkStats, error)
+	ProfileStats(ctx context.Context, ownerID string, q StatsQuery) (*entity.ProfileStats, error)
+	LinkBreakdown(ctx context.Context, ownerID, ref string, q BreakdownQuery) (*entity.Breakdown, error)
+	LinkVisitors(ctx context.Context, ownerID, ref string, q StatsQuery) (*entity.VisitorStats, error)
+	DetectClickAnomalies(ctx context.Context) error
+	RollupVisits(ctx context.Context) error
+}
+
+type statsUsecase struct {
+	linkRepo    repository.LinkRepository
+	visitRepo   repository.VisitRepository
+	profileRepo repository.ProfileRepository
+	sketchRepo  repository.VisitorSketchRepository
+	anomalyRepo repository.ClickAnomalyRepository
+	rollupRepo  repository.VisitRollupRepository
+	retention   time.Duration
+}
+
+func NewStatsUsecase(linkRepo repository.LinkRepository, visitRepo repository.VisitRepository, profileRepo repository.ProfileRepository, sketchRepo repository.VisitorSketchRepository, anomalyRepo repository.ClickAnomalyRepository, rollupRepo repository.VisitRollupRepository, opts ...StatsOption) StatsUsecase {
+	u := &statsUsecase{linkRepo: linkRepo, visitRepo: visitRepo, profileRepo: profileRepo, sketchRepo: sketchRepo, anomalyRepo: anomalyRepo, rollupRepo: rollupRepo}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
+}
+
+func (u *statsUsecase) LinkStats(ctx context.Context, ownerID, ref string, q StatsQuery) (*entity.LinkStats, error) {
+	window, loc, err := statsWindow(q, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	link, err := findOwnedLink(ctx, u.linkRepo, ownerID, ref)
+	if err != nil {
+		return nil, err
+	}
+	total, buckets, err := u.series(ctx, []string{link.ID}, window, loc)
+	if err != nil {
+		return nil, err
+	}
+	bots, err := u.count(ctx, repository.VisitQuery{LinkIDs: []string{link.ID}, From: window.From, To: window.To, Bots: repository.BotsOnly})
+	if err != nil {
+		return nil, err
+	}
+	anomalies, err := u.anomalyRepo.List(ctx, []string{link.ID}, window.From, window.To)
+	if err != nil {
+		return nil, err
+	}
+	return &entity.LinkStats{LinkID: link.ID, StatsRange: window, Total: total, BotHits: bots, Buckets: buckets, Anomalies: anomalies}, nil
+}
+
+// ProfileStats covers the links currently shown on the owner's profile.
+func (u *statsUsecase) ProfileStats(ctx context.Context, ownerID string, q StatsQuery) (*entity.ProfileStats, error) {
+	window, loc, err := statsWindow(q, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	profile, links, err := profileLinks(ctx, u.profileRepo, u.linkRepo, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	ids := linkIDs(links)
+	total, buckets, err := u.series(ctx, ids, window, loc)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := u.countByLink(ctx, repository.VisitQuery{LinkIDs: ids, From: window.From, To: window.To})
+	if err != nil {
+		return nil, err
+	}
+
+	anomalies, err := u.anomalyRepo.List(ctx, ids, window.From, window.To)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &entity.ProfileStats{Handle: profile.Handle, StatsRange: window, Total: total, Buckets: buckets, Links: []*entity.LinkTotal{}, Anomalies: anomalies}
+	for _, link := range links {
+		stats.Links = append(stats.Links, &entity.LinkTotal{LinkID: link.ID, Slug: link.Slug, Title: link.Title, Clicks: counts[link.ID]})
+	}
+	return stats, nil
+}
+
+// profileLinks returns the owner's profile and the owner's links on it, in
+// the profile's order.
+func profileLinks(ctx context.Context, profileRepo repository.ProfileRepository, linkRepo repository.LinkRepository, ownerID string) (*entity.Profile, []*entity.Link, error) {
+	profile, err := profileRepo.GetByUserID(ctx, ownerID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil, ErrProfileNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	links, err := linkRepo.GetByIDs(ctx, profile.LinkIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[string]*entity.Link, len(links))
+	for _, link := range links {
+		byID[link.ID] = link
+	}
+	owned := make([]*entity.Link, 0, len(links))
+	for _, id := range profile.LinkIDs {
+		if link, ok := byID[id]; ok && link.OwnerID == ownerID {
+			owned = append(owned, link)
+		}
+	}
+	return profile, owned, nil
+}
+
+// linkIDs returns the IDs of links.
+func linkIDs(links []*entity.Link) []string {
+	ids := make([]string, len(links))
+	for i, link := range links {
+		ids[i] = link.ID
+	}
+	return ids
+}
+
+// LinkBreakdown reports the most common values of a visit attribute among a
+// link's clicks. Clicks without a referrer are reported as "direct" and other
+// missing values as "unknown".
+func (u *statsUsecase) LinkBreakdown(ctx context.Context, ownerID, ref string, q BreakdownQuery) (*entity.Breakdown, error) {
+	dimension := entity.BreakdownDimension(q.Dimension)
+	if _, ok := repository.VisitFields[dimension]; !ok {
+		return nil, fmt.Errorf("%w: dimension must be referrer, country, device, browser, os or language", ErrInvalidQuery)
+	}
+	limit := q.Limit
+	switch {
+	case limit == 0:
+		limit = defaultBreakdownLimit
+	case limit < 0 || limit > maxBreakdownLimit:
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, maxBreakdownLimit)
+	}
+	from, to, timezone, _, err := statsRange(q.StatsQuery, time.Now(), defaultBreakdownSpan)
+	if err != nil {
+		return nil, err
+	}
+	link, err := findOwnedLink(ctx, u.linkRepo, ownerID, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	vq := repository.VisitQuery{LinkIDs: []string{link.ID}, From: from, To: to}
+	total, err := u.count(ctx, vq)
+	if err != nil {
+		return nil, err
+	}
+	items, err := u.topValues(ctx, vq, dimension, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	breakdown := &entity.Breakdown{
+		LinkID: link.ID, Dimension: dimension, From: from, To: to, Timezone: timezone,
+		Total: total, Items: items, Other: total,
+	}
+	for _, item := range items {
+		if item.Value == "" {
+			item.Value = "unknown"
+			if dimension == entity.DimensionReferrer {
+				item.Value = "direct"
+			}
+		}
+		item.Percent = percentOf(item.Clicks, total)
+		breakdown.Other -= item.Clicks
+	}
+	return breakdown, nil
+}
+
+// LinkVisitors estimates the distinct visitors of a link per UTC day and over
+// the whole range, next to its clicks.
+func (u *statsUsecase) LinkVisitors(ctx context.Context, ownerID, ref string, q StatsQuery) (*entity.VisitorStats, error) {
+	if q.Timezone != "" && q.Timezone != "UTC" {
+		return nil, fmt.Errorf("%w: visitors are counted per UTC day, so tz must be UTC", ErrInvalidQuery)
+	}
+	q.Interval, q.Timezone = string(entity.IntervalDay), "UTC"
+	window, loc, err := statsWindow(q, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	link, err := findOwnedLink(ctx, u.linkRepo, ownerID, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	clicks, buckets, err := u.series(ctx, []string{link.ID}, window, loc)
+	if err != nil {
+		return nil, err
+	}
+	sketches, err := u.sketchRepo.List(ctx, []string{link.ID}, window.From, window.To)
+	if err != nil {
+		return nil, err
+	}
+	daily := make(map[int64]*hll.Sketch, len(sketches))
+	var overall hll.Sketch
+	for _, stored := range sketches {
+		day := daily[stored.Day.Unix()]
+		if day == nil {
+			day = &hll.Sketch{}
+			daily[stored.Day.Unix()] = day
+		}
+		for index, rank := range stored.Registers {
+			day.Set(index, rank)
+			overall.Set(index, rank)
+		}
+	}
+
+	stats := &entity.VisitorStats{LinkID: link.ID, From: window.From, To: window.To, Clicks: clicks, Days: make([]*entity.DailyVisitors, len(buckets))}
+	for i, bucket := range buckets {
+		day := &entity.DailyVisitors{Day: bucket.Start.Format(time.DateOnly), Clicks: bucket.Clicks}
+		if sketch := daily[bucket.Start.Unix()]; sketch != nil {
+			day.Visitors = int64(sketch.Estimate())
+		}
+		stats.Days[i] = day
+	}
+	stats.Visitors = int64(overall.Estimate())
+	return stats, nil
+}
+
+// percentOf returns part as a percentage of total, rounded to one decimal.
+func percentOf(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*1000/float64(total)) / 10
+}
+
+// series returns the total and the gap-free time series of clicks on the
+// given links over window.
+func (u *statsUsecase) series(ctx context.Context, linkIDs []string, window entity.StatsRange, loc *time.Location) (int64, []*entity.StatsBucket, error) {
+	counted := map[int64]int64{}
+	if len(linkIDs) > 0 {
+		var err error
+		q := repository.VisitQuery{LinkIDs: linkIDs, From: window.From, To: window.To}
+		if counted, err = u.countByInterval(ctx, q, window.Interval, loc); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	var total int64
+	buckets := make([]*entity.StatsBucket, 0)
+	for start := window.From; start.Before(window.To); start = repository.NextBucket(start, window.Interval) {
+		clicks := counted[start.Unix()]
+		total += clicks
+		buckets = append(buckets, &entity.StatsBucket{Start: start, Clicks: clicks})
+	}
+	return total, buckets, nil
+}
+
+// statsWindow validates q and resolves it against now. From is aligned down
+// to the start of its bucket so that every bucket covers a full interval.
+func statsWindow(q StatsQuery, now time.Time) (entity.StatsRange, *time.Location, error) {
+	window := entity.StatsRange{Interval: entity.StatsInterval(q.Interval)}
+	var defaultSpan time.Duration
+	switch window.Interval {
+	case entity.IntervalHour:
+		defaultSpan = 24 * time.Hour
+	case "", entity.IntervalDay:
+		window.Interval = entity.IntervalDay
+		defaultSpan = 7 * 24 * time.Hour
+	case entity.IntervalWeek:
+		defaultSpan = 12 * 7 * 24 * time.Hour
+	default:
+		return window, nil, fmt.Errorf("%w: interval must be hour, day or week", ErrInvalidQuery)
+	}
+
+	var loc *time.Location
+	var err error
+	window.From, window.To, window.Timezone, loc, err = statsRange(q, now, defaultSpan)
+	if err != nil {
+		return window, nil, err
+	}
+	window.From = repository.BucketStart(window.From, window.Interval, loc)
+
+	count := 0
+	for start := window.From; start.Before(window.To); start = repository.NextBucket(start, window.Interval) {
+		if count++; count > maxStatsBuckets {
+			return window, nil, fmt.Errorf("%w: range spans more than %d %s buckets", ErrInvalidQuery, maxStatsBuckets, window.Interval)
+		}
+	}
+	return window, loc, nil
+}
+
+// statsRange resolves the timezone and the [from, to) range of q, defaulting
+// to the defaultSpan before now. Both times are returned in the timezone.
+func statsRange(q StatsQuery, now time.Time, defaultSpan time.Duration) (from, to time.Time, timezone string, loc *time.Location, err error) {
+	timezone = q.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err = time.LoadLocation(timezone)
+	if err != nil || timezone == "Local" {
+		err = fmt.Errorf("%w: unknown timezone %q, use an IANA name such as Europe/Berlin", ErrInvalidQuery, q.Timezone)
+		return
+	}
+
+	to = now
+	if q.To != "" {
+		if to, err = parseStatsTime(q.To, loc, true); err != nil {
+			return
+		}
+	}
+	from = to.Add(-defaultSpan)
+	if q.From != "" {
+		if from, err = parseStatsTime(q.From, loc, false); err != nil {
+			return
+		}
+	}
+	if !from.Before(to) {
+		err = fmt.Errorf("%w: from must be before to", ErrInvalidQuery)
+		return
+	}
+	return from.In(loc), to.In(loc), timezone, loc, nil
+}
+
+// parseStatsTime accepts an RFC 3339 time or a YYYY-MM-DD date in loc. With
+// endOfDay set, a date means the end of that day.
+func parseStatsTime(value string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.ParseInLocation(time.DateOnly, value, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q is neither an RFC 3339 time nor a YYYY-MM-DD date", ErrInvalidQuery, value)
+	}
+	if endOfDay {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const maxTagsPerLink = 10
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// normalizeTags lower-cases and trims tags, drops duplicates while keeping
+// their order, and rejects tags that could not be used as a filter value.
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !tagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("%w: tag %q must be 1-32 characters of letters, digits, '-' or '_'", ErrInvalidLink, tag)
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	if len(normalized) > maxTagsPerLink {
+		return nil, fmt.Errorf("%w: a link can have at most %d tags", ErrInvalidLink, maxTagsPerLink)
+	}
+	return normalized, nil
+}
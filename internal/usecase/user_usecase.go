@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+)
+
+var (
+	ErrUserNotFound  = errors.New("user not found")
+	ErrUserExists    = errors.New("a user with this email already exists")
+	ErrUserNameEmpty = errors.New("user name is required")
+)
+
+type UserUsecase interface {
+	CreateUser(ctx context.Context, user *entity.User) (*entity.User, error)
+	GetUser(ctx context.Context, id string) (*entity.User, error)
+	ListUsers(ctx context.Context) ([]*entity.User, error)
+}
+
+type userUsecase struct {
+	repo repository.UserRepository
+}
+
+func NewUserUsecase(repo repository.UserRepository) UserUsecase {
+	return &userUsecase{repo: repo}
+}
+
+func (u *userUsecase) CreateUser(ctx context.Context, user *entity.User) (*entity.User, error) {
+	user.Name = strings.TrimSpace(user.Name)
+	if user.Name == "" {
+		return nil, ErrUserNameEmpty
+	}
+	user.Email = strings.ToLower(strings.TrimSpace(user.Email))
+	user.CreatedAt = time.Now()
+
+	created, err := u.repo.Create(ctx, user)
+	if errors.Is(err, repository.ErrDuplicate) {
+		return nil, ErrUserExists
+	}
+	return created, err
+}
+
+func (u *userUsecase) GetUser(ctx context.Context, id string) (*entity.User, error) {
+	user, err := u.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrUserNotFound
+	}
+	return user, err
+}
+
+func (u *userUsecase) ListUsers(ctx context.Context) ([]*entity.User, error) {
+	return u.repo.List(ctx)
+}
@@ -0,0 +1,83 @@
+package usecase
+
+import "strings"
+
+// Device classes recorded on visits.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceUnknown = "unknown"
+)
+
+// uaMatcher names a browser or operating system when any of its markers
+// appear in a User-Agent header.
+type uaMatcher struct {
+	name    string
+	markers []string
+}
+
+// browsers is checked in order: in-app browsers first because they embed a
+// regular engine token, and Chromium forks before Chrome itself.
+var browsers = []uaMatcher{
+	{"Instagram", []string{"Instagram"}},
+	{"Facebook", []string{"FBAN/", "FBAV/", "FB_IAB/"}},
+	{"TikTok", []string{"musical_ly", "BytedanceWebview", "TikTok"}},
+	{"Snapchat", []string{"Snapchat"}},
+	{"Edge", []string{"Edg/", "EdgA/", "EdgiOS/", "Edge/"}},
+	{"Opera", []string{"OPR/", "Opera"}},
+	{"Samsung Internet", []string{"SamsungBrowser/"}},
+	{"Chrome", []string{"Chrome/", "CriOS/"}},
+	{"Firefox", []string{"Firefox/", "FxiOS/"}},
+	{"Safari", []string{"Safari/"}},
+	{"Internet Explorer", []string{"MSIE ", "Trident/"}},
+}
+
+var operatingSystems = []uaMatcher{
+	{"iOS", []string{"iPhone", "iPad", "iPod"}},
+	{"Android", []string{"Android"}},
+	{"Windows", []string{"Windows"}},
+	{"ChromeOS", []string{"CrOS"}},
+	{"macOS", []string{"Macintosh", "Mac OS X"}},
+	{"Linux", []string{"Linux", "X11"}},
+}
+
+// userAgentInfo is what a visit records about the client software.
+type userAgentInfo struct {
+	Browser string
+	OS      string
+	Device  string
+}
+
+// parseUserAgent classifies a User-Agent header. It recognises the clients
+// that make up nearly all link-in-bio traffic rather than every UA ever seen;
+// anything else is reported as "Other".
+func parseUserAgent(ua string) userAgentInfo {
+	if strings.TrimSpace(ua) == "" {
+		return userAgentInfo{Device: DeviceUnknown}
+	}
+	info := userAgentInfo{Browser: matchUA(browsers, ua), OS: matchUA(operatingSystems, ua)}
+	switch {
+	case strings.Contains(ua, "iPad") || strings.Contains(ua, "Tablet") ||
+		(info.OS == "Android" && !strings.Contains(ua, "Mobile")):
+		info.Device = DeviceTablet
+	case strings.Contains(ua, "Mobi") || info.OS == "iOS" || info.OS == "Android":
+		info.Device = DeviceMobile
+	case info.OS == "Windows" || info.OS == "macOS" || info.OS == "Linux" || info.OS == "ChromeOS":
+		info.Device = DeviceDesktop
+	default:
+		info.Device = DeviceUnknown
+	}
+	return info
+}
+
+func matchUA(matchers []uaMatcher, ua string) string {
+	for _, m := range matchers {
+		for _, marker := range m.markers {
+			if strings.Contains(ua, marker) {
+				return m.name
+			}
+		}
+	}
+	return "Other"
+}
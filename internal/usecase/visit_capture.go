@@ -0,0 +1,184 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/hll"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+)
+
+// VisitRequest carries what the delivery layer knows about the client
+// following a link. All fields are optional.
+type VisitRequest struct {
+	IP             string
+	UserAgent      string
+	Referrer       string
+	AcceptLanguage string
+	// Method, Accept and Purpose help tell browsers from bots. Purpose is
+	// the Purpose or Sec-Purpose header sent with prefetches.
+	Method  string
+	Accept  string
+	Purpose string
+}
+
+// CountryResolver maps a client IP to an ISO 3166-1 alpha-2 country code,
+// returning "" when the country is unknown.
+type CountryResolver interface {
+	Country(ip net.IP) string
+}
+
+// WithGeoIP resolves the country of each visit.
+func WithGeoIP(resolver CountryResolver) LinkOption {
+	return func(u *linkUsecase) {
+		u.geo = resolver
+	}
+}
+
+// WithVisitorSalt sets the secret mixed into visitor hashes. It must stay the
+// same across restarts for visitors to be recognised within a day.
+func WithVisitorSalt(salt []byte) LinkOption {
+	return func(u *linkUsecase) {
+		u.visitorSalt = salt
+	}
+}
+
+// WithVisitorSketches counts unique visitors per link and day in sketches.
+func WithVisitorSketches(repo repository.VisitorSketchRepository) LinkOption {
+	return func(u *linkUsecase) {
+		u.sketches = repo
+	}
+}
+
+// WithClickDedup counts at most one click per visitor and link within
+// window; further visits in the window are recorded as repeats. Zero turns
+// deduplication off.
+func WithClickDedup(repo repository.ClickWindowRepository, window time.Duration) LinkOption {
+	return func(u *linkUsecase) {
+		u.clickWindows = repo
+		u.dedupWindow = window
+	}
+}
+
+// WithClickAnomalies lets purges delete the click anomalies of purged links.
+func WithClickAnomalies(repo repository.ClickAnomalyRepository) LinkOption {
+	return func(u *linkUsecase) {
+		u.anomalies = repo
+	}
+}
+
+// WithVisitRollups lets purges delete the visit rollups of purged links.
+func WithVisitRollups(repo repository.VisitRollupRepository) LinkOption {
+	return func(u *linkUsecase) {
+		u.rollups = repo
+	}
+}
+
+// VisitPublisher receives every recorded visit, e.g. to stream it to live
+// subscribers. Publish must not block.
+type VisitPublisher interface {
+	Publish(event *entity.VisitEvent)
+}
+
+// WithVisitEvents publishes each visit once it has been recorded.
+func WithVisitEvents(publisher VisitPublisher) LinkOption {
+	return func(u *linkUsecase) {
+		u.events = publisher
+	}
+}
+
+// newVisit records a visit to linkID, deriving analytics fields from req.
+// The client IP itself is never stored.
+func (u *linkUsecase) newVisit(linkID string, req VisitRequest, now time.Time) *entity.Visit {
+	ua := parseUserAgent(req.UserAgent)
+	visit := &entity.Visit{
+		LinkID:    linkID,
+		VisitedAt: now,
+		Browser:   ua.Browser,
+		OS:        ua.OS,
+		Device:    ua.Device,
+		Language:  primaryLanguage(req.AcceptLanguage),
+	}
+	visit.Referrer, visit.ReferrerHost = cleanReferrer(req.Referrer)
+	visit.Bot, visit.BotName = classifyBot(req)
+
+	if ip := net.ParseIP(strings.TrimSpace(req.IP)); ip != nil {
+		visit.VisitorHash = u.visitorHash(ip, req.UserAgent, now)
+		if u.geo != nil {
+			visit.Country = strings.ToUpper(u.geo.Country(ip))
+		}
+	}
+	return visit
+}
+
+// visitorHash pseudonymises a client as a keyed hash of its IP address and
+// User-Agent. The day is mixed in as well, so a visitor cannot be followed
+// from one day to the next and the hash is useless once the day is over.
+func (u *linkUsecase) visitorHash(ip net.IP, userAgent string, now time.Time) string {
+	mac := hmac.New(sha256.New, u.visitorSalt)
+	mac.Write([]byte(now.UTC().Format(time.DateOnly)))
+	mac.Write(ip.To16())
+	mac.Write([]byte(userAgent))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// markRepeat flags the visit as a repeat when the same visitor's click on
+// the link was counted within the dedup window. Visits without a visitor
+// hash cannot be recognised and always count.
+func (u *linkUsecase) markRepeat(ctx context.Context, visit *entity.Visit) error {
+	if u.clickWindows == nil || u.dedupWindow <= 0 || visit.Bot || visit.VisitorHash == "" {
+		return nil
+	}
+	counted, err := u.clickWindows.Claim(ctx, visit.LinkID, visit.VisitorHash, visit.VisitedAt, u.dedupWindow)
+	if err != nil {
+		return err
+	}
+	visit.Repeat = !counted
+	return nil
+}
+
+// countVisitor adds the visit's visitor to the link's sketch for the day.
+func (u *linkUsecase) countVisitor(ctx context.Context, visit *entity.Visit) error {
+	hash, err := hex.DecodeString(visit.VisitorHash)
+	if u.sketches == nil || visit.Bot || visit.Repeat || err != nil || len(hash) < 8 {
+		return nil
+	}
+	index, rank := hll.Position(binary.BigEndian.Uint64(hash[:8]))
+	return u.sketches.Raise(ctx, visit.LinkID, sketchDay(visit.VisitedAt), index, rank)
+}
+
+// sketchDay returns the UTC day a visitor sketch for t covers.
+func sketchDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// cleanReferrer drops the query string and fragment, which often carry
+// personal data, and returns the referrer with its lower-cased host.
+func cleanReferrer(raw string) (referrer, host string) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", ""
+	}
+	host = strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	return u.Scheme + "://" + u.Host + u.EscapedPath(), host
+}
+
+// primaryLanguage returns the first language tag of an Accept-Language
+// header, e.g. "en-US" for "en-US,en;q=0.9".
+func primaryLanguage(header string) string {
+	first, _, _ := strings.Cut(header, ",")
+	tag, _, _ := strings.Cut(first, ";")
+	tag = strings.TrimSpace(tag)
+	if tag == "*" || len(tag) > 35 {
+		return ""
+	}
+	return tag
+}
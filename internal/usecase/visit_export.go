@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+)
+
+const defaultExportSpan = 30 * 24 * time.Hour
+
+// VisitExport is a validated export of raw visit records, ready to be
+// streamed. It includes bot hits and repeats, which are flagged.
+type VisitExport struct {
+	// Name identifies what is exported, the link's slug or the profile's
+	// handle, e.g. for use in a file name.
+	Name     string
+	From     time.Time
+	To       time.Time
+	Timezone string
+
+	loc       *time.Location
+	query     repository.VisitQuery
+	visitRepo repository.VisitRepository
+}
+
+// Each calls fn with every visit in the export, oldest first, with its time
+// in the export's timezone. It stops at the first error fn returns.
+func (e *VisitExport) Each(ctx context.Context, fn func(*entity.Visit) error) error {
+	return e.visitRepo.Each(ctx, e.query, func(visit *entity.Visit) error {
+		visit.VisitedAt = visit.VisitedAt.In(e.loc)
+		return fn(visit)
+	})
+}
+
+// ExportUsecase exports raw visit records for analysis elsewhere.
+type ExportUsecase interface {
+	ExportLinkVisits(ctx context.Context, ownerID, ref string, q StatsQuery) (*VisitExport, error)
+	ExportProfileVisits(ctx context.Context, ownerID string, q StatsQuery) (*VisitExport, error)
+}
+
+type exportUsecase struct {
+	linkRepo    repository.LinkRepository
+	visitRepo   repository.VisitRepository
+	profileRepo repository.ProfileRepository
+}
+
+func NewExportUsecase(linkRepo repository.LinkRepository, visitRepo repository.VisitRepository, profileRepo repository.ProfileRepository) ExportUsecase {
+	return &exportUsecase{linkRepo: linkRepo, visitRepo: visitRepo, profileRepo: profileRepo}
+}
+
+// ExportLinkVisits exports the visits of one link. The range is read as for
+// stats and defaults to the last 30 days; Interval is ignored.
+func (u *exportUsecase) ExportLinkVisits(ctx context.Context, ownerID, ref string, q StatsQuery) (*VisitExport, error) {
+	export, err := u.newExport(q)
+	if err != nil {
+		return nil, err
+	}
+	link, err := findOwnedLink(ctx, u.linkRepo, ownerID, ref)
+	if err != nil {
+		return nil, err
+	}
+	export.Name = link.Slug
+	if export.Name == "" {
+		export.Name = link.ID
+	}
+	export.query.LinkIDs = []string{link.ID}
+	return export, nil
+}
+
+// ExportProfileVisits exports the visits of all links currently shown on
+// the owner's profile.
+func (u *exportUsecase) ExportProfileVisits(ctx context.Context, ownerID string, q StatsQuery) (*VisitExport, error) {
+	export, err := u.newExport(q)
+	if err != nil {
+		return nil, err
+	}
+	profile, links, err := profileLinks(ctx, u.profileRepo, u.linkRepo, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	export.Name = profile.Handle
+	export.query.LinkIDs = linkIDs(links)
+	return export, nil
+}
+
+func (u *exportUsecase) newExport(q StatsQuery) (*VisitExport, error) {
+	from, to, timezone, loc, err := statsRange(q, time.Now(), defaultExportSpan)
+	if err != nil {
+		return nil, err
+	}
+	return &VisitExport{
+		From:      from,
+		To:        to,
+		Timezone:  timezone,
+		loc:       loc,
+		query:     repository.VisitQuery{From: from, To: to, Bots: repository.BotsIncluded, IncludeRepeats: true},
+		visitRepo: u.visitRepo,
+	}, nil
+}
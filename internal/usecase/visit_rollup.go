@@ -0,0 +1,308 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+)
+
+const (
+	// rollupDelay leaves time for visits being recorded as an hour ends
+	// before the hour is rolled up. Visits are stamped just before they are
+	// stored, so only a write that takes longer than this lands behind the
+	// watermark; such a visit is never rolled up, so stats leave it out.
+	rollupDelay = 5 * time.Minute
+	// maxRollupDaysPerRun bounds the work of one RollupVisits call when
+	// catching up on old visits.
+	maxRollupDaysPerRun = 7
+	// minVisitRetention keeps raw visits long enough for click dedup, anomaly
+	// detection and recent exports.
+	minVisitRetention = 7 * 24 * time.Hour
+	// mergedTopValues is how many values of each source are merged when a
+	// breakdown spans both raw visits and rollups.
+	mergedTopValues = 1000
+)
+
+// StatsOption configures optional behaviour of the stats usecase.
+type StatsOption func(*statsUsecase)
+
+// WithVisitRetention deletes raw visits once they are older than retention
+// and rolled up, so stats are served from rollups beyond it. Retentions
+// under a week are raised to a week; zero keeps raw visits forever.
+func WithVisitRetention(retention time.Duration) StatsOption {
+	return func(u *statsUsecase) {
+		if retention > 0 && retention < minVisitRetention {
+			retention = minVisitRetention
+		}
+		u.retention = retention
+	}
+}
+
+// RollupVisits sums the visits of every complete hour since the last run
+// into hourly rollups, and those of every complete UTC day into daily ones.
+// It then deletes raw visits past the retention.
+func (u *statsUsecase) RollupVisits(ctx context.Context) error {
+	now := time.Now()
+	cutoff := now.Add(-rollupDelay).Truncate(time.Hour)
+	watermark, err := u.rollupRepo.Watermark(ctx)
+	if err != nil {
+		return err
+	}
+	if watermark.IsZero() {
+		oldest, err := u.visitRepo.Oldest(ctx)
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			watermark = cutoff
+		case err != nil:
+			return err
+		default:
+			watermark = repository.RollupStart(oldest, entity.IntervalHour)
+		}
+		if err := u.rollupRepo.SetWatermark(ctx, watermark); err != nil {
+			return err
+		}
+	}
+
+	for days := 0; watermark.Before(cutoff) && days < maxRollupDaysPerRun; days++ {
+		day := repository.RollupStart(watermark, entity.IntervalDay)
+		end := day.Add(24 * time.Hour)
+		dayComplete := !cutoff.Before(end)
+		if !dayComplete {
+			end = cutoff
+		}
+		rollups, err := u.visitRepo.Rollup(ctx, watermark, end)
+		if err != nil {
+			return err
+		}
+		if dayComplete {
+			hours := rollups
+			if watermark.After(day) {
+				if hours, err = u.visitRepo.Rollup(ctx, day, end); err != nil {
+					return err
+				}
+			}
+			daily := repository.NewRollupBuilder(entity.IntervalDay)
+			for _, hour := range hours {
+				daily.AddRollup(hour)
+			}
+			rollups = append(rollups, daily.Rollups()...)
+		}
+		if err := u.rollupRepo.Save(ctx, rollups); err != nil {
+			return err
+		}
+		if err := u.rollupRepo.SetWatermark(ctx, end); err != nil {
+			return err
+		}
+		watermark = end
+	}
+
+	if u.retention <= 0 {
+		return nil
+	}
+	// Keep what is not rolled up yet, including the start of a day whose
+	// daily rollup still has to be summed from raw visits.
+	before := now.Add(-u.retention)
+	if day := repository.RollupStart(watermark, entity.IntervalDay); day.Before(before) {
+		before = day
+	}
+	_, err = u.visitRepo.DeleteBefore(ctx, before)
+	return err
+}
+
+// visitSpans splits a visit query into the parts read from raw visits and
+// the parts read from rollups.
+type visitSpans struct {
+	raw    []repository.VisitQuery
+	rolled []repository.RollupQuery
+}
+
+// spans reads whole hours up to the rollup watermark from rollups, using
+// daily rollups for whole UTC days if daily is set, and the rest from raw
+// visits.
+func (u *statsUsecase) spans(ctx context.Context, q repository.VisitQuery, daily bool) (visitSpans, error) {
+	watermark, err := u.rollupRepo.Watermark(ctx)
+	if err != nil {
+		return visitSpans{}, err
+	}
+	from := ceilTime(q.From, time.Hour)
+	to := q.To
+	if watermark.Before(to) {
+		to = watermark
+	}
+	to = to.Truncate(time.Hour)
+	if !from.Before(to) {
+		return visitSpans{raw: []repository.VisitQuery{q}}, nil
+	}
+
+	var spans visitSpans
+	for _, r := range [][2]time.Time{{q.From, from}, {to, q.To}} {
+		if r[0].Before(r[1]) {
+			raw := q
+			raw.From, raw.To = r[0], r[1]
+			spans.raw = append(spans.raw, raw)
+		}
+	}
+	rolled := func(granularity entity.StatsInterval, from, to time.Time) {
+		if from.Before(to) {
+			spans.rolled = append(spans.rolled, repository.RollupQuery{LinkIDs: q.LinkIDs, Granularity: granularity, From: from, To: to})
+		}
+	}
+	firstDay, lastDay := ceilTime(from, 24*time.Hour), to.Truncate(24*time.Hour)
+	if daily && firstDay.Before(lastDay) {
+		rolled(entity.IntervalHour, from, firstDay)
+		rolled(entity.IntervalDay, firstDay, lastDay)
+		rolled(entity.IntervalHour, lastDay, to)
+	} else {
+		rolled(entity.IntervalHour, from, to)
+	}
+	return spans, nil
+}
+
+// countByInterval counts the visits selected by q per interval in loc,
+// keyed by the Unix time of the bucket start. Rolled up hours are counted in
+// the bucket they start in.
+func (u *statsUsecase) countByInterval(ctx context.Context, q repository.VisitQuery, interval entity.StatsInterval, loc *time.Location) (map[int64]int64, error) {
+	spans, err := u.spans(ctx, q, interval != entity.IntervalHour && loc == time.UTC)
+	if err != nil {
+		return nil, err
+	}
+	counted := map[int64]int64{}
+	for _, raw := range spans.raw {
+		buckets, err := u.visitRepo.CountByInterval(ctx, raw, interval, loc)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range buckets {
+			counted[b.Start.Unix()] += b.Clicks
+		}
+	}
+	for _, rolled := range spans.rolled {
+		buckets, err := u.rollupRepo.CountByInterval(ctx, rolled, interval, loc)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range buckets {
+			counted[b.Start.Unix()] += b.Clicks
+		}
+	}
+	return counted, nil
+}
+
+// count counts the visits selected by q. Rollups only hold counted clicks
+// and bot hits, so q must not include repeats.
+func (u *statsUsecase) count(ctx context.Context, q repository.VisitQuery) (int64, error) {
+	spans, err := u.spans(ctx, q, true)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, raw := range spans.raw {
+		n, err := u.visitRepo.Count(ctx, raw)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	for _, rolled := range spans.rolled {
+		clicks, bots, err := u.rollupRepo.Count(ctx, rolled)
+		if err != nil {
+			return 0, err
+		}
+		if q.Bots != repository.BotsExcluded {
+			total += bots
+		}
+		if q.Bots != repository.BotsOnly {
+			total += clicks
+		}
+	}
+	return total, nil
+}
+
+// countByLink counts the clicks selected by q per link ID.
+func (u *statsUsecase) countByLink(ctx context.Context, q repository.VisitQuery) (map[string]int64, error) {
+	spans, err := u.spans(ctx, q, true)
+	if err != nil {
+		return nil, err
+	}
+	counts := map[string]int64{}
+	for _, raw := range spans.raw {
+		part, err := u.visitRepo.CountByLink(ctx, raw)
+		if err != nil {
+			return nil, err
+		}
+		for id, clicks := range part {
+			counts[id] += clicks
+		}
+	}
+	for _, rolled := range spans.rolled {
+		part, err := u.rollupRepo.CountByLink(ctx, rolled)
+		if err != nil {
+			return nil, err
+		}
+		for id, clicks := range part {
+			counts[id] += clicks
+		}
+	}
+	return counts, nil
+}
+
+// topValues returns the limit most common values of dimension among the
+// clicks selected by q. When q spans raw visits and rollups, the top values
+// of each part are merged.
+func (u *statsUsecase) topValues(ctx context.Context, q repository.VisitQuery, dimension entity.BreakdownDimension, limit int) ([]*entity.BreakdownItem, error) {
+	spans, err := u.spans(ctx, q, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(spans.raw) == 1 && len(spans.rolled) == 0 {
+		return u.visitRepo.TopValues(ctx, spans.raw[0], dimension, limit)
+	}
+
+	counts := map[string]int64{}
+	for _, raw := range spans.raw {
+		items, err := u.visitRepo.TopValues(ctx, raw, dimension, max(limit, mergedTopValues))
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			counts[item.Value] += item.Clicks
+		}
+	}
+	for _, rolled := range spans.rolled {
+		items, err := u.rollupRepo.TopValues(ctx, rolled, dimension, max(limit, mergedTopValues))
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			counts[item.Value] += item.Clicks
+		}
+	}
+	items := make([]*entity.BreakdownItem, 0, len(counts))
+	for value, clicks := range counts {
+		items = append(items, &entity.BreakdownItem{Value: value, Clicks: clicks})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Clicks != items[j].Clicks {
+			return items[i].Clicks > items[j].Clicks
+		}
+		return items[i].Value < items[j].Value
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+// ceilTime rounds t up to a multiple of d since the zero time, e.g. to the
+// next UTC hour.
+func ceilTime(t time.Time, d time.Duration) time.Time {
+	c := t.Truncate(d)
+	if c.Before(t) {
+		c = c.Add(d)
+	}
+	return c
+}
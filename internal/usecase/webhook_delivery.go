@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+)
+
+const (
+	webhookTimeout = 10 * time.Second
+	// maxDeliveryAttempts bounds the retries of a failing delivery. Waits
+	// double from webhookRetryBase, so the last attempt is made about two
+	// hours after the first.
+	maxDeliveryAttempts = 8
+	webhookRetryBase    = time.Minute
+	// deliveryLease keeps a claimed delivery from being picked up by another
+	// worker while it is being sent.
+	deliveryLease = 5 * webhookTimeout
+	// maxDeliveriesPerRun bounds the work of one DeliverDue call.
+	maxDeliveriesPerRun = 100
+)
+
+var errPrivateAddress = errors.New("webhook URL resolves to a private or loopback address")
+
+// SignWebhook returns the signature sent in the X-Webhook-Signature header:
+// the hex HMAC-SHA256 of the timestamp, a dot and the payload, keyed with the
+// webhook's secret.
+func SignWebhook(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (u *webhookUsecase) DeliverDue(ctx context.Context) error {
+	for i := 0; i < maxDeliveriesPerRun; i++ {
+		delivery, err := u.deliveries.ClaimDue(ctx, time.Now(), deliveryLease)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := u.attempt(ctx, delivery); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attempt sends a delivery once and records the outcome, scheduling a retry
+// with exponential backoff when it fails.
+func (u *webhookUsecase) attempt(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus, delivery.LastError = 0, ""
+
+	webhook, err := u.repo.GetByID(ctx, delivery.WebhookID)
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		delivery.LastError = "webhook was deleted"
+		delivery.Attempts = maxDeliveryAttempts
+	case err != nil:
+		return err
+	default:
+		delivery.ResponseStatus, err = u.send(ctx, webhook, delivery, now)
+		if err != nil {
+			delivery.LastError = err.Error()
+		}
+	}
+
+	switch {
+	case delivery.LastError == "":
+		delivery.Status, delivery.NextAttemptAt = entity.DeliverySucceeded, nil
+	case delivery.Attempts >= maxDeliveryAttempts:
+		delivery.Status, delivery.NextAttemptAt = entity.DeliveryFailed, nil
+	default:
+		next := now.Add(webhookRetryBase << (delivery.Attempts - 1))
+		delivery.Status, delivery.NextAttemptAt = entity.DeliveryPending, &next
+	}
+	return u.deliveries.Update(ctx, delivery)
+}
+
+// send posts the payload, returning the response status and an error unless
+// the endpoint answered with 2xx.
+func (u *webhookUsecase) send(ctx context.Context, webhook *entity.Webhook, delivery *entity.WebhookDelivery, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "link-in-bio-webhooks/1.0")
+	req.Header.Set("X-Webhook-Id", delivery.ID)
+	req.Header.Set("X-Webhook-Event", string(delivery.Event))
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", SignWebhook(webhook.Secret, timestamp, delivery.Payload))
+
+	res, err := u.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("endpoint responded with HTTP %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
+
+// publicHTTPClient returns a client that only connects to public addresses
+// and does not follow redirects, so webhook URLs cannot be used to reach
+// services inside the network.
+func publicHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return errPrivateAddress
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: timeout},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || sharedAddressSpace.Contains(ip))
+}
@@ -0,0 +1,231 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+)
+
+const (
+	webhookSecretPrefix = "whsec_"
+	maxWebhooksPerOwner = 10
+	maxWebhookURLLength = 2048
+	// webhookDeliveryLimit is the number of deliveries the log shows.
+	webhookDeliveryLimit = 50
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	// ErrInvalidWebhook is wrapped by validation errors on webhook input.
+	ErrInvalidWebhook = errors.New("invalid webhook")
+)
+
+// WebhookUsecase manages owners' webhooks and delivers link events to them.
+type WebhookUsecase interface {
+	// CreateWebhook registers a webhook. An empty Events list subscribes it
+	// to every event. The secret is only returned here.
+	CreateWebhook(ctx context.Context, webhook *entity.Webhook) (*entity.Webhook, error)
+	ListWebhooks(ctx context.Context, ownerID string) ([]*entity.Webhook, error)
+	DeleteWebhook(ctx context.Context, ownerID, id string) error
+	ListDeliveries(ctx context.Context, ownerID, webhookID string) ([]*entity.WebhookDelivery, error)
+	// Redeliver queues a fresh copy of a past delivery for immediate sending.
+	Redeliver(ctx context.Context, ownerID, webhookID, deliveryID string) (*entity.WebhookDelivery, error)
+	// Emit queues an event for each of the owner's webhooks subscribed to it.
+	Emit(ctx context.Context, ownerID string, event entity.WebhookEvent, data entity.WebhookData) error
+	// DeliverDue sends the deliveries whose next attempt is due.
+	DeliverDue(ctx context.Context) error
+}
+
+type webhookUsecase struct {
+	repo       repository.WebhookRepository
+	deliveries repository.WebhookDeliveryRepository
+	client     *http.Client
+}
+
+// WebhookOption configures optional behaviour of the webhook usecase.
+type WebhookOption func(*webhookUsecase)
+
+// WithWebhookClient sends deliveries with client instead of the default one,
+// which refuses to connect to loopback and private network addresses.
+func WithWebhookClient(client *http.Client) WebhookOption {
+	return func(u *webhookUsecase) {
+		u.client = client
+	}
+}
+
+func NewWebhookUsecase(repo repository.WebhookRepository, deliveries repository.WebhookDeliveryRepository, opts ...WebhookOption) WebhookUsecase {
+	u := &webhookUsecase{repo: repo, deliveries: deliveries, client: publicHTTPClient(webhookTimeout)}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
+}
+
+func (u *webhookUsecase) CreateWebhook(ctx context.Context, webhook *entity.Webhook) (*entity.Webhook, error) {
+	if err := validateWebhookURL(webhook.URL); err != nil {
+		return nil, err
+	}
+	events, err := normalizeWebhookEvents(webhook.Events)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := u.repo.ListByOwner(ctx, webhook.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= maxWebhooksPerOwner {
+		return nil, fmt.Errorf("%w: at most %d webhooks are allowed", ErrInvalidWebhook, maxWebhooksPerOwner)
+	}
+
+	secret, err := randomToken(24)
+	if err != nil {
+		return nil, err
+	}
+	webhook.ID = ""
+	webhook.Events = events
+	webhook.Secret = webhookSecretPrefix + secret
+	webhook.CreatedAt = time.Now()
+	return u.repo.Create(ctx, webhook)
+}
+
+func (u *webhookUsecase) ListWebhooks(ctx context.Context, ownerID string) ([]*entity.Webhook, error) {
+	webhooks, err := u.repo.ListByOwner(ctx, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, webhook := range webhooks {
+		webhook.Secret = ""
+	}
+	return webhooks, nil
+}
+
+func (u *webhookUsecase) DeleteWebhook(ctx context.Context, ownerID, id string) error {
+	if _, err := u.getOwned(ctx, ownerID, id); err != nil {
+		return err
+	}
+	if err := u.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	_, err := u.deliveries.DeleteByWebhookID(ctx, id)
+	return err
+}
+
+func (u *webhookUsecase) ListDeliveries(ctx context.Context, ownerID, webhookID string) ([]*entity.WebhookDelivery, error) {
+	if _, err := u.getOwned(ctx, ownerID, webhookID); err != nil {
+		return nil, err
+	}
+	return u.deliveries.ListByWebhook(ctx, webhookID, webhookDeliveryLimit)
+}
+
+func (u *webhookUsecase) Redeliver(ctx context.Context, ownerID, webhookID, deliveryID string) (*entity.WebhookDelivery, error) {
+	if _, err := u.getOwned(ctx, ownerID, webhookID); err != nil {
+		return nil, err
+	}
+	original, err := u.deliveries.GetByID(ctx, deliveryID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && original.WebhookID != webhookID) {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return u.deliveries.Create(ctx, &entity.WebhookDelivery{
+		WebhookID:     webhookID,
+		OwnerID:       ownerID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        entity.DeliveryPending,
+		RedeliveryOf:  original.ID,
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+	})
+}
+
+func (u *webhookUsecase) Emit(ctx context.Context, ownerID string, event entity.WebhookEvent, data entity.WebhookData) error {
+	webhooks, err := u.repo.ListSubscribed(ctx, ownerID, event)
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+	id, err := randomToken(12)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	payload, err := json.Marshal(&entity.WebhookPayload{ID: "evt_" + id, Event: event, CreatedAt: now, Data: data})
+	if err != nil {
+		return err
+	}
+	for _, webhook := range webhooks {
+		_, err := u.deliveries.Create(ctx, &entity.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			OwnerID:       ownerID,
+			Event:         event,
+			Payload:       payload,
+			Status:        entity.DeliveryPending,
+			NextAttemptAt: &now,
+			CreatedAt:     now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getOwned hides webhooks of other owners behind ErrWebhookNotFound.
+func (u *webhookUsecase) getOwned(ctx context.Context, ownerID, id string) (*entity.Webhook, error) {
+	webhook, err := u.repo.GetByID(ctx, id)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && webhook.OwnerID != ownerID) {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, err
+}
+
+func validateWebhookURL(raw string) error {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	if len(raw) > maxWebhookURLLength {
+		return fmt.Errorf("%w: url must be at most %d characters", ErrInvalidWebhook, maxWebhookURLLength)
+	}
+	return nil
+}
+
+// normalizeWebhookEvents dedupes and checks events, defaulting to all.
+func normalizeWebhookEvents(events []entity.WebhookEvent) ([]entity.WebhookEvent, error) {
+	if len(events) == 0 {
+		return slices.Clone(entity.WebhookEvents), nil
+	}
+	normalized := make([]entity.WebhookEvent, 0, len(events))
+	for _, event := range events {
+		if !slices.Contains(entity.WebhookEvents, event) {
+			return nil, fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, event)
+		}
+		if !slices.Contains(normalized, event) {
+			normalized = append(normalized, event)
+		}
+	}
+	return normalized, nil
+}
+
+// randomToken returns n random bytes, hex encoded.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	httphandler "github.com/hussainr95/link-in-bio-service/internal/delivery/http"
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockAPIKeyRepository struct {
+	keys map[string]*entity.APIKey
+}
+
+func newMockAPIKeyRepository() *mockAPIKeyRepository {
+	return &mockAPIKeyRepository{keys: make(map[string]*entity.APIKey)}
+}
+
+func (r *mockAPIKeyRepository) Create(ctx context.Context, key *entity.APIKey) (*entity.APIKey, error) {
+	key.ID = key.Prefix
+	r.keys[key.ID] = key
+	return key, nil
+}
+
+func (r *mockAPIKeyRepository) GetByHash(ctx context.Context, hash string) (*entity.APIKey, error) {
+	for _, key := range r.keys {
+		if key.KeyHash == hash {
+			return key, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *mockAPIKeyRepository) List(ctx context.Context) ([]*entity.APIKey, error) {
+	keys := make([]*entity.APIKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (r *mockAPIKeyRepository) Revoke(ctx context.Context, id string) error {
+	key, exists := r.keys[id]
+	if !exists {
+		return repository.ErrNotFound
+	}
+	key.Revoked = true
+	return nil
+}
+
+func (r *mockAPIKeyRepository) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	key, exists := r.keys[id]
+	if !exists {
+		return repository.ErrNotFound
+	}
+	key.LastUsedAt = &at
+	return nil
+}
+
+func setupAuthRouter(keys usecase.APIKeyUsecase) *gin.Engine {
+	router := gin.New()
+	router.Use(httphandler.AuthMiddleware(keys))
+	router.GET("/whoami", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"id": httphandler.Principal(c).ID})
+	})
+	return router
+}
+
+func newTestAPIKeyUsecase(repo *mockAPIKeyRepository) usecase.APIKeyUsecase {
+	users := newMockUserRepository()
+	users.users[testOwnerID] = &entity.User{ID: testOwnerID, Name: "Owner"}
+	return usecase.NewAPIKeyUsecase(repo, users)
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	ctx := context.Background()
+	uc := newTestAPIKeyUsecase(newMockAPIKeyRepository())
+
+	key, err := uc.CreateKey(ctx, testOwnerID, "partner")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, key.Key)
+	assert.NotEqual(t, key.Key, key.KeyHash)
+	assert.Contains(t, key.Key, key.Prefix)
+	assert.Equal(t, testOwnerID, key.UserID)
+
+	_, err = uc.CreateKey(ctx, testOwnerID, "  ")
+	assert.ErrorIs(t, err, usecase.ErrAPIKeyName)
+
+	_, err = uc.CreateKey(ctx, "nobody", "partner")
+	assert.ErrorIs(t, err, usecase.ErrUserNotFound)
+}
+
+func TestAuthMiddlewareAcceptsValidKey(t *testing.T) {
+	repo := newMockAPIKeyRepository()
+	uc := newTestAPIKeyUsecase(repo)
+	key, _ := uc.CreateKey(context.Background(), testOwnerID, "partner")
+	router := setupAuthRouter(uc)
+
+	req, _ := http.NewRequest("GET", "/whoami", nil)
+	req.Header.Set("Authorization", "Bearer "+key.Key)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), key.ID)
+	assert.NotNil(t, repo.keys[key.ID].LastUsedAt)
+}
+
+func TestAuthMiddlewareRejectsInvalidKeys(t *testing.T) {
+	uc := newTestAPIKeyUsecase(newMockAPIKeyRepository())
+	revoked, _ := uc.CreateKey(context.Background(), testOwnerID, "revoked")
+	_ = uc.RevokeKey(context.Background(), revoked.ID)
+	router := setupAuthRouter(uc)
+
+	for _, header := range []string{"", "Bearer test", "Bearer lib_unknown", "Bearer " + revoked.Key} {
+		req, _ := http.NewRequest("GET", "/whoami", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, header)
+	}
+}
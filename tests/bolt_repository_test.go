@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openBoltRepositories returns repositories backed by a fresh bbolt file.
+func openBoltRepositories(t *testing.T) *repository.Repositories {
+	t.Helper()
+	db, err := repository.OpenBolt(filepath.Join(t.TempDir(), "test.bolt"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return repository.NewBoltRepositories(db)
+}
+
+func TestBoltRepositoriesPersistAcrossRestarts(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.bolt")
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	db, err := repository.OpenBolt(path)
+	require.NoError(t, err)
+	repos := repository.NewBoltRepositories(db)
+	link, err := repos.Links.Create(ctx, &entity.Link{OwnerID: testOwnerID, Slug: "launch", URL: "http://example.com", Tags: []string{"promo"}, CreatedAt: now})
+	require.NoError(t, err)
+	require.NoError(t, repos.Links.IncrementClicks(ctx, link.ID))
+	_, err = repos.Visits.Create(ctx, &entity.Visit{LinkID: link.ID, VisitedAt: now, Browser: "Firefox"})
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db, err = repository.OpenBolt(path)
+	require.NoError(t, err)
+	defer db.Close()
+	repos = repository.NewBoltRepositories(db)
+	found, err := repos.Links.GetBySlug(ctx, "launch")
+	require.NoError(t, err)
+	assert.Equal(t, link.ID, found.ID)
+	assert.Equal(t, 1, found.Clicks)
+	assert.Equal(t, []string{"promo"}, found.Tags)
+	assert.True(t, now.Equal(found.CreatedAt))
+	count, err := repos.Visits.Count(ctx, repository.VisitQuery{LinkIDs: []string{link.ID}, From: now, To: now.Add(time.Second)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestBoltLinkRepositoryArchivesThroughExpiryIndex(t *testing.T) {
+	ctx := context.Background()
+	repo := openBoltRepositories(t).Links
+	now := time.Now().UTC()
+	soon, later := now.Add(time.Hour), now.Add(48*time.Hour)
+
+	expiring, err := repo.Create(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.com", ExpiresAt: &soon})
+	require.NoError(t, err)
+	extended, err := repo.Create(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.org", ExpiresAt: &soon})
+	require.NoError(t, err)
+	_, err = repo.Create(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.net"})
+	require.NoError(t, err)
+
+	// Extending an expiry moves the link in the index.
+	extended.ExpiresAt = &later
+	_, err = repo.Update(ctx, extended)
+	require.NoError(t, err)
+
+	archived, err := repo.ArchiveExpired(ctx, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, []string{expiring.ID}, ids(archived))
+	assert.NotNil(t, archived[0].ArchivedAt)
+
+	archived, err = repo.ArchiveExpired(ctx, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, archived, "archived links leave the expiry index")
+
+	listed, err := repo.ListArchived(ctx, testOwnerID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{expiring.ID}, ids(listed))
+	purged, err := repo.PurgeArchived(ctx, now.Add(3*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []string{expiring.ID}, purged)
+}
+
+func TestBoltVisitRepositoryMatchesMemory(t *testing.T) {
+	checkVisitsMatchMemory(t, openBoltRepositories(t).Visits)
+}
+
+func TestBoltRepositoriesClaimOnceUnderConcurrency(t *testing.T) {
+	checkClaimsOnce(t, openBoltRepositories(t))
+}
+
+func TestBoltRepositoriesServeStats(t *testing.T) {
+	checkServesStats(t, openBoltRepositories(t))
+}
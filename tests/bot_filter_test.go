@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitLinkFlagsBots(t *testing.T) {
+	cases := []struct {
+		req  usecase.VisitRequest
+		name string
+	}{
+		{usecase.VisitRequest{UserAgent: "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)"}, "Slack"},
+		{usecase.VisitRequest{UserAgent: "Twitterbot/1.0"}, "Twitter"},
+		{usecase.VisitRequest{UserAgent: "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)"}, "Facebook"},
+		{usecase.VisitRequest{UserAgent: "Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)"}, "Discord"},
+		{usecase.VisitRequest{UserAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", Accept: htmlAccept}, "Googlebot"},
+		{usecase.VisitRequest{UserAgent: "Mozilla/5.0+(compatible; UptimeRobot/2.0; http://www.uptimerobot.com/)"}, "UptimeRobot"},
+		{usecase.VisitRequest{UserAgent: "kube-probe/1.29"}, "Kubernetes probe"},
+		{usecase.VisitRequest{UserAgent: "curl/8.4.0", Accept: "*/*"}, "curl"},
+		{usecase.VisitRequest{UserAgent: "Mozilla/5.0 (compatible; AcmeLinkChecker/3.1; +https://acme.test/crawler)"}, "self-declared bot"},
+		{usecase.VisitRequest{}, "no user agent"},
+		{usecase.VisitRequest{UserAgent: windowsEdgeUA, Accept: htmlAccept, Method: http.MethodHead}, "HEAD request"},
+		{usecase.VisitRequest{UserAgent: windowsEdgeUA, Accept: htmlAccept, Purpose: "prefetch;prerender"}, "prefetch"},
+		{usecase.VisitRequest{UserAgent: windowsEdgeUA}, "missing browser headers"},
+	}
+	ctx := context.Background()
+	for _, tc := range cases {
+		linkRepo := newMockLinkRepository()
+		visitRepo := newMockVisitRepository()
+		uc := usecase.NewLinkUsecase(linkRepo, visitRepo)
+		link, _ := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.com"})
+
+		visited, err := uc.VisitLink(ctx, link.ID, tc.req)
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, 0, visited.Clicks, tc.name)
+		if assert.Len(t, visitRepo.visits, 1, tc.name) {
+			assert.True(t, visitRepo.visits[0].Bot, tc.name)
+			assert.Equal(t, tc.name, visitRepo.visits[0].BotName)
+		}
+	}
+}
+
+func TestVisitLinkCountsBrowsers(t *testing.T) {
+	ctx := context.Background()
+	humans := []usecase.VisitRequest{
+		browserVisit,
+		{UserAgent: instagramUA, Accept: htmlAccept, Method: http.MethodGet},
+		{UserAgent: macFirefoxUA, AcceptLanguage: "fr-FR"},
+		{UserAgent: "Mozilla/5.0 (Linux; Android 9; CUBOT_X19) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36", Accept: htmlAccept},
+	}
+	for _, req := range humans {
+		visitRepo := newMockVisitRepository()
+		uc := usecase.NewLinkUsecase(newMockLinkRepository(), visitRepo)
+		link, _ := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.com"})
+
+		visited, err := uc.VisitLink(ctx, link.ID, req)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, visited.Clicks, req.UserAgent)
+		if assert.Len(t, visitRepo.visits, 1) {
+			assert.False(t, visitRepo.visits[0].Bot, req.UserAgent)
+			assert.Empty(t, visitRepo.visits[0].BotName)
+		}
+	}
+}
+
+func TestBotsAreLeftOutOfAnalytics(t *testing.T) {
+	ctx := context.Background()
+	f := newStatsFixture()
+	links := usecase.NewLinkUsecase(f.links, f.visits, usecase.WithVisitorSalt([]byte("salt")), usecase.WithVisitorSketches(f.sketches))
+	link, _ := links.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.com"})
+
+	_, _ = links.VisitLink(ctx, link.ID, usecase.VisitRequest{IP: "198.51.100.1", UserAgent: iPhoneSafariUA, Accept: htmlAccept})
+	_, _ = links.VisitLink(ctx, link.ID, usecase.VisitRequest{IP: "198.51.100.2", UserAgent: "Slackbot-LinkExpanding 1.0"})
+	_, _ = links.VisitLink(ctx, link.ID, usecase.VisitRequest{IP: "198.51.100.3", UserAgent: "Twitterbot/1.0"})
+
+	stats, err := f.uc.LinkStats(ctx, testOwnerID, link.ID, usecase.StatsQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Total)
+	assert.Equal(t, int64(2), stats.BotHits)
+
+	breakdown, err := f.uc.LinkBreakdown(ctx, testOwnerID, link.ID, usecase.BreakdownQuery{Dimension: "browser"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), breakdown.Total)
+	if assert.Len(t, breakdown.Items, 1) {
+		assert.Equal(t, "Safari", breakdown.Items[0].Value)
+	}
+
+	visitors, err := f.uc.LinkVisitors(ctx, testOwnerID, link.ID, usecase.StatsQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), visitors.Clicks)
+	assert.Equal(t, int64(1), visitors.Visitors)
+}
+
+func TestRedirectEndpointDoesNotCountUnfurlers(t *testing.T) {
+	router, linkRepo, visitRepo := setupRedirectRouter(http.StatusFound)
+	link, _ := linkRepo.Create(context.Background(), &entity.Link{OwnerID: testOwnerID, URL: "http://example.com"})
+
+	req, _ := http.NewRequest("GET", "/r/"+link.ID, nil)
+	req.Header.Set("User-Agent", "facebookexternalhit/1.1")
+	req.Header.Set("Accept", "*/*")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Unfurlers still get the redirect so previews keep working.
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "http://example.com", w.Header().Get("Location"))
+	assert.Equal(t, 0, link.Clicks)
+	if assert.Len(t, visitRepo.visits, 1) {
+		assert.True(t, visitRepo.visits[0].Bot)
+		assert.Equal(t, "Facebook", visitRepo.visits[0].BotName)
+	}
+}
+
+func TestRedirectEndpointAnswersHeadAsBot(t *testing.T) {
+	router, linkRepo, visitRepo := setupRedirectRouter(http.StatusFound)
+	link, _ := linkRepo.Create(context.Background(), &entity.Link{OwnerID: testOwnerID, URL: "http://example.com"})
+
+	req, _ := http.NewRequest("HEAD", "/r/"+link.ID, nil)
+	setBrowserHeaders(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "http://example.com", w.Header().Get("Location"))
+	stored, err := linkRepo.GetByID(context.Background(), link.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, stored.Clicks)
+	if assert.Len(t, visitRepo.visits, 1) {
+		assert.True(t, visitRepo.visits[0].Bot)
+		assert.Equal(t, "HEAD request", visitRepo.visits[0].BotName)
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisitLinkDedupsRapidRepeats(t *testing.T) {
+	ctx := context.Background()
+	f := newStatsFixture()
+	windows := newMockClickWindowRepository()
+	uc := usecase.NewLinkUsecase(f.links, f.visits,
+		usecase.WithVisitorSalt([]byte("salt")),
+		usecase.WithVisitorSketches(f.sketches),
+		usecase.WithClickDedup(windows, 30*time.Second))
+	link, _ := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.com"})
+
+	hammer := usecase.VisitRequest{IP: "198.51.100.7", UserAgent: windowsEdgeUA, Accept: htmlAccept}
+	for i := 0; i < 5; i++ {
+		_, err := uc.VisitLink(ctx, link.ID, hammer)
+		assert.NoError(t, err)
+	}
+	visited, _ := uc.VisitLink(ctx, link.ID, usecase.VisitRequest{IP: "198.51.100.8", UserAgent: windowsEdgeUA, Accept: htmlAccept})
+	assert.Equal(t, 2, visited.Clicks)
+
+	// Once the window has passed, the same visitor counts again.
+	for key, counted := range windows.counted {
+		windows.counted[key] = counted.Add(-31 * time.Second)
+	}
+	visited, _ = uc.VisitLink(ctx, link.ID, hammer)
+	assert.Equal(t, 3, visited.Clicks)
+
+	repeats := 0
+	for _, visit := range f.visits.visits {
+		if visit.Repeat {
+			repeats++
+		}
+	}
+	assert.Len(t, f.visits.visits, 7)
+	assert.Equal(t, 4, repeats)
+
+	stats, err := f.uc.LinkStats(ctx, testOwnerID, link.ID, usecase.StatsQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), stats.Total)
+}
+
+func TestVisitLinkWithoutDedupCountsEveryClick(t *testing.T) {
+	ctx := context.Background()
+	uc := usecase.NewLinkUsecase(newMockLinkRepository(), newMockVisitRepository(),
+		usecase.WithClickDedup(newMockClickWindowRepository(), 0))
+	link, _ := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.com"})
+
+	req := usecase.VisitRequest{IP: "198.51.100.7", UserAgent: windowsEdgeUA, Accept: htmlAccept}
+	_, _ = uc.VisitLink(ctx, link.ID, req)
+	visited, _ := uc.VisitLink(ctx, link.ID, req)
+	assert.Equal(t, 2, visited.Clicks)
+}
+
+func TestDetectClickAnomalies(t *testing.T) {
+	ctx := context.Background()
+	f := newStatsFixture()
+	fraud := f.link(testOwnerID, "fraud")
+	viral := f.link(testOwnerID, "viral")
+	busy := f.link(testOwnerID, "busy")
+
+	end := time.Now().Truncate(10 * time.Minute)
+	start := end.Add(-10 * time.Minute)
+	click := func(link *entity.Link, at time.Time, visitor string) {
+		f.visits.visits = append(f.visits.visits, &entity.Visit{LinkID: link.ID, VisitedAt: at, VisitorHash: visitor})
+	}
+	for i := 0; i < 30; i++ {
+		at := start.Add(time.Duration(i) * 10 * time.Second)
+		// Two visitors hammering one link, ...
+		click(fraud, at, fmt.Sprintf("hammer-%d", i%2))
+		// ... a link going viral with thirty different visitors, ...
+		click(viral, at, fmt.Sprintf("fan-%d", i))
+		// ... and a few regulars on a link that is always this busy.
+		click(busy, at, fmt.Sprintf("regular-%d", i%3))
+	}
+	for i := 0; i < 2000; i++ {
+		click(busy, start.Add(-time.Duration(i)*40*time.Second-time.Second), fmt.Sprintf("visitor-%d", i))
+	}
+	// Bot hits and repeats are not clicks and cannot cause a spike.
+	for i := 0; i < 50; i++ {
+		f.visits.visits = append(f.visits.visits,
+			&entity.Visit{LinkID: viral.ID, VisitedAt: start, VisitorHash: "bot", Bot: true},
+			&entity.Visit{LinkID: viral.ID, VisitedAt: start, VisitorHash: "fan-0", Repeat: true})
+	}
+
+	assert.NoError(t, f.uc.DetectClickAnomalies(ctx))
+	assert.NoError(t, f.uc.DetectClickAnomalies(ctx))
+
+	if assert.Len(t, f.anomalies.anomalies, 1) {
+		anomaly := f.anomalies.anomalies[0]
+		assert.Equal(t, fraud.ID, anomaly.LinkID)
+		assert.Equal(t, start, anomaly.WindowStart)
+		assert.Equal(t, end, anomaly.WindowEnd)
+		assert.Equal(t, int64(30), anomaly.Clicks)
+		assert.Equal(t, int64(2), anomaly.Sources)
+		assert.Equal(t, int64(15), anomaly.TopSourceClicks)
+		assert.Zero(t, anomaly.Baseline)
+	}
+
+	stats, err := f.uc.LinkStats(ctx, testOwnerID, fraud.ID, usecase.StatsQuery{Interval: "hour"})
+	assert.NoError(t, err)
+	assert.Len(t, stats.Anomalies, 1)
+	stats, err = f.uc.LinkStats(ctx, testOwnerID, viral.ID, usecase.StatsQuery{Interval: "hour"})
+	assert.NoError(t, err)
+	assert.Empty(t, stats.Anomalies)
+	assert.NotNil(t, stats.Anomalies)
+}
@@ -18,34 +18,50 @@ import (
 
 // Reuse the same mock repositories from link_usecase_test.go
 
+// testAPIKey is minted by setupRouter for the user identified by testOwnerID.
+var testAPIKey string
+
 func setupRouter() (*gin.Engine, usecase.LinkUsecase) {
 	linkRepo := newMockLinkRepository()
 	visitRepo := newMockVisitRepository()
 	uc := usecase.NewLinkUsecase(linkRepo, visitRepo)
 	handler := httphandler.NewLinkHandler(uc)
 
+	userRepo := newMockUserRepository()
+	userRepo.users[testOwnerID] = &entity.User{ID: testOwnerID, Name: "Owner"}
+	keys := usecase.NewAPIKeyUsecase(newMockAPIKeyRepository(), userRepo)
+	key, _ := keys.CreateKey(context.Background(), testOwnerID, "test")
+	testAPIKey = key.Key
+
 	router := gin.Default()
-	// Register routes (without auth for simplicity)
-	router.POST("/links", handler.CreateLink)
-	router.GET("/links/:id", handler.GetLink)
-	router.PUT("/links/:id", handler.UpdateLink)
-	router.DELETE("/links/:id", handler.DeleteLink)
-	router.GET("/visit/:id", handler.VisitLink)
+	api := router.Group("/", httphandler.AuthMiddleware(keys))
+	handler.RegisterAPIRoutes(api)
 	return router, uc
 }
 
+func newAuthorizedRequest(method, path string, body *bytes.Buffer) *http.Request {
+	var req *http.Request
+	if body == nil {
+		req, _ = http.NewRequest(method, path, nil)
+	} else {
+		req, _ = http.NewRequest(method, path, body)
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+testAPIKey)
+	return req
+}
+
 func TestCreateLinkEndpoint(t *testing.T) {
 	router, _ := setupRouter()
 
 	newLink := entity.Link{
 		Title:     "Test Link",
 		URL:       "http://example.com",
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+		ExpiresAt: expiresIn(24 * time.Hour),
 	}
 	body, _ := json.Marshal(newLink)
 
-	req, _ := http.NewRequest("POST", "/links", bytes.NewBuffer(body))
-	req.Header.Set("Content-Type", "application/json")
+	req := newAuthorizedRequest("POST", "/links", bytes.NewBuffer(body))
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -57,6 +73,7 @@ func TestCreateLinkEndpoint(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEmpty(t, created.ID)
 	assert.Equal(t, newLink.Title, created.Title)
+	assert.Equal(t, testOwnerID, created.OwnerID)
 }
 
 func TestGetLinkEndpoint(t *testing.T) {
@@ -65,13 +82,14 @@ func TestGetLinkEndpoint(t *testing.T) {
 
 	// Create a link directly via the usecase
 	link := &entity.Link{
+		OwnerID:   testOwnerID,
 		Title:     "Test Link",
 		URL:       "http://example.com",
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+		ExpiresAt: expiresIn(24 * time.Hour),
 	}
 	createdLink, _ := uc.CreateLink(ctx, link)
 
-	req, _ := http.NewRequest("GET", "/links/"+createdLink.ID, nil)
+	req := newAuthorizedRequest("GET", "/links/"+createdLink.ID, nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
@@ -88,9 +106,10 @@ func TestUpdateLinkEndpoint(t *testing.T) {
 
 	// Create a link
 	link := &entity.Link{
+		OwnerID:   testOwnerID,
 		Title:     "Original Title",
 		URL:       "http://example.com",
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+		ExpiresAt: expiresIn(24 * time.Hour),
 	}
 	createdLink, _ := uc.CreateLink(ctx, link)
 
@@ -98,11 +117,10 @@ func TestUpdateLinkEndpoint(t *testing.T) {
 	updatedData := entity.Link{
 		Title:     "Updated Title",
 		URL:       "http://updated.com",
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+		ExpiresAt: expiresIn(24 * time.Hour),
 	}
 	body, _ := json.Marshal(updatedData)
-	req, _ := http.NewRequest("PUT", "/links/"+createdLink.ID, bytes.NewBuffer(body))
-	req.Header.Set("Content-Type", "application/json")
+	req := newAuthorizedRequest("PUT", "/links/"+createdLink.ID, bytes.NewBuffer(body))
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -121,19 +139,20 @@ func TestDeleteLinkEndpoint(t *testing.T) {
 
 	// Create a link
 	link := &entity.Link{
+		OwnerID:   testOwnerID,
 		Title:     "Test Link",
 		URL:       "http://example.com",
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+		ExpiresAt: expiresIn(24 * time.Hour),
 	}
 	createdLink, _ := uc.CreateLink(ctx, link)
 
-	req, _ := http.NewRequest("DELETE", "/links/"+createdLink.ID, nil)
+	req := newAuthorizedRequest("DELETE", "/links/"+createdLink.ID, nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusOK, w.Code)
 
 	// Try fetching the deleted link
-	req, _ = http.NewRequest("GET", "/links/"+createdLink.ID, nil)
+	req = newAuthorizedRequest("GET", "/links/"+createdLink.ID, nil)
 	w = httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusNotFound, w.Code)
@@ -145,13 +164,15 @@ func TestVisitLinkEndpoint(t *testing.T) {
 
 	// Create a link
 	link := &entity.Link{
+		OwnerID:   testOwnerID,
 		Title:     "Test Link",
 		URL:       "http://example.com",
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+		ExpiresAt: expiresIn(24 * time.Hour),
 	}
 	createdLink, _ := uc.CreateLink(ctx, link)
 
-	req, _ := http.NewRequest("GET", "/visit/"+createdLink.ID, nil)
+	req := newAuthorizedRequest("GET", "/visit/"+createdLink.ID, nil)
+	setBrowserHeaders(req)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusOK, w.Code)
@@ -161,3 +182,153 @@ func TestVisitLinkEndpoint(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 1, visited.Clicks)
 }
+
+func TestGetLinkEndpointHidesOtherOwnersLinks(t *testing.T) {
+	router, uc := setupRouter()
+	ctx := context.Background()
+
+	link := &entity.Link{
+		OwnerID:   "someone-else",
+		Title:     "Not Yours",
+		URL:       "http://example.com",
+		ExpiresAt: expiresIn(24 * time.Hour),
+	}
+	createdLink, _ := uc.CreateLink(ctx, link)
+
+	for _, method := range []string{"GET", "DELETE"} {
+		req := newAuthorizedRequest(method, "/links/"+createdLink.ID, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code, method)
+	}
+}
+
+func TestUpdateLinkEndpointKeepsClicks(t *testing.T) {
+	router, uc := setupRouter()
+	ctx := context.Background()
+
+	createdLink, _ := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, Title: "Popular", URL: "http://example.com"})
+	_, _ = uc.VisitLink(ctx, createdLink.ID, browserVisit)
+	_, _ = uc.VisitLink(ctx, createdLink.ID, browserVisit)
+
+	body, _ := json.Marshal(entity.Link{Title: "Renamed", URL: "http://example.com"})
+	req := newAuthorizedRequest("PUT", "/links/"+createdLink.ID, bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var updated entity.Link
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, 2, updated.Clicks)
+	assert.Equal(t, createdLink.CreatedAt.Unix(), updated.CreatedAt.Unix())
+}
+
+func TestCreateLinkEndpointIgnoresArchivedAt(t *testing.T) {
+	router, uc := setupRouter()
+	ctx := context.Background()
+
+	longAgo := time.Now().Add(-365 * 24 * time.Hour)
+	body, _ := json.Marshal(entity.Link{Title: "Fresh", URL: "http://example.com", ArchivedAt: &longAgo})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newAuthorizedRequest("POST", "/links", bytes.NewBuffer(body)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var created entity.Link
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Nil(t, created.ArchivedAt)
+	assert.Equal(t, entity.LinkActive, created.Status)
+	archived, err := uc.ListArchivedLinks(ctx, testOwnerID)
+	assert.NoError(t, err)
+	assert.Empty(t, archived)
+}
+
+func TestLinkEndpointsIgnoreDeletedAt(t *testing.T) {
+	router, uc := setupRouter()
+	ctx := context.Background()
+
+	// Links only reach the trash through DELETE, however the body is dressed.
+	longAgo := time.Now().Add(-365 * 24 * time.Hour)
+	body, _ := json.Marshal(entity.Link{Title: "Fresh", URL: "http://example.com", DeletedAt: &longAgo})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newAuthorizedRequest("POST", "/links", bytes.NewBuffer(body)))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created entity.Link
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Nil(t, created.DeletedAt)
+
+	body, _ = json.Marshal(entity.Link{Title: "Renamed", URL: "http://example.com", DeletedAt: &longAgo})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, newAuthorizedRequest("PUT", "/links/"+created.ID, bytes.NewBuffer(body)))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var updated entity.Link
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Nil(t, updated.DeletedAt)
+	assert.Equal(t, entity.LinkActive, updated.Status)
+
+	assert.NoError(t, uc.PurgeDeletedLinks(ctx))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, newAuthorizedRequest("GET", "/links/"+created.ID, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	trashed, err := uc.ListDeletedLinks(ctx, testOwnerID)
+	assert.NoError(t, err)
+	assert.Empty(t, trashed)
+}
+
+func TestTrashEndpoints(t *testing.T) {
+	router, uc := setupRouter()
+	ctx := context.Background()
+
+	createdLink, _ := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, Title: "Oops", URL: "http://example.com"})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newAuthorizedRequest("DELETE", "/links/"+createdLink.ID, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, newAuthorizedRequest("GET", "/links/trash", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var trashed []entity.Link
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &trashed))
+	if assert.Len(t, trashed, 1) {
+		assert.Equal(t, createdLink.ID, trashed[0].ID)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, newAuthorizedRequest("POST", "/links/"+createdLink.ID+"/restore", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, newAuthorizedRequest("GET", "/links/"+createdLink.ID, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestListLinksEndpoint(t *testing.T) {
+	router, uc := setupRouter()
+	ctx := context.Background()
+
+	for _, title := range []string{"First", "Second", "Third"} {
+		_, _ = uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, Title: title, URL: "http://example.com", Tags: []string{"bio"}})
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newAuthorizedRequest("GET", "/links?tag=bio&status=active,scheduled&limit=2", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	var page entity.LinkPage
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Len(t, page.Links, 2)
+	assert.NotEmpty(t, page.NextCursor)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, newAuthorizedRequest("GET", "/links?limit=2&cursor="+page.NextCursor, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	page = entity.LinkPage{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Len(t, page.Links, 1)
+	assert.Empty(t, page.NextCursor)
+
+	for _, query := range []string{"limit=ten", "createdFrom=yesterday", "sort=title"} {
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, newAuthorizedRequest("GET", "/links?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
@@ -4,15 +4,23 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
 	"github.com/hussainr95/link-in-bio-service/internal/usecase"
 	"github.com/stretchr/testify/assert"
 )
 
+// mockLinkRepository is a minimal LinkRepository for usecase tests. It passes
+// the repotest conformance suite, so it fails the way the real repositories
+// do.
 type mockLinkRepository struct {
+	mu     sync.Mutex
 	links  map[string]*entity.Link
 	nextID int
 }
@@ -25,71 +33,519 @@ func newMockLinkRepository() *mockLinkRepository {
 }
 
 func (r *mockLinkRepository) Create(ctx context.Context, link *entity.Link) (*entity.Link, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.slugTaken(link.Slug, "") {
+		return nil, repository.ErrDuplicate
+	}
 	id := fmt.Sprintf("%d", r.nextID)
 	r.nextID++
 	link.ID = id
-	r.links[id] = link
+	r.links[id] = copyLink(link)
 	return link, nil
 }
 
 func (r *mockLinkRepository) GetByID(ctx context.Context, id string) (*entity.Link, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	link, exists := r.links[id]
 	if !exists {
-		return nil, errors.New("link not found")
+		return nil, repository.ErrNotFound
 	}
-	return link, nil
+	return copyLink(link), nil
+}
+
+func (r *mockLinkRepository) GetBySlug(ctx context.Context, slug string) (*entity.Link, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, link := range r.links {
+		if slug != "" && link.Slug == slug {
+			return copyLink(link), nil
+		}
+	}
+	return nil, repository.ErrNotFound
 }
 
+func (r *mockLinkRepository) slugTaken(slug, exceptID string) bool {
+	if slug == "" {
+		return false
+	}
+	for id, link := range r.links {
+		if id != exceptID && link.Slug == slug {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *mockLinkRepository) GetByIDs(ctx context.Context, ids []string) ([]*entity.Link, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	links := make([]*entity.Link, 0, len(ids))
+	for i, id := range ids {
+		if link, exists := r.links[id]; exists && !slices.Contains(ids[:i], id) {
+			links = append(links, copyLink(link))
+		}
+	}
+	return links, nil
+}
+
+// Update saves the fields a client may change, like the real repositories.
 func (r *mockLinkRepository) Update(ctx context.Context, link *entity.Link) (*entity.Link, error) {
-	if _, exists := r.links[link.ID]; !exists {
-		return nil, errors.New("link not found")
+	if link.ID == "" {
+		return nil, errors.New("missing link ID")
 	}
-	r.links[link.ID] = link
-	return link, nil
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, exists := r.links[link.ID]
+	if !exists {
+		return nil, repository.ErrNotFound
+	}
+	if r.slugTaken(link.Slug, link.ID) {
+		return nil, repository.ErrDuplicate
+	}
+	updated := copyLink(link)
+	updated.OwnerID = stored.OwnerID
+	updated.CreatedAt = stored.CreatedAt
+	updated.Clicks = stored.Clicks
+	r.links[link.ID] = updated
+	return copyLink(updated), nil
 }
 
 func (r *mockLinkRepository) Delete(ctx context.Context, id string) error {
-	if _, exists := r.links[id]; !exists {
-		return errors.New("link not found")
-	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	delete(r.links, id)
 	return nil
 }
 
 func (r *mockLinkRepository) IncrementClicks(ctx context.Context, id string) error {
-	link, exists := r.links[id]
-	if !exists {
-		return errors.New("link not found")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if link, exists := r.links[id]; exists {
+		link.Clicks++
 	}
-	link.Clicks++
 	return nil
 }
 
-func (r *mockLinkRepository) DeleteExpired(ctx context.Context) error {
-	now := time.Now()
+func (r *mockLinkRepository) List(ctx context.Context, filter repository.LinkFilter) ([]*entity.Link, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	links := make([]*entity.Link, 0, len(r.links))
+	for _, link := range r.links {
+		links = append(links, copyLink(link))
+	}
+	return repository.ApplyLinkFilter(links, filter), nil
+}
+
+func (r *mockLinkRepository) ListArchived(ctx context.Context, ownerID string) ([]*entity.Link, error) {
+	return r.listOwned(ownerID, func(link *entity.Link) *time.Time { return link.ArchivedAt }), nil
+}
+
+func (r *mockLinkRepository) ListDeleted(ctx context.Context, ownerID string) ([]*entity.Link, error) {
+	return r.listOwned(ownerID, func(link *entity.Link) *time.Time { return link.DeletedAt }), nil
+}
+
+func (r *mockLinkRepository) listOwned(ownerID string, at func(*entity.Link) *time.Time) []*entity.Link {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	links := make([]*entity.Link, 0)
+	for _, link := range r.links {
+		if link.OwnerID == ownerID && at(link) != nil {
+			links = append(links, copyLink(link))
+		}
+	}
+	sort.Slice(links, func(i, j int) bool { return at(links[i]).After(*at(links[j])) })
+	return links
+}
+
+func (r *mockLinkRepository) ArchiveExpired(ctx context.Context, now time.Time) ([]*entity.Link, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	archived := make([]*entity.Link, 0)
+	for _, link := range r.links {
+		if link.ArchivedAt == nil && link.DeletedAt == nil && link.ExpiresAt != nil && link.ExpiresAt.Before(now) {
+			at := now
+			link.ArchivedAt = &at
+			archived = append(archived, copyLink(link))
+		}
+	}
+	return archived, nil
+}
+
+func (r *mockLinkRepository) PurgeArchived(ctx context.Context, before time.Time) ([]string, error) {
+	return r.purge(func(link *entity.Link) bool { return link.ArchivedAt != nil && link.ArchivedAt.Before(before) }), nil
+}
+
+func (r *mockLinkRepository) PurgeDeleted(ctx context.Context, before time.Time) ([]string, error) {
+	return r.purge(func(link *entity.Link) bool { return link.DeletedAt != nil && link.DeletedAt.Before(before) }), nil
+}
+
+func (r *mockLinkRepository) purge(matching func(*entity.Link) bool) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var purged []string
 	for id, link := range r.links {
-		if link.ExpiresAt.Before(now) {
+		if matching(link) {
 			delete(r.links, id)
+			purged = append(purged, id)
 		}
 	}
-	return nil
+	return purged
+}
+
+// saveLink writes changes a test made to link back to repo.
+func saveLink(t *testing.T, repo repository.LinkRepository, link *entity.Link) {
+	t.Helper()
+	_, err := repo.Update(context.Background(), link)
+	assert.NoError(t, err)
+}
+
+// copyLink copies link so that callers never share the stored value.
+func copyLink(link *entity.Link) *entity.Link {
+	c := *link
+	for _, t := range []**time.Time{&c.ActiveFrom, &c.ExpiresAt, &c.ArchivedAt, &c.DeletedAt} {
+		if *t != nil {
+			copied := **t
+			*t = &copied
+		}
+	}
+	c.Tags = slices.Clone(link.Tags)
+	return &c
 }
 
 type mockVisitRepository struct {
+	mu     sync.Mutex
 	visits []*entity.Visit
+	nextID int
 }
 
 func newMockVisitRepository() *mockVisitRepository {
 	return &mockVisitRepository{
 		visits: make([]*entity.Visit, 0),
+		nextID: 1,
 	}
 }
 
 func (r *mockVisitRepository) Create(ctx context.Context, visit *entity.Visit) (*entity.Visit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	visit.ID = fmt.Sprintf("%d", r.nextID)
+	r.nextID++
 	r.visits = append(r.visits, visit)
 	return visit, nil
 }
 
+func (r *mockVisitRepository) DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	remove := make(map[string]bool, len(linkIDs))
+	for _, id := range linkIDs {
+		remove[id] = true
+	}
+	kept := r.visits[:0]
+	for _, visit := range r.visits {
+		if !remove[visit.LinkID] {
+			kept = append(kept, visit)
+		}
+	}
+	deleted := int64(len(r.visits) - len(kept))
+	r.visits = kept
+	return deleted, nil
+}
+
+func (r *mockVisitRepository) CountByInterval(ctx context.Context, q repository.VisitQuery, interval entity.StatsInterval, loc *time.Location) ([]*entity.StatsBucket, error) {
+	counts := map[time.Time]int64{}
+	for _, visit := range r.matching(q) {
+		counts[repository.BucketStart(visit.VisitedAt, interval, loc)]++
+	}
+	buckets := make([]*entity.StatsBucket, 0, len(counts))
+	for start, clicks := range counts {
+		buckets = append(buckets, &entity.StatsBucket{Start: start, Clicks: clicks})
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Start.Before(buckets[j].Start) })
+	return buckets, nil
+}
+
+func (r *mockVisitRepository) CountByLink(ctx context.Context, q repository.VisitQuery) (map[string]int64, error) {
+	counts := map[string]int64{}
+	for _, visit := range r.matching(q) {
+		counts[visit.LinkID]++
+	}
+	return counts, nil
+}
+
+func (r *mockVisitRepository) Count(ctx context.Context, q repository.VisitQuery) (int64, error) {
+	return int64(len(r.matching(q))), nil
+}
+
+func (r *mockVisitRepository) TopValues(ctx context.Context, q repository.VisitQuery, dimension entity.BreakdownDimension, limit int) ([]*entity.BreakdownItem, error) {
+	counts := map[string]int64{}
+	for _, visit := range r.matching(q) {
+		counts[repository.VisitDimension(visit, dimension)]++
+	}
+	items := make([]*entity.BreakdownItem, 0, len(counts))
+	for value, clicks := range counts {
+		items = append(items, &entity.BreakdownItem{Value: value, Clicks: clicks})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Clicks != items[j].Clicks {
+			return items[i].Clicks > items[j].Clicks
+		}
+		return items[i].Value < items[j].Value
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+func (r *mockVisitRepository) SourceCounts(ctx context.Context, from, to time.Time, minClicks int64) ([]*repository.LinkSources, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	perVisitor := map[string]map[string]int64{}
+	for _, visit := range r.visits {
+		if visit.Bot || visit.Repeat || visit.VisitedAt.Before(from) || !visit.VisitedAt.Before(to) {
+			continue
+		}
+		if perVisitor[visit.LinkID] == nil {
+			perVisitor[visit.LinkID] = map[string]int64{}
+		}
+		perVisitor[visit.LinkID][visit.VisitorHash]++
+	}
+	var sources []*repository.LinkSources
+	for linkID, visitors := range perVisitor {
+		s := &repository.LinkSources{LinkID: linkID, Sources: int64(len(visitors))}
+		for _, clicks := range visitors {
+			s.Clicks += clicks
+			s.TopSourceClicks = max(s.TopSourceClicks, clicks)
+		}
+		if s.Clicks >= minClicks {
+			sources = append(sources, s)
+		}
+	}
+	return sources, nil
+}
+
+func (r *mockVisitRepository) Each(ctx context.Context, q repository.VisitQuery, fn func(*entity.Visit) error) error {
+	visits := r.matching(q)
+	sort.SliceStable(visits, func(i, j int) bool { return visits[i].VisitedAt.Before(visits[j].VisitedAt) })
+	for _, visit := range visits {
+		copied := *visit
+		if err := fn(&copied); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *mockVisitRepository) Rollup(ctx context.Context, from, to time.Time) ([]*entity.VisitRollup, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	builder := repository.NewRollupBuilder(entity.IntervalHour)
+	for _, visit := range r.visits {
+		if !visit.VisitedAt.Before(from) && visit.VisitedAt.Before(to) {
+			builder.AddVisit(visit, 1)
+		}
+	}
+	return builder.Rollups(), nil
+}
+
+func (r *mockVisitRepository) Oldest(ctx context.Context) (time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.visits) == 0 {
+		return time.Time{}, repository.ErrNotFound
+	}
+	oldest := r.visits[0].VisitedAt
+	for _, visit := range r.visits {
+		if visit.VisitedAt.Before(oldest) {
+			oldest = visit.VisitedAt
+		}
+	}
+	return oldest, nil
+}
+
+func (r *mockVisitRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.visits[:0]
+	for _, visit := range r.visits {
+		if !visit.VisitedAt.Before(before) {
+			kept = append(kept, visit)
+		}
+	}
+	deleted := int64(len(r.visits) - len(kept))
+	r.visits = kept
+	return deleted, nil
+}
+
+func (r *mockVisitRepository) matching(q repository.VisitQuery) []*entity.Visit {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matched []*entity.Visit
+	for _, visit := range r.visits {
+		if q.Matches(visit) {
+			matched = append(matched, visit)
+		}
+	}
+	return matched
+}
+
+type mockClickWindowRepository struct {
+	counted map[string]time.Time // last counted click by link ID and visitor
+}
+
+func newMockClickWindowRepository() *mockClickWindowRepository {
+	return &mockClickWindowRepository{counted: make(map[string]time.Time)}
+}
+
+func (r *mockClickWindowRepository) Claim(ctx context.Context, linkID, visitor string, now time.Time, window time.Duration) (bool, error) {
+	key := linkID + ":" + visitor
+	if last, ok := r.counted[key]; ok && now.Sub(last) < window {
+		return false, nil
+	}
+	r.counted[key] = now
+	return true, nil
+}
+
+type mockClickAnomalyRepository struct {
+	anomalies []*entity.ClickAnomaly
+}
+
+func newMockClickAnomalyRepository() *mockClickAnomalyRepository {
+	return &mockClickAnomalyRepository{anomalies: make([]*entity.ClickAnomaly, 0)}
+}
+
+func (r *mockClickAnomalyRepository) Create(ctx context.Context, anomaly *entity.ClickAnomaly) (*entity.ClickAnomaly, error) {
+	for _, existing := range r.anomalies {
+		if existing.LinkID == anomaly.LinkID && existing.WindowStart.Equal(anomaly.WindowStart) {
+			return nil, repository.ErrDuplicate
+		}
+	}
+	anomaly.ID = fmt.Sprintf("anomaly-%d", len(r.anomalies)+1)
+	r.anomalies = append(r.anomalies, anomaly)
+	return anomaly, nil
+}
+
+func (r *mockClickAnomalyRepository) List(ctx context.Context, linkIDs []string, from, to time.Time) ([]*entity.ClickAnomaly, error) {
+	anomalies := []*entity.ClickAnomaly{}
+	for _, anomaly := range r.anomalies {
+		for _, id := range linkIDs {
+			if anomaly.LinkID == id && !anomaly.WindowStart.Before(from) && anomaly.WindowStart.Before(to) {
+				anomalies = append(anomalies, anomaly)
+			}
+		}
+	}
+	return anomalies, nil
+}
+
+func (r *mockClickAnomalyRepository) DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error) {
+	kept := r.anomalies[:0]
+	for _, anomaly := range r.anomalies {
+		if !slices.Contains(linkIDs, anomaly.LinkID) {
+			kept = append(kept, anomaly)
+		}
+	}
+	deleted := int64(len(r.anomalies) - len(kept))
+	r.anomalies = kept
+	return deleted, nil
+}
+
+type mockVisitorSketchRepository struct {
+	sketches map[string]*entity.VisitorSketch // keyed by link ID and day
+}
+
+func newMockVisitorSketchRepository() *mockVisitorSketchRepository {
+	return &mockVisitorSketchRepository{sketches: make(map[string]*entity.VisitorSketch)}
+}
+
+func (r *mockVisitorSketchRepository) Raise(ctx context.Context, linkID string, day time.Time, index uint16, rank uint8) error {
+	key := linkID + "/" + day.Format(time.DateOnly)
+	sketch, exists := r.sketches[key]
+	if !exists {
+		sketch = &entity.VisitorSketch{LinkID: linkID, Day: day, Registers: map[uint16]uint8{}}
+		r.sketches[key] = sketch
+	}
+	if rank > sketch.Registers[index] {
+		sketch.Registers[index] = rank
+	}
+	return nil
+}
+
+func (r *mockVisitorSketchRepository) List(ctx context.Context, linkIDs []string, from, to time.Time) ([]*entity.VisitorSketch, error) {
+	var sketches []*entity.VisitorSketch
+	for _, sketch := range r.sketches {
+		for _, id := range linkIDs {
+			if sketch.LinkID == id && !sketch.Day.Before(from) && sketch.Day.Before(to) {
+				sketches = append(sketches, sketch)
+			}
+		}
+	}
+	return sketches, nil
+}
+
+func (r *mockVisitorSketchRepository) DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error) {
+	var deleted int64
+	for key, sketch := range r.sketches {
+		for _, id := range linkIDs {
+			if sketch.LinkID == id {
+				delete(r.sketches, key)
+				deleted++
+			}
+		}
+	}
+	return deleted, nil
+}
+
+type mockUserRepository struct {
+	users  map[string]*entity.User
+	nextID int
+}
+
+func newMockUserRepository() *mockUserRepository {
+	return &mockUserRepository{
+		users:  make(map[string]*entity.User),
+		nextID: 1,
+	}
+}
+
+func (r *mockUserRepository) Create(ctx context.Context, user *entity.User) (*entity.User, error) {
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return nil, repository.ErrDuplicate
+		}
+	}
+	user.ID = fmt.Sprintf("user-%d", r.nextID)
+	r.nextID++
+	r.users[user.ID] = user
+	return user, nil
+}
+
+func (r *mockUserRepository) GetByID(ctx context.Context, id string) (*entity.User, error) {
+	user, exists := r.users[id]
+	if !exists {
+		return nil, repository.ErrNotFound
+	}
+	return user, nil
+}
+
+func (r *mockUserRepository) List(ctx context.Context) ([]*entity.User, error) {
+	users := make([]*entity.User, 0, len(r.users))
+	for _, user := range r.users {
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+const testOwnerID = "owner-1"
+
+// expiresIn returns an expiry time d from now.
+func expiresIn(d time.Duration) *time.Time {
+	t := time.Now().Add(d)
+	return &t
+}
+
 // --- Usecase Tests ---
 
 func TestCreateLink(t *testing.T) {
@@ -99,9 +555,10 @@ func TestCreateLink(t *testing.T) {
 	uc := usecase.NewLinkUsecase(linkRepo, visitRepo)
 
 	link := &entity.Link{
+		OwnerID:   testOwnerID,
 		Title:     "Test Link",
 		URL:       "http://example.com",
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+		ExpiresAt: expiresIn(24 * time.Hour),
 	}
 
 	createdLink, err := uc.CreateLink(ctx, link)
@@ -109,9 +566,9 @@ func TestCreateLink(t *testing.T) {
 	assert.NotEmpty(t, createdLink.ID)
 	assert.Equal(t, 0, createdLink.Clicks)
 	assert.False(t, createdLink.CreatedAt.IsZero())
-	expectedExpiry := createdLink.CreatedAt.Add(2 * time.Minute)
+	expectedExpiry := createdLink.CreatedAt.Add(24 * time.Hour)
 	diff := createdLink.ExpiresAt.Sub(expectedExpiry)
-	assert.LessOrEqual(t, diff.Abs().Seconds(), 1.0, "ExpiresAt should be 2 minutes after CreatedAt")
+	assert.LessOrEqual(t, diff.Abs().Seconds(), 1.0, "ExpiresAt should be the client-supplied expiry")
 }
 
 func TestGetLink(t *testing.T) {
@@ -121,13 +578,14 @@ func TestGetLink(t *testing.T) {
 	uc := usecase.NewLinkUsecase(linkRepo, visitRepo)
 
 	link := &entity.Link{
+		OwnerID:   testOwnerID,
 		Title:     "Test Link",
 		URL:       "http://example.com",
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+		ExpiresAt: expiresIn(24 * time.Hour),
 	}
 	createdLink, _ := uc.CreateLink(ctx, link)
 
-	fetchedLink, err := uc.GetLink(ctx, createdLink.ID)
+	fetchedLink, err := uc.GetLink(ctx, testOwnerID, createdLink.ID)
 	assert.NoError(t, err)
 	assert.Equal(t, createdLink.Title, fetchedLink.Title)
 }
@@ -139,9 +597,10 @@ func TestUpdateLink(t *testing.T) {
 	uc := usecase.NewLinkUsecase(linkRepo, visitRepo)
 
 	link := &entity.Link{
+		OwnerID:   testOwnerID,
 		Title:     "Test Link",
 		URL:       "http://example.com",
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+		ExpiresAt: expiresIn(24 * time.Hour),
 	}
 	createdLink, _ := uc.CreateLink(ctx, link)
 
@@ -149,7 +608,7 @@ func TestUpdateLink(t *testing.T) {
 	createdLink.Title = "Updated Title"
 	createdLink.URL = "http://updated.com"
 
-	updatedLink, err := uc.UpdateLink(ctx, createdLink)
+	updatedLink, err := uc.UpdateLink(ctx, testOwnerID, createdLink)
 	assert.NoError(t, err)
 	assert.Equal(t, "Updated Title", updatedLink.Title)
 	assert.Equal(t, "http://updated.com", updatedLink.URL)
@@ -162,16 +621,17 @@ func TestDeleteLink(t *testing.T) {
 	uc := usecase.NewLinkUsecase(linkRepo, visitRepo)
 
 	link := &entity.Link{
+		OwnerID:   testOwnerID,
 		Title:     "Test Link",
 		URL:       "http://example.com",
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+		ExpiresAt: expiresIn(24 * time.Hour),
 	}
 	createdLink, _ := uc.CreateLink(ctx, link)
 
-	err := uc.DeleteLink(ctx, createdLink.ID)
+	err := uc.DeleteLink(ctx, testOwnerID, createdLink.ID)
 	assert.NoError(t, err)
 
-	_, err = uc.GetLink(ctx, createdLink.ID)
+	_, err = uc.GetLink(ctx, testOwnerID, createdLink.ID)
 	assert.Error(t, err)
 }
 
@@ -182,13 +642,14 @@ func TestVisitLink(t *testing.T) {
 	uc := usecase.NewLinkUsecase(linkRepo, visitRepo)
 
 	link := &entity.Link{
+		OwnerID:   testOwnerID,
 		Title:     "Test Link",
 		URL:       "http://example.com",
-		ExpiresAt: time.Now().Add(24 * time.Hour),
+		ExpiresAt: expiresIn(24 * time.Hour),
 	}
 	createdLink, _ := uc.CreateLink(ctx, link)
 
-	visitedLink, err := uc.VisitLink(ctx, createdLink.ID)
+	visitedLink, err := uc.VisitLink(ctx, createdLink.ID, browserVisit)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, visitedLink.Clicks)
 	assert.Equal(t, 1, len(visitRepo.visits))
@@ -202,30 +663,387 @@ func TestCleanupExpiredLinks(t *testing.T) {
 
 	// Create an "expired" link using CreateLink, then override ExpiresAt.
 	expiredLink := &entity.Link{
-		Title: "Expired Link",
-		URL:   "http://expired.com",
+		OwnerID: testOwnerID,
+		Title:   "Expired Link",
+		URL:     "http://expired.com",
 	}
 	createdExpired, _ := uc.CreateLink(ctx, expiredLink)
 	// Overwrite ExpiresAt to simulate expiration (1 minute in the past)
-	createdExpired.ExpiresAt = time.Now().Add(-1 * time.Minute)
+	createdExpired.ExpiresAt = expiresIn(-1 * time.Minute)
+	saveLink(t, linkRepo, createdExpired)
 
-	// Create a valid link; CreateLink sets ExpiresAt to 2 minutes from creation.
+	// Create a valid link that expires in 2 minutes.
 	validLink := &entity.Link{
-		Title: "Valid Link",
-		URL:   "http://valid.com",
+		OwnerID:   testOwnerID,
+		Title:     "Valid Link",
+		URL:       "http://valid.com",
+		ExpiresIn: "2m",
 	}
 	validCreated, _ := uc.CreateLink(ctx, validLink)
 
-	// Run cleanup: this should remove links where ExpiresAt is less than now.
+	// Run cleanup: this should archive links where ExpiresAt is less than now.
 	err := uc.CleanupExpiredLinks(ctx)
 	assert.NoError(t, err)
 
-	// The expired link should be removed.
-	_, err = uc.GetLink(ctx, createdExpired.ID)
-	assert.Error(t, err)
+	// The expired link should be archived, not removed, and no longer visitable.
+	archived, err := uc.GetLink(ctx, testOwnerID, createdExpired.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.LinkArchived, archived.Status)
+	_, err = uc.VisitLink(ctx, createdExpired.ID, browserVisit)
+	assert.ErrorIs(t, err, usecase.ErrLinkExpired)
+
+	archivedLinks, err := uc.ListArchivedLinks(ctx, testOwnerID)
+	assert.NoError(t, err)
+	assert.Len(t, archivedLinks, 1)
 
 	// The valid link should still exist.
-	fetchedValid, err := uc.GetLink(ctx, validCreated.ID)
+	fetchedValid, err := uc.GetLink(ctx, testOwnerID, validCreated.ID)
 	assert.NoError(t, err)
 	assert.Equal(t, "Valid Link", fetchedValid.Title)
 }
+
+func TestLinkOwnershipIsEnforced(t *testing.T) {
+	ctx := context.Background()
+	uc := usecase.NewLinkUsecase(newMockLinkRepository(), newMockVisitRepository())
+
+	link := &entity.Link{
+		OwnerID:   testOwnerID,
+		Title:     "Private Link",
+		URL:       "http://example.com",
+		ExpiresAt: expiresIn(24 * time.Hour),
+	}
+	createdLink, _ := uc.CreateLink(ctx, link)
+
+	_, err := uc.GetLink(ctx, "intruder", createdLink.ID)
+	assert.ErrorIs(t, err, usecase.ErrLinkNotFound)
+
+	_, err = uc.UpdateLink(ctx, "intruder", &entity.Link{ID: createdLink.ID, Title: "Hijacked"})
+	assert.ErrorIs(t, err, usecase.ErrLinkNotFound)
+
+	err = uc.DeleteLink(ctx, "intruder", createdLink.ID)
+	assert.ErrorIs(t, err, usecase.ErrLinkNotFound)
+
+	fetched, err := uc.GetLink(ctx, testOwnerID, createdLink.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Private Link", fetched.Title)
+}
+
+func TestCreateLinkSlugs(t *testing.T) {
+	ctx := context.Background()
+	uc := usecase.NewLinkUsecase(newMockLinkRepository(), newMockVisitRepository())
+
+	generated, err := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, Title: "Generated", URL: "http://example.com"})
+	assert.NoError(t, err)
+	assert.Len(t, generated.Slug, 7)
+
+	custom, err := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, Title: "Custom", URL: "http://example.com", Slug: "summer-sale"})
+	assert.NoError(t, err)
+	assert.Equal(t, "summer-sale", custom.Slug)
+
+	_, err = uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.com", Slug: "summer-sale"})
+	assert.ErrorIs(t, err, usecase.ErrSlugTaken)
+
+	for _, slug := range []string{"Admin", "a", "has space", "507f1f77bcf86cd799439011"} {
+		_, err = uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.com", Slug: slug})
+		assert.ErrorIs(t, err, usecase.ErrInvalidLink, slug)
+	}
+
+	fetched, err := uc.GetLink(ctx, testOwnerID, "summer-sale")
+	assert.NoError(t, err)
+	assert.Equal(t, custom.ID, fetched.ID)
+
+	visited, err := uc.VisitLink(ctx, generated.Slug, browserVisit)
+	assert.NoError(t, err)
+	assert.Equal(t, generated.ID, visited.ID)
+	assert.Equal(t, 1, visited.Clicks)
+}
+
+func TestUpdateLinkKeepsSlugUnlessChanged(t *testing.T) {
+	ctx := context.Background()
+	uc := usecase.NewLinkUsecase(newMockLinkRepository(), newMockVisitRepository())
+
+	first, _ := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.com", Slug: "first"})
+	_, _ = uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.com", Slug: "second"})
+
+	updated, err := uc.UpdateLink(ctx, testOwnerID, &entity.Link{ID: "first", Title: "Renamed", URL: "http://example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, first.ID, updated.ID)
+	assert.Equal(t, "first", updated.Slug)
+
+	_, err = uc.UpdateLink(ctx, testOwnerID, &entity.Link{ID: first.ID, URL: "http://example.com", Slug: "second"})
+	assert.ErrorIs(t, err, usecase.ErrSlugTaken)
+}
+
+func TestCreateLinkLifetime(t *testing.T) {
+	ctx := context.Background()
+	uc := usecase.NewLinkUsecase(newMockLinkRepository(), newMockVisitRepository(),
+		usecase.WithLinkLifetime(7*24*time.Hour, 30*24*time.Hour))
+
+	defaulted, err := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.com"})
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(7*24*time.Hour), *defaulted.ExpiresAt, time.Second)
+
+	relative, err := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.com", ExpiresIn: "2d"})
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(48*time.Hour), *relative.ExpiresAt, time.Second)
+	assert.Empty(t, relative.ExpiresIn)
+
+	invalid := []*entity.Link{
+		{ExpiresAt: expiresIn(-time.Minute)},
+		{ExpiresAt: expiresIn(60 * 24 * time.Hour)},
+		{ExpiresIn: "-1h"},
+		{ExpiresIn: "soon"},
+		{ExpiresIn: "+5d"},
+		{NeverExpires: true},
+		{ExpiresIn: "1h", ExpiresAt: expiresIn(time.Hour)},
+	}
+	for _, link := range invalid {
+		link.OwnerID, link.URL = testOwnerID, "http://example.com"
+		_, err := uc.CreateLink(ctx, link)
+		assert.ErrorIs(t, err, usecase.ErrInvalidLink)
+	}
+
+	// Day counts a time.Duration cannot hold are rejected rather than
+	// wrapped around, even without a maximum lifetime.
+	unlimited := usecase.NewLinkUsecase(newMockLinkRepository(), newMockVisitRepository())
+	_, err = unlimited.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.com", ExpiresIn: "220000d"})
+	assert.ErrorIs(t, err, usecase.ErrInvalidLink)
+}
+
+func TestNeverExpiringLinks(t *testing.T) {
+	ctx := context.Background()
+	uc := usecase.NewLinkUsecase(newMockLinkRepository(), newMockVisitRepository(),
+		usecase.WithLinkLifetime(time.Hour, 0))
+
+	link, err := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.com", NeverExpires: true})
+	assert.NoError(t, err)
+	assert.Nil(t, link.ExpiresAt)
+	assert.False(t, link.IsExpired(time.Now().Add(100*365*24*time.Hour)))
+
+	// Updates that do not mention expiry keep the stored one.
+	updated, err := uc.UpdateLink(ctx, testOwnerID, &entity.Link{ID: link.ID, Title: "Renamed", URL: "http://example.com"})
+	assert.NoError(t, err)
+	assert.Nil(t, updated.ExpiresAt)
+
+	updated, err = uc.UpdateLink(ctx, testOwnerID, &entity.Link{ID: link.ID, URL: "http://example.com", ExpiresIn: "90m"})
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(90*time.Minute), *updated.ExpiresAt, time.Second)
+}
+
+func TestScheduledLinks(t *testing.T) {
+	ctx := context.Background()
+	linkRepo := newMockLinkRepository()
+	uc := usecase.NewLinkUsecase(linkRepo, newMockVisitRepository())
+
+	launch := expiresIn(time.Hour)
+	scheduled, err := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://launch.com", ActiveFrom: launch, ExpiresIn: "48h"})
+	assert.NoError(t, err)
+	assert.Equal(t, entity.LinkScheduled, scheduled.Status)
+
+	_, err = uc.VisitLink(ctx, scheduled.ID, browserVisit)
+	assert.ErrorIs(t, err, usecase.ErrLinkNotFound)
+	assert.Equal(t, 0, scheduled.Clicks)
+
+	// Once the launch time has passed the link behaves like any other.
+	scheduled.ActiveFrom = expiresIn(-time.Minute)
+	saveLink(t, linkRepo, scheduled)
+	visited, err := uc.VisitLink(ctx, scheduled.ID, browserVisit)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.LinkActive, visited.Status)
+
+	expired, _ := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://old.com"})
+	expired.ExpiresAt = expiresIn(-time.Minute)
+	saveLink(t, linkRepo, expired)
+	fetched, err := uc.GetLink(ctx, testOwnerID, expired.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.LinkExpired, fetched.Status)
+
+	_, err = uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://x.com", ActiveFrom: expiresIn(3 * time.Hour), ExpiresIn: "1h"})
+	assert.ErrorIs(t, err, usecase.ErrInvalidLink)
+}
+
+func TestCleanupPurgesOldArchivedLinks(t *testing.T) {
+	ctx := context.Background()
+	linkRepo := newMockLinkRepository()
+	visitRepo := newMockVisitRepository()
+	uc := usecase.NewLinkUsecase(linkRepo, visitRepo, usecase.WithArchivePurge(24*time.Hour))
+
+	old, _ := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://old.com", ExpiresIn: "1h"})
+	_, _ = uc.VisitLink(ctx, old.ID, browserVisit)
+	recent, _ := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://recent.com", ExpiresIn: "1h"})
+	_, _ = uc.VisitLink(ctx, recent.ID, browserVisit)
+
+	old.ExpiresAt = expiresIn(-72 * time.Hour)
+	old.ArchivedAt = expiresIn(-48 * time.Hour)
+	recent.ExpiresAt = expiresIn(-time.Minute)
+	saveLink(t, linkRepo, old)
+	saveLink(t, linkRepo, recent)
+
+	assert.NoError(t, uc.CleanupExpiredLinks(ctx))
+
+	_, err := uc.GetLink(ctx, testOwnerID, old.ID)
+	assert.ErrorIs(t, err, usecase.ErrLinkNotFound)
+	fetched, err := uc.GetLink(ctx, testOwnerID, recent.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.LinkArchived, fetched.Status)
+	if assert.Len(t, visitRepo.visits, 1) {
+		assert.Equal(t, recent.ID, visitRepo.visits[0].LinkID)
+	}
+
+	// Extending an archived link's lifetime brings it back.
+	revived, err := uc.UpdateLink(ctx, testOwnerID, &entity.Link{ID: recent.ID, URL: "http://recent.com", ExpiresIn: "24h"})
+	assert.NoError(t, err)
+	assert.Nil(t, revived.ArchivedAt)
+	assert.Equal(t, entity.LinkActive, revived.Status)
+}
+
+func TestDeletedLinksGoToTrash(t *testing.T) {
+	ctx := context.Background()
+	linkRepo := newMockLinkRepository()
+	uc := usecase.NewLinkUsecase(linkRepo, newMockVisitRepository())
+
+	link, _ := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.com", Slug: "my-link"})
+	_, _ = uc.VisitLink(ctx, link.ID, browserVisit)
+	assert.NoError(t, uc.DeleteLink(ctx, testOwnerID, link.ID))
+
+	_, err := uc.GetLink(ctx, testOwnerID, link.ID)
+	assert.ErrorIs(t, err, usecase.ErrLinkNotFound)
+	_, err = uc.VisitLink(ctx, "my-link", browserVisit)
+	assert.ErrorIs(t, err, usecase.ErrLinkNotFound)
+	assert.ErrorIs(t, uc.DeleteLink(ctx, testOwnerID, link.ID), usecase.ErrLinkNotFound)
+
+	// The slug stays reserved while the link is in the trash.
+	_, err = uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://other.com", Slug: "my-link"})
+	assert.ErrorIs(t, err, usecase.ErrSlugTaken)
+
+	trashed, err := uc.ListDeletedLinks(ctx, testOwnerID)
+	assert.NoError(t, err)
+	if assert.Len(t, trashed, 1) {
+		assert.Equal(t, entity.LinkDeleted, trashed[0].Status)
+	}
+
+	_, err = uc.RestoreLink(ctx, "someone-else", link.ID)
+	assert.ErrorIs(t, err, usecase.ErrLinkNotFound)
+	restored, err := uc.RestoreLink(ctx, testOwnerID, "my-link")
+	assert.NoError(t, err)
+	assert.Nil(t, restored.DeletedAt)
+	assert.Equal(t, entity.LinkActive, restored.Status)
+	assert.Equal(t, 1, restored.Clicks)
+
+	_, err = uc.RestoreLink(ctx, testOwnerID, link.ID)
+	assert.ErrorIs(t, err, usecase.ErrLinkNotFound)
+}
+
+func TestPurgeDeletedLinks(t *testing.T) {
+	ctx := context.Background()
+	linkRepo := newMockLinkRepository()
+	visitRepo := newMockVisitRepository()
+	uc := usecase.NewLinkUsecase(linkRepo, visitRepo, usecase.WithTrashRetention(24*time.Hour))
+
+	old, _ := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://old.com"})
+	_, _ = uc.VisitLink(ctx, old.ID, browserVisit)
+	recent, _ := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://recent.com"})
+	_, _ = uc.VisitLink(ctx, recent.ID, browserVisit)
+
+	assert.NoError(t, uc.DeleteLink(ctx, testOwnerID, old.ID))
+	assert.NoError(t, uc.DeleteLink(ctx, testOwnerID, recent.ID))
+	old.DeletedAt = expiresIn(-48 * time.Hour)
+	saveLink(t, linkRepo, old)
+
+	assert.NoError(t, uc.PurgeDeletedLinks(ctx))
+
+	_, err := uc.RestoreLink(ctx, testOwnerID, old.ID)
+	assert.ErrorIs(t, err, usecase.ErrLinkNotFound)
+	_, err = uc.RestoreLink(ctx, testOwnerID, recent.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, visitRepo.visits, 1) {
+		assert.Equal(t, recent.ID, visitRepo.visits[0].LinkID)
+	}
+}
+
+func TestListLinksPaginates(t *testing.T) {
+	ctx := context.Background()
+	uc := usecase.NewLinkUsecase(newMockLinkRepository(), newMockVisitRepository())
+
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 5; i++ {
+		link, _ := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, Title: fmt.Sprintf("Link %d", i), URL: "http://example.com"})
+		link.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+	}
+	_, _ = uc.CreateLink(ctx, &entity.Link{OwnerID: "someone-else", URL: "http://example.com"})
+
+	var titles []string
+	query := usecase.LinkQuery{Limit: 2}
+	for pages := 0; ; pages++ {
+		page, err := uc.ListLinks(ctx, testOwnerID, query)
+		assert.NoError(t, err)
+		for _, link := range page.Links {
+			titles = append(titles, link.Title)
+			assert.Equal(t, entity.LinkActive, link.Status)
+		}
+		if page.NextCursor == "" {
+			assert.Equal(t, 2, pages)
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	assert.Equal(t, []string{"Link 4", "Link 3", "Link 2", "Link 1", "Link 0"}, titles)
+
+	// A cursor only works with the ordering it was issued for.
+	first, _ := uc.ListLinks(ctx, testOwnerID, usecase.LinkQuery{Limit: 2})
+	_, err := uc.ListLinks(ctx, testOwnerID, usecase.LinkQuery{Limit: 2, Sort: "clicks", Cursor: first.NextCursor})
+	assert.ErrorIs(t, err, usecase.ErrInvalidQuery)
+	_, err = uc.ListLinks(ctx, testOwnerID, usecase.LinkQuery{Cursor: "not-a-cursor"})
+	assert.ErrorIs(t, err, usecase.ErrInvalidQuery)
+}
+
+func TestListLinksFiltersAndSorts(t *testing.T) {
+	ctx := context.Background()
+	uc := usecase.NewLinkUsecase(newMockLinkRepository(), newMockVisitRepository())
+
+	docs, _ := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, Title: "Go Docs", URL: "https://Go.dev/doc", Tags: []string{" Go ", "docs", "go"}, ExpiresIn: "48h"})
+	blog, _ := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, Title: "Blog", URL: "https://example.com:8443/blog", Tags: []string{"writing"}, ExpiresIn: "24h"})
+	later, _ := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, Title: "Launch", URL: "https://go.dev/launch", ActiveFrom: expiresIn(time.Hour)})
+	trashed, _ := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, Title: "Old", URL: "https://go.dev/old"})
+	assert.NoError(t, uc.DeleteLink(ctx, testOwnerID, trashed.ID))
+	_, _ = uc.VisitLink(ctx, blog.ID, browserVisit)
+	_, _ = uc.VisitLink(ctx, blog.ID, browserVisit)
+	_, _ = uc.VisitLink(ctx, docs.ID, browserVisit)
+
+	assert.Equal(t, []string{"go", "docs"}, docs.Tags)
+
+	ids := func(q usecase.LinkQuery) []string {
+		page, err := uc.ListLinks(ctx, testOwnerID, q)
+		assert.NoError(t, err)
+		var ids []string
+		for _, link := range page.Links {
+			ids = append(ids, link.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []string{docs.ID}, ids(usecase.LinkQuery{Tag: "GO"}))
+	assert.ElementsMatch(t, []string{docs.ID, later.ID}, ids(usecase.LinkQuery{Host: "go.dev"}))
+	assert.Equal(t, []string{blog.ID}, ids(usecase.LinkQuery{Host: "example.com"}))
+	assert.Equal(t, []string{later.ID}, ids(usecase.LinkQuery{Title: "LAUN"}))
+	assert.Equal(t, []string{later.ID}, ids(usecase.LinkQuery{Statuses: []entity.LinkStatus{entity.LinkScheduled}}))
+	assert.Equal(t, []string{trashed.ID}, ids(usecase.LinkQuery{Statuses: []entity.LinkStatus{entity.LinkDeleted}}))
+	assert.Equal(t, []string{blog.ID, docs.ID, later.ID}, ids(usecase.LinkQuery{Sort: "-clicks"}))
+	assert.Equal(t, []string{later.ID, blog.ID, docs.ID}, ids(usecase.LinkQuery{Sort: "expiresAt"}))
+	assert.Equal(t, []string{docs.ID, blog.ID, later.ID}, ids(usecase.LinkQuery{Sort: "-expiresAt"}))
+
+	from := time.Now().Add(time.Minute)
+	assert.Empty(t, ids(usecase.LinkQuery{CreatedFrom: &from}))
+
+	for _, q := range []usecase.LinkQuery{
+		{Sort: "title"},
+		{Limit: 500},
+		{Statuses: []entity.LinkStatus{"gone"}},
+		{Host: "https://go.dev"},
+	} {
+		_, err := uc.ListLinks(ctx, testOwnerID, q)
+		assert.ErrorIs(t, err, usecase.ErrInvalidQuery)
+	}
+
+	_, err := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "https://go.dev", Tags: []string{"no spaces"}})
+	assert.ErrorIs(t, err, usecase.ErrInvalidLink)
+}
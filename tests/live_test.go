@@ -0,0 +1,106 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	httphandler "github.com/hussainr95/link-in-bio-service/internal/delivery/http"
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/pubsub"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrokerDeliversToLinkSubscribers(t *testing.T) {
+	broker := pubsub.NewBroker()
+	first := broker.Subscribe([]string{"a", "b"})
+	second := broker.Subscribe([]string{"b"})
+
+	broker.Publish(&entity.VisitEvent{Visit: &entity.Visit{LinkID: "a"}, Clicks: 1})
+	broker.Publish(&entity.VisitEvent{Visit: &entity.Visit{LinkID: "b"}, Clicks: 7})
+	broker.Publish(&entity.VisitEvent{Visit: &entity.Visit{LinkID: "c"}, Clicks: 3})
+
+	assert.Equal(t, "a", (<-first.Events()).Visit.LinkID)
+	assert.Equal(t, "b", (<-first.Events()).Visit.LinkID)
+	assert.Equal(t, 7, (<-second.Events()).Clicks)
+	assert.Empty(t, first.Events())
+	assert.Empty(t, second.Events())
+
+	// A subscriber that stops reading misses events but never blocks others.
+	for i := 0; i < pubsub.SubscriptionBuffer+10; i++ {
+		broker.Publish(&entity.VisitEvent{Visit: &entity.Visit{LinkID: "b"}})
+	}
+	assert.Len(t, second.Events(), pubsub.SubscriptionBuffer)
+
+	second.Close()
+	second.Close()
+	_, open := <-first.Events()
+	assert.True(t, open)
+	broker.Publish(&entity.VisitEvent{Visit: &entity.Visit{LinkID: "b"}})
+}
+
+func TestLiveEndpointStreamsVisits(t *testing.T) {
+	ctx := context.Background()
+	broker := pubsub.NewBroker()
+	linkRepo := newMockLinkRepository()
+	profileRepo := newMockProfileRepository()
+	links := usecase.NewLinkUsecase(linkRepo, newMockVisitRepository(), usecase.WithVisitEvents(broker))
+	link, _ := links.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, Slug: "launch", URL: "http://example.com"})
+	_, _ = links.CreateLink(ctx, &entity.Link{OwnerID: "someone-else", Slug: "theirs", URL: "http://example.com"})
+
+	router := gin.New()
+	api := router.Group("/", func(c *gin.Context) {
+		c.Set("principal", &entity.APIKey{UserID: testOwnerID})
+	})
+	httphandler.NewLiveHandler(usecase.NewLiveUsecase(linkRepo, profileRepo, broker)).RegisterAPIRoutes(api)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	for _, path := range []string{"/links/theirs/live", "/profile/live"} {
+		res, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusNotFound, res.StatusCode, path)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(reqCtx, "GET", server.URL+"/links/launch/live", nil)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	lines := bufio.NewScanner(res.Body)
+	require.True(t, lines.Scan())
+	assert.Equal(t, ": connected", lines.Text())
+	require.True(t, lines.Scan())
+
+	_, err = links.VisitLink(ctx, link.Slug, browserVisit)
+	require.NoError(t, err)
+
+	var event, data string
+	for lines.Scan() && lines.Text() != "" {
+		if value, ok := strings.CutPrefix(lines.Text(), "event:"); ok {
+			event = value
+		}
+		if value, ok := strings.CutPrefix(lines.Text(), "data:"); ok {
+			data = value
+		}
+	}
+	assert.Equal(t, "visit", event)
+	var visit entity.VisitEvent
+	require.NoError(t, json.Unmarshal([]byte(data), &visit))
+	assert.Equal(t, link.ID, visit.Visit.LinkID)
+	assert.Equal(t, "Safari", visit.Visit.Browser)
+	assert.Equal(t, 1, visit.Clicks)
+}
@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRepositoriesAreSafeForConcurrentUse(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	link, err := repos.Links.Create(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.com"})
+	require.NoError(t, err)
+	now := time.Now()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		claimed int
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, repos.Links.IncrementClicks(ctx, link.ID))
+			_, err := repos.Visits.Create(ctx, &entity.Visit{LinkID: link.ID, VisitedAt: now.Add(-time.Duration(i) * time.Second)})
+			assert.NoError(t, err)
+			_, err = repos.Links.List(ctx, repository.LinkFilter{OwnerID: testOwnerID})
+			assert.NoError(t, err)
+			ok, err := repos.ClickWindows.Claim(ctx, link.ID, "visitor", now, time.Minute)
+			assert.NoError(t, err)
+			if ok {
+				mu.Lock()
+				claimed++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	stored, err := repos.Links.GetByID(ctx, link.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 50, stored.Clicks)
+	count, err := repos.Visits.Count(ctx, repository.VisitQuery{LinkIDs: []string{link.ID}, From: now.Add(-time.Hour), To: now.Add(time.Second)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(50), count)
+	assert.Equal(t, 1, claimed, "a window is claimed once however many clicks race for it")
+}
+
+func TestMemoryClickWindowRepositoryReopensAfterWindow(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryClickWindowRepository()
+	now := time.Now()
+
+	ok, _ := repo.Claim(ctx, "link", "visitor", now, 30*time.Second)
+	assert.True(t, ok)
+	ok, _ = repo.Claim(ctx, "link", "visitor", now.Add(29*time.Second), 30*time.Second)
+	assert.False(t, ok)
+	ok, _ = repo.Claim(ctx, "link", "other", now.Add(29*time.Second), 30*time.Second)
+	assert.True(t, ok)
+	ok, _ = repo.Claim(ctx, "link", "visitor", now.Add(30*time.Second), 30*time.Second)
+	assert.True(t, ok)
+}
+
+func TestMemoryWebhookDeliveryRepositoryClaimsEachDeliveryOnce(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryWebhookDeliveryRepository()
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		next := now.Add(-time.Duration(i) * time.Minute)
+		_, err := repo.Create(ctx, &entity.WebhookDelivery{WebhookID: "hook", Status: entity.DeliveryPending, NextAttemptAt: &next, CreatedAt: now})
+		require.NoError(t, err)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		claimed = map[string]int{}
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			delivery, err := repo.ClaimDue(ctx, now, time.Minute)
+			if err != nil {
+				assert.ErrorIs(t, err, repository.ErrNotFound)
+				return
+			}
+			mu.Lock()
+			claimed[delivery.ID]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, claimed, 5)
+	for _, n := range claimed {
+		assert.Equal(t, 1, n)
+	}
+	_, err := repo.ClaimDue(ctx, now.Add(59*time.Second), time.Minute)
+	assert.ErrorIs(t, err, repository.ErrNotFound, "claimed deliveries are leased")
+}
+
+func TestMemoryVisitRepositoryEachAllowsCallbacksIntoRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemoryVisitRepository()
+	now := time.Now()
+	for i := 3; i > 0; i-- {
+		_, err := repo.Create(ctx, &entity.Visit{LinkID: "link", VisitedAt: now.Add(-time.Duration(i) * time.Minute)})
+		require.NoError(t, err)
+	}
+
+	q := repository.VisitQuery{LinkIDs: []string{"link"}, From: now.Add(-time.Hour), To: now}
+	var seen []time.Time
+	err := repo.Each(ctx, q, func(visit *entity.Visit) error {
+		seen = append(seen, visit.VisitedAt)
+		_, err := repo.Count(ctx, q)
+		return err
+	})
+	require.NoError(t, err)
+	assert.IsIncreasing(t, []int64{seen[0].UnixNano(), seen[1].UnixNano(), seen[2].UnixNano()})
+
+	deleted, err := repo.DeleteBefore(ctx, now.Add(-90*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+	oldest, err := repo.Oldest(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, seen[2], oldest)
+}
+
+func TestMemoryRepositoriesServeStats(t *testing.T) {
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	links := usecase.NewLinkUsecase(repos.Links, repos.Visits, usecase.WithVisitorSketches(repos.Sketches))
+	stats := usecase.NewStatsUsecase(repos.Links, repos.Visits, repos.Profiles, repos.Sketches, repos.ClickAnomalies, repos.VisitRollups)
+
+	link, err := links.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, Slug: "launch", URL: "http://example.com"})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err := links.VisitLink(ctx, link.ID, browserVisit)
+		require.NoError(t, err)
+	}
+
+	today := time.Now().UTC().Format(time.DateOnly)
+	result, err := stats.LinkStats(ctx, testOwnerID, link.ID, usecase.StatsQuery{From: today, To: today})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), result.Total)
+
+	breakdown, err := stats.LinkBreakdown(ctx, testOwnerID, link.ID, usecase.BreakdownQuery{StatsQuery: usecase.StatsQuery{From: today, To: today}, Dimension: "browser"})
+	require.NoError(t, err)
+	require.NotEmpty(t, breakdown.Items)
+	assert.Equal(t, int64(3), breakdown.Items[0].Clicks)
+}
@@ -0,0 +1,45 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+	"github.com/hussainr95/link-in-bio-service/internal/repository/repotest"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The Mongo tests run only when MONGO_URI points at a server, e.g.
+// mongodb://localhost:27017. Each test uses a database of its own and drops
+// it afterwards.
+
+// openMongoRepositories returns repositories backed by a fresh database with
+// the indexes the server creates at start-up.
+func openMongoRepositories(t *testing.T) *repository.Repositories {
+	t.Helper()
+	uri := os.Getenv("MONGO_URI")
+	if uri == "" {
+		t.Skip("MONGO_URI is not set")
+	}
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Disconnect(ctx) })
+	db := client.Database(fmt.Sprintf("test_%d", time.Now().UnixNano()))
+	t.Cleanup(func() { db.Drop(ctx) })
+	require.NoError(t, repository.EnsureMongoIndexes(ctx, db))
+	return repository.NewMongoRepositories(db)
+}
+
+func TestMongoLinkRepositoryConformance(t *testing.T) {
+	repotest.TestLinkRepository(t, func(t *testing.T) repository.LinkRepository { return openMongoRepositories(t).Links })
+}
+
+func TestMongoVisitRepositoryConformance(t *testing.T) {
+	repotest.TestVisitRepository(t, func(t *testing.T) repository.VisitRepository { return openMongoRepositories(t).Visits })
+}
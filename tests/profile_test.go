@@ -0,0 +1,164 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	httphandler "github.com/hussainr95/link-in-bio-service/internal/delivery/http"
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockProfileRepository struct {
+	profiles map[string]*entity.Profile
+	nextID   int
+}
+
+func newMockProfileRepository() *mockProfileRepository {
+	return &mockProfileRepository{
+		profiles: make(map[string]*entity.Profile),
+		nextID:   1,
+	}
+}
+
+func (r *mockProfileRepository) Create(ctx context.Context, profile *entity.Profile) (*entity.Profile, error) {
+	for _, existing := range r.profiles {
+		if existing.Handle == profile.Handle || existing.UserID == profile.UserID {
+			return nil, repository.ErrDuplicate
+		}
+	}
+	profile.ID = fmt.Sprintf("profile-%d", r.nextID)
+	r.nextID++
+	r.profiles[profile.ID] = profile
+	return profile, nil
+}
+
+func (r *mockProfileRepository) GetByHandle(ctx context.Context, handle string) (*entity.Profile, error) {
+	for _, profile := range r.profiles {
+		if profile.Handle == handle {
+			return profile, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *mockProfileRepository) GetByUserID(ctx context.Context, userID string) (*entity.Profile, error) {
+	for _, profile := range r.profiles {
+		if profile.UserID == userID {
+			return profile, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *mockProfileRepository) Update(ctx context.Context, profile *entity.Profile) (*entity.Profile, error) {
+	if _, exists := r.profiles[profile.ID]; !exists {
+		return nil, repository.ErrNotFound
+	}
+	for _, existing := range r.profiles {
+		if existing.ID != profile.ID && existing.Handle == profile.Handle {
+			return nil, repository.ErrDuplicate
+		}
+	}
+	r.profiles[profile.ID] = profile
+	return profile, nil
+}
+
+func TestSaveProfileValidatesHandleAndLinks(t *testing.T) {
+	ctx := context.Background()
+	linkRepo := newMockLinkRepository()
+	uc := usecase.NewProfileUsecase(newMockProfileRepository(), linkRepo)
+
+	_, err := uc.SaveProfile(ctx, testOwnerID, &entity.Profile{Handle: "no spaces!"})
+	assert.ErrorIs(t, err, usecase.ErrInvalidProfile)
+
+	foreign, _ := linkRepo.Create(ctx, &entity.Link{OwnerID: "someone-else", Title: "Foreign"})
+	_, err = uc.SaveProfile(ctx, testOwnerID, &entity.Profile{Handle: "jane", LinkIDs: []string{foreign.ID}})
+	assert.ErrorIs(t, err, usecase.ErrInvalidProfile)
+
+	saved, err := uc.SaveProfile(ctx, testOwnerID, &entity.Profile{Handle: "Jane", DisplayName: "Jane"})
+	assert.NoError(t, err)
+	assert.Equal(t, "jane", saved.Handle)
+
+	_, err = uc.SaveProfile(ctx, "other-owner", &entity.Profile{Handle: "jane"})
+	assert.ErrorIs(t, err, usecase.ErrHandleTaken)
+}
+
+func TestPublicProfileEndpoint(t *testing.T) {
+	ctx := context.Background()
+	linkRepo := newMockLinkRepository()
+	uc := usecase.NewProfileUsecase(newMockProfileRepository(), linkRepo)
+
+	live, _ := linkRepo.Create(ctx, &entity.Link{OwnerID: testOwnerID, Title: "Live", URL: "http://live.com", ExpiresAt: expiresIn(time.Hour)})
+	expired, _ := linkRepo.Create(ctx, &entity.Link{OwnerID: testOwnerID, Title: "Expired", URL: "http://old.com", ExpiresAt: expiresIn(-time.Hour)})
+	second, _ := linkRepo.Create(ctx, &entity.Link{OwnerID: testOwnerID, Title: "Second", URL: "http://second.com", ExpiresAt: expiresIn(time.Hour)})
+	scheduled, _ := linkRepo.Create(ctx, &entity.Link{OwnerID: testOwnerID, Title: "Soon", URL: "http://soon.com", ActiveFrom: expiresIn(time.Hour)})
+	_, err := uc.SaveProfile(ctx, testOwnerID, &entity.Profile{
+		Handle:      "jane",
+		DisplayName: "Jane",
+		LinkIDs:     []string{second.ID, expired.ID, scheduled.ID, live.ID},
+	})
+	assert.NoError(t, err)
+
+	router := gin.New()
+	httphandler.NewProfileHandler(uc).RegisterPublicRoutes(router)
+
+	req, _ := http.NewRequest("GET", "/p/jane", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var page entity.PublicProfile
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Equal(t, "Jane", page.DisplayName)
+	if assert.Len(t, page.Links, 2) {
+		assert.Equal(t, "Second", page.Links[0].Title)
+		assert.Equal(t, "Live", page.Links[1].Title)
+	}
+
+	req, _ = http.NewRequest("GET", "/p/nobody", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRenderProfilePage(t *testing.T) {
+	ctx := context.Background()
+	linkRepo := newMockLinkRepository()
+	uc := usecase.NewProfileUsecase(newMockProfileRepository(), linkRepo)
+
+	link, _ := linkRepo.Create(ctx, &entity.Link{OwnerID: testOwnerID, Title: "My <Shop>", URL: "http://shop.com", ExpiresAt: expiresIn(time.Hour)})
+	_, err := uc.SaveProfile(ctx, testOwnerID, &entity.Profile{Handle: "jane", DisplayName: "Jane", Theme: "sunset", LinkIDs: []string{link.ID}})
+	assert.NoError(t, err)
+
+	_, err = uc.SaveProfile(ctx, testOwnerID, &entity.Profile{Handle: "jane", Theme: "neon"})
+	assert.ErrorIs(t, err, usecase.ErrInvalidProfile)
+
+	router := gin.New()
+	httphandler.NewProfileHandler(uc).RegisterPublicRoutes(router)
+
+	req, _ := http.NewRequest("GET", "/@jane", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	body := w.Body.String()
+	assert.Contains(t, body, "<h1>Jane</h1>")
+	assert.Contains(t, body, "My &lt;Shop&gt;")
+	assert.Contains(t, body, "linear-gradient(160deg")
+	assert.Contains(t, body, `href="/r/`+link.ID+`"`)
+
+	req, _ = http.NewRequest("GET", "/@nobody", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "Page not found")
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	httphandler "github.com/hussainr95/link-in-bio-service/internal/delivery/http"
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRedirectRouter(status int) (*gin.Engine, *mockLinkRepository, *mockVisitRepository) {
+	linkRepo := newMockLinkRepository()
+	visitRepo := newMockVisitRepository()
+	uc := usecase.NewLinkUsecase(linkRepo, visitRepo)
+
+	router := gin.New()
+	httphandler.NewRedirectHandler(uc, status).RegisterPublicRoutes(router)
+	return router, linkRepo, visitRepo
+}
+
+func TestRedirectEndpoint(t *testing.T) {
+	router, linkRepo, visitRepo := setupRedirectRouter(http.StatusFound)
+	link, _ := linkRepo.Create(context.Background(), &entity.Link{
+		OwnerID:   testOwnerID,
+		URL:       "http://example.com/landing",
+		ExpiresAt: expiresIn(time.Hour),
+	})
+
+	req, _ := http.NewRequest("GET", "/r/"+link.ID, nil)
+	setBrowserHeaders(req)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "http://example.com/landing", w.Header().Get("Location"))
+	stored, _ := linkRepo.GetByID(context.Background(), link.ID)
+	assert.Equal(t, 1, stored.Clicks)
+	assert.Len(t, visitRepo.visits, 1)
+}
+
+func TestRedirectEndpointUsesConfiguredStatus(t *testing.T) {
+	router, linkRepo, _ := setupRedirectRouter(http.StatusTemporaryRedirect)
+	link, _ := linkRepo.Create(context.Background(), &entity.Link{
+		OwnerID:   testOwnerID,
+		URL:       "http://example.com",
+		ExpiresAt: expiresIn(time.Hour),
+	})
+
+	req, _ := http.NewRequest("GET", "/r/"+link.ID, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTemporaryRedirect, w.Code)
+}
+
+func TestRedirectEndpointMissingAndExpired(t *testing.T) {
+	router, linkRepo, visitRepo := setupRedirectRouter(http.StatusFound)
+	expired, _ := linkRepo.Create(context.Background(), &entity.Link{
+		OwnerID:   testOwnerID,
+		URL:       "http://example.com",
+		ExpiresAt: expiresIn(-time.Minute),
+	})
+
+	req, _ := http.NewRequest("GET", "/r/"+expired.ID, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.Contains(t, w.Body.String(), "Link expired")
+	assert.Equal(t, 0, expired.Clicks)
+
+	req, _ = http.NewRequest("GET", "/r/does-not-exist", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, visitRepo.visits)
+}
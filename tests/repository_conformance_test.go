@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+	"github.com/hussainr95/link-in-bio-service/internal/repository/repotest"
+)
+
+func TestMemoryLinkRepositoryConformance(t *testing.T) {
+	repotest.TestLinkRepository(t, func(*testing.T) repository.LinkRepository { return repository.NewMemoryLinkRepository() })
+}
+
+func TestMemoryVisitRepositoryConformance(t *testing.T) {
+	repotest.TestVisitRepository(t, func(*testing.T) repository.VisitRepository { return repository.NewMemoryVisitRepository() })
+}
+
+func TestSQLLinkRepositoryConformance(t *testing.T) {
+	repotest.TestLinkRepository(t, func(t *testing.T) repository.LinkRepository { return openSQLiteRepositories(t).Links })
+}
+
+func TestSQLVisitRepositoryConformance(t *testing.T) {
+	repotest.TestVisitRepository(t, func(t *testing.T) repository.VisitRepository { return openSQLiteRepositories(t).Visits })
+}
+
+func TestBoltLinkRepositoryConformance(t *testing.T) {
+	repotest.TestLinkRepository(t, func(t *testing.T) repository.LinkRepository { return openBoltRepositories(t).Links })
+}
+
+func TestBoltVisitRepositoryConformance(t *testing.T) {
+	repotest.TestVisitRepository(t, func(t *testing.T) repository.VisitRepository { return openBoltRepositories(t).Visits })
+}
+
+// The test doubles must behave like the real repositories, or the usecase
+// tests prove nothing.
+func TestMockLinkRepositoryConformance(t *testing.T) {
+	repotest.TestLinkRepository(t, func(*testing.T) repository.LinkRepository { return newMockLinkRepository() })
+}
+
+func TestMockVisitRepositoryConformance(t *testing.T) {
+	repotest.TestVisitRepository(t, func(*testing.T) repository.VisitRepository { return newMockVisitRepository() })
+}
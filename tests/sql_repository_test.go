@@ -0,0 +1,278 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openSQLiteRepositories returns repositories backed by a fresh SQLite file.
+func openSQLiteRepositories(t *testing.T) *repository.Repositories {
+	t.Helper()
+	db := openSQLite(t, filepath.Join(t.TempDir(), "test.db"))
+	return repository.NewSQLRepositories(db)
+}
+
+func openSQLite(t *testing.T, path string) *repository.SQLDB {
+	t.Helper()
+	ctx := context.Background()
+	db, err := repository.OpenSQL(ctx, repository.DialectSQLite, path)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	require.NoError(t, repository.MigrateSQL(ctx, db))
+	return db
+}
+
+func TestMigrateSQLIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+	db := openSQLite(t, path)
+	link, err := repository.NewSQLLinkRepository(db).Create(ctx, &entity.Link{OwnerID: testOwnerID, Slug: "launch", URL: "http://example.com"})
+	require.NoError(t, err)
+	require.NoError(t, repository.MigrateSQL(ctx, db))
+
+	reopened := openSQLite(t, path)
+	found, err := repository.NewSQLLinkRepository(reopened).GetBySlug(ctx, "launch")
+	require.NoError(t, err)
+	assert.Equal(t, link.ID, found.ID)
+}
+
+// The SQLite and PostgreSQL migrations differ only in column types, so they
+// must create the same tables, columns, constraints and indexes.
+func TestSQLMigrationsDefineTheSameSchema(t *testing.T) {
+	dir := filepath.Join("..", "internal", "repository", "migrations")
+	sqlite := migrationSchema(t, filepath.Join(dir, "sqlite"))
+	postgres := migrationSchema(t, filepath.Join(dir, "postgres"))
+	require.NotEmpty(t, sqlite)
+	assert.Equal(t, sqlite, postgres)
+}
+
+var (
+	createTable = regexp.MustCompile(`^CREATE TABLE (\w+) \($`)
+	createIndex = regexp.MustCompile(`^CREATE (?:UNIQUE )?INDEX (\w+) ON .*;$`)
+)
+
+// migrationSchema reads the migrations in dir and returns, per file, the
+// column names and constraints of each table and the definition of each
+// index.
+func migrationSchema(t *testing.T, dir string) map[string][]string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	require.NoError(t, err)
+	schema := map[string][]string{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		name := filepath.Base(file)
+		table := ""
+		for _, line := range strings.Split(string(data), "\n") {
+			line, _, _ = strings.Cut(line, "--")
+			line = strings.TrimSpace(line)
+			switch {
+			case line == "":
+			case table != "" && strings.HasPrefix(line, ")"):
+				table = ""
+			case table != "":
+				line = strings.TrimSuffix(line, ",")
+				switch word := strings.Fields(line)[0]; word {
+				case "PRIMARY", "UNIQUE", "FOREIGN", "CHECK", "CONSTRAINT":
+					schema[table] = append(schema[table], line)
+				default:
+					schema[table] = append(schema[table], word)
+				}
+			case createTable.MatchString(line):
+				table = name + " table " + createTable.FindStringSubmatch(line)[1]
+			case createIndex.MatchString(line):
+				schema[name+" index "+createIndex.FindStringSubmatch(line)[1]] = []string{line}
+			default:
+				t.Fatalf("%s: unexpected statement %q", file, line)
+			}
+		}
+	}
+	return schema
+}
+
+func TestSQLVisitRepositoryMatchesMemory(t *testing.T) {
+	checkVisitsMatchMemory(t, openSQLiteRepositories(t).Visits)
+}
+
+// checkVisitsMatchMemory records the same visits in repo and the in-memory
+// repository and compares their aggregates.
+func checkVisitsMatchMemory(t *testing.T, repo repository.VisitRepository) {
+	ctx := context.Background()
+	memRepo := repository.NewMemoryVisitRepository()
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	countries := []string{"DE", "US", "", "FR"}
+	browsers := []string{"Chrome", "Firefox", "Safari"}
+	for i := 0; i < 200; i++ {
+		visit := entity.Visit{
+			LinkID:       fmt.Sprintf("link-%d", i%3),
+			VisitedAt:    start.Add(time.Duration(i*37) * time.Minute).Add(123 * time.Millisecond),
+			ReferrerHost: fmt.Sprintf("ref%d.example", i%5),
+			Country:      countries[i%4],
+			Browser:      browsers[i%3],
+			Device:       "desktop",
+			VisitorHash:  fmt.Sprintf("visitor-%d", i%7),
+			Bot:          i%11 == 0,
+			Repeat:       i%13 == 0,
+		}
+		memVisit := visit
+		_, err := repo.Create(ctx, &visit)
+		require.NoError(t, err)
+		_, err = memRepo.Create(ctx, &memVisit)
+		require.NoError(t, err)
+	}
+
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+	q := repository.VisitQuery{LinkIDs: []string{"link-0", "link-2"}, From: start.Add(time.Hour), To: start.Add(4 * 24 * time.Hour)}
+	for _, variant := range []repository.VisitQuery{q, {LinkIDs: q.LinkIDs, From: q.From, To: q.To, Bots: repository.BotsOnly, IncludeRepeats: true}} {
+		for _, interval := range []entity.StatsInterval{entity.IntervalHour, entity.IntervalDay, entity.IntervalWeek} {
+			want, err := memRepo.CountByInterval(ctx, variant, interval, kolkata)
+			require.NoError(t, err)
+			got, err := repo.CountByInterval(ctx, variant, interval, kolkata)
+			require.NoError(t, err)
+			assert.Equal(t, want, got, interval)
+		}
+		want, _ := memRepo.CountByLink(ctx, variant)
+		got, err := repo.CountByLink(ctx, variant)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+		wantTop, _ := memRepo.TopValues(ctx, variant, entity.DimensionCountry, 3)
+		gotTop, err := repo.TopValues(ctx, variant, entity.DimensionCountry, 3)
+		require.NoError(t, err)
+		assert.Equal(t, wantTop, gotTop)
+	}
+
+	wantSources, _ := memRepo.SourceCounts(ctx, start, start.Add(24*time.Hour), 5)
+	gotSources, err := repo.SourceCounts(ctx, start, start.Add(24*time.Hour), 5)
+	require.NoError(t, err)
+	sortSources := func(s []*repository.LinkSources) {
+		sort.Slice(s, func(i, j int) bool { return s[i].LinkID < s[j].LinkID })
+	}
+	sortSources(wantSources)
+	sortSources(gotSources)
+	assert.Equal(t, wantSources, gotSources)
+
+	wantRollups, _ := memRepo.Rollup(ctx, start, start.Add(24*time.Hour))
+	gotRollups, err := repo.Rollup(ctx, start, start.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, wantRollups, gotRollups)
+
+	deleted, err := repo.DeleteBefore(ctx, start.Add(24*time.Hour))
+	require.NoError(t, err)
+	memDeleted, _ := memRepo.DeleteBefore(ctx, start.Add(24*time.Hour))
+	assert.Equal(t, memDeleted, deleted)
+	oldest, err := repo.Oldest(ctx)
+	require.NoError(t, err)
+	memOldest, _ := memRepo.Oldest(ctx)
+	assert.True(t, memOldest.Truncate(time.Millisecond).Equal(oldest))
+}
+
+func TestSQLRepositoriesClaimOnceUnderConcurrency(t *testing.T) {
+	checkClaimsOnce(t, openSQLiteRepositories(t))
+}
+
+// checkClaimsOnce races workers for click windows and webhook deliveries.
+func checkClaimsOnce(t *testing.T, repos *repository.Repositories) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	for i := 0; i < 5; i++ {
+		next := now.Add(-time.Duration(i) * time.Minute)
+		_, err := repos.Deliveries.Create(ctx, &entity.WebhookDelivery{WebhookID: "hook", Status: entity.DeliveryPending, NextAttemptAt: &next, CreatedAt: now})
+		require.NoError(t, err)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		claimed = map[string]int{}
+		windows int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := repos.ClickWindows.Claim(ctx, "link", "visitor", now, time.Minute)
+			assert.NoError(t, err)
+			delivery, err := repos.Deliveries.ClaimDue(ctx, now, time.Minute)
+			mu.Lock()
+			defer mu.Unlock()
+			if ok {
+				windows++
+			}
+			if err != nil {
+				assert.ErrorIs(t, err, repository.ErrNotFound)
+				return
+			}
+			claimed[delivery.ID]++
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, windows)
+	assert.Len(t, claimed, 5)
+	for _, n := range claimed {
+		assert.Equal(t, 1, n)
+	}
+	ok, err := repos.ClickWindows.Claim(ctx, "link", "visitor", now.Add(time.Minute), time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok, "the window reopens once it has passed")
+}
+
+func TestSQLRepositoriesServeStats(t *testing.T) {
+	checkServesStats(t, openSQLiteRepositories(t))
+}
+
+// checkServesStats records visits through the usecases and reads them back
+// as stats.
+func checkServesStats(t *testing.T, repos *repository.Repositories) {
+	ctx := context.Background()
+	links := usecase.NewLinkUsecase(repos.Links, repos.Visits,
+		usecase.WithVisitorSketches(repos.Sketches), usecase.WithClickDedup(repos.ClickWindows, 0))
+	stats := usecase.NewStatsUsecase(repos.Links, repos.Visits, repos.Profiles, repos.Sketches, repos.ClickAnomalies, repos.VisitRollups)
+
+	link, err := links.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, Slug: "launch", URL: "http://example.com"})
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err := links.VisitLink(ctx, link.ID, browserVisit)
+		require.NoError(t, err)
+	}
+
+	today := time.Now().UTC().Format(time.DateOnly)
+	result, err := stats.LinkStats(ctx, testOwnerID, link.ID, usecase.StatsQuery{From: today, To: today})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), result.Total)
+
+	breakdown, err := stats.LinkBreakdown(ctx, testOwnerID, link.ID, usecase.BreakdownQuery{StatsQuery: usecase.StatsQuery{From: today, To: today}, Dimension: "browser"})
+	require.NoError(t, err)
+	require.NotEmpty(t, breakdown.Items)
+	assert.Equal(t, int64(3), breakdown.Items[0].Clicks)
+
+	require.NoError(t, links.DeleteLink(ctx, testOwnerID, link.ID))
+	stored, err := repos.Links.GetByID(ctx, link.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, stored.DeletedAt)
+	assert.Equal(t, 3, stored.Clicks)
+}
+
+func ids(links []*entity.Link) []string {
+	ids := make([]string, len(links))
+	for i, link := range links {
+		ids[i] = link.ID
+	}
+	return ids
+}
@@ -0,0 +1,354 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	httphandler "github.com/hussainr95/link-in-bio-service/internal/delivery/http"
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+type statsFixture struct {
+	links     *mockLinkRepository
+	visits    *mockVisitRepository
+	profiles  *mockProfileRepository
+	sketches  *mockVisitorSketchRepository
+	anomalies *mockClickAnomalyRepository
+	rollups   *mockVisitRollupRepository
+	uc        usecase.StatsUsecase
+}
+
+func newStatsFixture() *statsFixture {
+	f := &statsFixture{
+		links:     newMockLinkRepository(),
+		visits:    newMockVisitRepository(),
+		profiles:  newMockProfileRepository(),
+		sketches:  newMockVisitorSketchRepository(),
+		anomalies: newMockClickAnomalyRepository(),
+		rollups:   newMockVisitRollupRepository(),
+	}
+	f.uc = usecase.NewStatsUsecase(f.links, f.visits, f.profiles, f.sketches, f.anomalies, f.rollups)
+	return f
+}
+
+func (f *statsFixture) link(ownerID, slug string) *entity.Link {
+	link, _ := f.links.Create(context.Background(), &entity.Link{OwnerID: ownerID, Slug: slug, Title: slug, URL: "http://example.com"})
+	return link
+}
+
+// visitAt records visits to link at the given RFC 3339 times.
+func (f *statsFixture) visitAt(link *entity.Link, times ...string) {
+	for _, value := range times {
+		at, _ := time.Parse(time.RFC3339, value)
+		f.visits.visits = append(f.visits.visits, &entity.Visit{LinkID: link.ID, VisitedAt: at})
+	}
+}
+
+func bucketClicks(buckets []*entity.StatsBucket) []int64 {
+	clicks := make([]int64, len(buckets))
+	for i, b := range buckets {
+		clicks[i] = b.Clicks
+	}
+	return clicks
+}
+
+func TestLinkStatsBucketsByDayInTimezone(t *testing.T) {
+	f := newStatsFixture()
+	link := f.link(testOwnerID, "launch")
+	f.visitAt(link,
+		"2024-03-01T08:00:00Z",
+		"2024-03-01T23:30:00Z", // already 2 March in Berlin
+		"2024-03-03T12:00:00Z",
+		"2024-03-04T00:30:00Z", // after the range
+	)
+
+	stats, err := f.uc.LinkStats(context.Background(), testOwnerID, "launch", usecase.StatsQuery{
+		From: "2024-03-01", To: "2024-03-03", Timezone: "Europe/Berlin",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, link.ID, stats.LinkID)
+	assert.Equal(t, entity.IntervalDay, stats.Interval)
+	assert.Equal(t, int64(3), stats.Total)
+	assert.Equal(t, []int64{1, 1, 1}, bucketClicks(stats.Buckets))
+	assert.Equal(t, "2024-03-02T00:00:00+01:00", stats.Buckets[1].Start.Format(time.RFC3339))
+}
+
+func TestLinkStatsHourlyAndWeekly(t *testing.T) {
+	f := newStatsFixture()
+	link := f.link(testOwnerID, "weekly")
+	f.visitAt(link, "2024-05-06T09:15:00Z", "2024-05-06T09:45:00Z", "2024-05-12T23:59:00Z", "2024-05-13T00:00:00Z")
+
+	hourly, err := f.uc.LinkStats(context.Background(), testOwnerID, link.ID, usecase.StatsQuery{
+		From: "2024-05-06T08:30:00Z", To: "2024-05-06T11:00:00Z", Interval: "hour",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-05-06T08:00:00Z", hourly.From.Format(time.RFC3339))
+	assert.Equal(t, []int64{0, 2, 0}, bucketClicks(hourly.Buckets))
+
+	// 8 May is a Wednesday; weeks start on Monday.
+	weekly, err := f.uc.LinkStats(context.Background(), testOwnerID, link.ID, usecase.StatsQuery{
+		From: "2024-05-08", To: "2024-05-19", Interval: "week",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Monday, weekly.From.Weekday())
+	assert.Equal(t, []int64{3, 1}, bucketClicks(weekly.Buckets))
+}
+
+func TestLinkStatsValidation(t *testing.T) {
+	f := newStatsFixture()
+	link := f.link(testOwnerID, "mine")
+	other := f.link("someone-else", "theirs")
+	ctx := context.Background()
+
+	_, err := f.uc.LinkStats(ctx, testOwnerID, other.ID, usecase.StatsQuery{})
+	assert.ErrorIs(t, err, usecase.ErrLinkNotFound)
+
+	defaults, err := f.uc.LinkStats(ctx, testOwnerID, link.ID, usecase.StatsQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, "UTC", defaults.Timezone)
+	assert.Len(t, defaults.Buckets, 8) // seven days back from part-way through today
+
+	for _, q := range []usecase.StatsQuery{
+		{Timezone: "Mars/Olympus"},
+		{Interval: "minute"},
+		{From: "2024-03-05", To: "2024-03-01"},
+		{From: "last tuesday"},
+		{From: "2020-01-01", To: "2024-01-01", Interval: "hour"},
+	} {
+		_, err := f.uc.LinkStats(ctx, testOwnerID, link.ID, q)
+		assert.ErrorIs(t, err, usecase.ErrInvalidQuery, q)
+	}
+}
+
+func TestProfileStats(t *testing.T) {
+	f := newStatsFixture()
+	ctx := context.Background()
+	_, err := f.uc.ProfileStats(ctx, testOwnerID, usecase.StatsQuery{})
+	assert.ErrorIs(t, err, usecase.ErrProfileNotFound)
+
+	first := f.link(testOwnerID, "first")
+	second := f.link(testOwnerID, "second")
+	hidden := f.link(testOwnerID, "hidden")
+	_, _ = f.profiles.Create(ctx, &entity.Profile{UserID: testOwnerID, Handle: "creator", LinkIDs: []string{second.ID, first.ID}})
+	f.visitAt(first, "2024-03-01T10:00:00Z")
+	f.visitAt(second, "2024-03-01T11:00:00Z", "2024-03-02T11:00:00Z")
+	f.visitAt(hidden, "2024-03-01T12:00:00Z")
+
+	stats, err := f.uc.ProfileStats(ctx, testOwnerID, usecase.StatsQuery{From: "2024-03-01", To: "2024-03-02"})
+	assert.NoError(t, err)
+	assert.Equal(t, "creator", stats.Handle)
+	assert.Equal(t, int64(3), stats.Total)
+	assert.Equal(t, []int64{2, 1}, bucketClicks(stats.Buckets))
+	if assert.Len(t, stats.Links, 2) {
+		assert.Equal(t, "second", stats.Links[0].Slug)
+		assert.Equal(t, int64(2), stats.Links[0].Clicks)
+		assert.Equal(t, int64(1), stats.Links[1].Clicks)
+	}
+}
+
+// reversedLinkRepository returns GetByIDs results in reverse, which the
+// repositories are free to do.
+type reversedLinkRepository struct {
+	repository.LinkRepository
+}
+
+func (r reversedLinkRepository) GetByIDs(ctx context.Context, ids []string) ([]*entity.Link, error) {
+	links, err := r.LinkRepository.GetByIDs(ctx, ids)
+	slices.Reverse(links)
+	return links, err
+}
+
+func TestProfileStatsKeepProfileOrder(t *testing.T) {
+	f := newStatsFixture()
+	ctx := context.Background()
+	uc := usecase.NewStatsUsecase(reversedLinkRepository{f.links}, f.visits, f.profiles, f.sketches, f.anomalies, f.rollups)
+	first, second, third := f.link(testOwnerID, "first"), f.link(testOwnerID, "second"), f.link(testOwnerID, "third")
+	_, _ = f.profiles.Create(ctx, &entity.Profile{UserID: testOwnerID, Handle: "creator", LinkIDs: []string{second.ID, third.ID, first.ID}})
+
+	stats, err := uc.ProfileStats(ctx, testOwnerID, usecase.StatsQuery{})
+	assert.NoError(t, err)
+	var slugs []string
+	for _, link := range stats.Links {
+		slugs = append(slugs, link.Slug)
+	}
+	assert.Equal(t, []string{"second", "third", "first"}, slugs)
+}
+
+func TestStatsEndpoints(t *testing.T) {
+	f := newStatsFixture()
+	link := f.link(testOwnerID, "stats")
+	f.visitAt(link, "2024-03-01T10:00:00Z")
+
+	router := gin.New()
+	api := router.Group("/", func(c *gin.Context) {
+		c.Set("principal", &entity.APIKey{UserID: testOwnerID})
+	})
+	httphandler.NewStatsHandler(f.uc).RegisterAPIRoutes(api)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/links/stats/stats?from=2024-03-01&to=2024-03-01&tz=UTC", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var stats entity.LinkStats
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(t, int64(1), stats.Total)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/links/stats/stats?interval=fortnight", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/profile/stats", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestLinkBreakdown(t *testing.T) {
+	f := newStatsFixture()
+	link := f.link(testOwnerID, "bio")
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, referrer := range []string{"instagram.com", "instagram.com", "instagram.com", "tiktok.com", "tiktok.com", "", "x.com", "youtube.com"} {
+		f.visits.visits = append(f.visits.visits, &entity.Visit{
+			LinkID: link.ID, VisitedAt: at.Add(time.Duration(i) * time.Minute),
+			ReferrerHost: referrer, Device: usecase.DeviceMobile,
+		})
+	}
+	ctx := context.Background()
+	q := usecase.BreakdownQuery{StatsQuery: usecase.StatsQuery{From: "2024-03-01", To: "2024-03-01"}, Dimension: "referrer", Limit: 3}
+
+	breakdown, err := f.uc.LinkBreakdown(ctx, testOwnerID, link.ID, q)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(8), breakdown.Total)
+	assert.Equal(t, int64(2), breakdown.Other)
+	if assert.Len(t, breakdown.Items, 3) {
+		assert.Equal(t, entity.BreakdownItem{Value: "instagram.com", Clicks: 3, Percent: 37.5}, *breakdown.Items[0])
+		assert.Equal(t, "tiktok.com", breakdown.Items[1].Value)
+		assert.Equal(t, "direct", breakdown.Items[2].Value)
+		assert.Equal(t, 12.5, breakdown.Items[2].Percent)
+	}
+
+	q.Dimension, q.Limit = "device", 0
+	breakdown, err = f.uc.LinkBreakdown(ctx, testOwnerID, link.ID, q)
+	assert.NoError(t, err)
+	if assert.Len(t, breakdown.Items, 1) {
+		assert.Equal(t, 100.0, breakdown.Items[0].Percent)
+	}
+
+	// Nothing recorded in another range.
+	q.From, q.To = "2024-04-01", "2024-04-02"
+	breakdown, err = f.uc.LinkBreakdown(ctx, testOwnerID, link.ID, q)
+	assert.NoError(t, err)
+	assert.Empty(t, breakdown.Items)
+	assert.Zero(t, breakdown.Total)
+
+	for _, bad := range []usecase.BreakdownQuery{{Dimension: "city"}, {Dimension: "country", Limit: 1000}} {
+		_, err = f.uc.LinkBreakdown(ctx, testOwnerID, link.ID, bad)
+		assert.ErrorIs(t, err, usecase.ErrInvalidQuery)
+	}
+	_, err = f.uc.LinkBreakdown(ctx, "someone-else", link.ID, usecase.BreakdownQuery{Dimension: "country"})
+	assert.ErrorIs(t, err, usecase.ErrLinkNotFound)
+}
+
+func TestLinkBreakdownEndpoint(t *testing.T) {
+	f := newStatsFixture()
+	link := f.link(testOwnerID, "bio")
+	f.visits.visits = append(f.visits.visits, &entity.Visit{LinkID: link.ID, VisitedAt: time.Now().Add(-time.Hour), Country: "NL"})
+
+	router := gin.New()
+	api := router.Group("/", func(c *gin.Context) {
+		c.Set("principal", &entity.APIKey{UserID: testOwnerID})
+	})
+	httphandler.NewStatsHandler(f.uc).RegisterAPIRoutes(api)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/links/bio/stats/breakdown?dimension=country&limit=5", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var breakdown entity.Breakdown
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &breakdown))
+	if assert.Len(t, breakdown.Items, 1) {
+		assert.Equal(t, "NL", breakdown.Items[0].Value)
+	}
+
+	for _, query := range []string{"dimension=city", "dimension=country&limit=lots"} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/links/bio/stats/breakdown?"+query, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestLinkVisitorsCountsDistinctVisitors(t *testing.T) {
+	f := newStatsFixture()
+	ctx := context.Background()
+	links := usecase.NewLinkUsecase(f.links, f.visits, usecase.WithVisitorSalt([]byte("salt")), usecase.WithVisitorSketches(f.sketches))
+	link, _ := links.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.com"})
+
+	// One visitor refreshing three times, another on a different browser and
+	// a third from a different address.
+	for _, req := range []usecase.VisitRequest{
+		{IP: "198.51.100.1", UserAgent: iPhoneSafariUA, Accept: htmlAccept},
+		{IP: "198.51.100.1", UserAgent: iPhoneSafariUA, Accept: htmlAccept},
+		{IP: "198.51.100.1", UserAgent: iPhoneSafariUA, Accept: htmlAccept},
+		{IP: "198.51.100.1", UserAgent: macFirefoxUA, Accept: htmlAccept},
+		{IP: "198.51.100.2", UserAgent: iPhoneSafariUA, Accept: htmlAccept},
+		{UserAgent: iPhoneSafariUA, Accept: htmlAccept}, // no address, so it cannot be told apart and is not counted
+	} {
+		_, err := links.VisitLink(ctx, link.ID, req)
+		assert.NoError(t, err)
+	}
+
+	stats, err := f.uc.LinkVisitors(ctx, testOwnerID, link.ID, usecase.StatsQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), stats.Clicks)
+	assert.Equal(t, int64(3), stats.Visitors)
+	today := stats.Days[len(stats.Days)-1]
+	assert.Equal(t, time.Now().UTC().Format(time.DateOnly), today.Day)
+	assert.Equal(t, int64(6), today.Clicks)
+	assert.Equal(t, int64(3), today.Visitors)
+	assert.Zero(t, stats.Days[0].Visitors)
+
+	_, err = f.uc.LinkVisitors(ctx, testOwnerID, link.ID, usecase.StatsQuery{Timezone: "Asia/Tokyo"})
+	assert.ErrorIs(t, err, usecase.ErrInvalidQuery)
+
+	// Purging the link drops its sketches along with its visits.
+	assert.NoError(t, links.DeleteLink(ctx, testOwnerID, link.ID))
+	purger := usecase.NewLinkUsecase(f.links, f.visits, usecase.WithVisitorSketches(f.sketches), usecase.WithTrashRetention(time.Nanosecond))
+	time.Sleep(time.Millisecond)
+	assert.NoError(t, purger.PurgeDeletedLinks(ctx))
+	assert.Empty(t, f.sketches.sketches)
+}
+
+func TestLinkVisitorsEstimatesLargeAudiences(t *testing.T) {
+	f := newStatsFixture()
+	ctx := context.Background()
+	links := usecase.NewLinkUsecase(f.links, f.visits, usecase.WithVisitorSketches(f.sketches))
+	link, _ := links.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.com"})
+
+	const audience = 5000
+	for i := 0; i < audience; i++ {
+		ip := fmt.Sprintf("10.%d.%d.%d", i/65536, i/256%256, i%256)
+		_, _ = links.VisitLink(ctx, link.ID, usecase.VisitRequest{IP: ip, UserAgent: windowsEdgeUA, Accept: htmlAccept})
+		_, _ = links.VisitLink(ctx, link.ID, usecase.VisitRequest{IP: ip, UserAgent: windowsEdgeUA, Accept: htmlAccept})
+	}
+
+	stats, err := f.uc.LinkVisitors(ctx, testOwnerID, link.ID, usecase.StatsQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2*audience), stats.Clicks)
+	assert.InEpsilon(t, audience, stats.Visitors, 0.05)
+	// Sketches stay small however many visitors there are.
+	for _, sketch := range f.sketches.sketches {
+		assert.LessOrEqual(t, len(sketch.Registers), 4096)
+	}
+}
@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	httphandler "github.com/hussainr95/link-in-bio-service/internal/delivery/http"
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeGeoIP resolves countries from a fixed table.
+type fakeGeoIP map[string]string
+
+func (g fakeGeoIP) Country(ip net.IP) string {
+	return g[ip.String()]
+}
+
+const (
+	iPhoneSafariUA = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1"
+	instagramUA    = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/123.0.0.0 Mobile Safari/537.36 Instagram 325.0.0.35.91 Android"
+	windowsEdgeUA  = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.0.0"
+	iPadChromeUA   = "Mozilla/5.0 (iPad; CPU OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/124.0.6367.88 Mobile/15E148 Safari/604.1"
+	macFirefoxUA   = "Mozilla/5.0 (Macintosh; Intel Mac OS X 14.4; rv:125.0) Gecko/20100101 Firefox/125.0"
+
+	htmlAccept = "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"
+)
+
+// browserVisit looks like a person following the link in a browser, so it
+// counts as a click.
+var browserVisit = usecase.VisitRequest{UserAgent: iPhoneSafariUA, Accept: htmlAccept, AcceptLanguage: "en-US"}
+
+// setBrowserHeaders makes req look like it was sent by a browser.
+func setBrowserHeaders(req *http.Request) {
+	req.Header.Set("User-Agent", browserVisit.UserAgent)
+	req.Header.Set("Accept", browserVisit.Accept)
+	req.Header.Set("Accept-Language", browserVisit.AcceptLanguage)
+}
+
+func TestVisitLinkCapturesClientDetails(t *testing.T) {
+	ctx := context.Background()
+	visitRepo := newMockVisitRepository()
+	uc := usecase.NewLinkUsecase(newMockLinkRepository(), visitRepo,
+		usecase.WithGeoIP(fakeGeoIP{"81.2.69.160": "gb"}),
+		usecase.WithVisitorSalt([]byte("pepper")))
+
+	link, _ := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.com"})
+	_, err := uc.VisitLink(ctx, link.ID, usecase.VisitRequest{
+		IP:             "81.2.69.160",
+		UserAgent:      iPhoneSafariUA,
+		Referrer:       "https://www.Instagram.com/some.creator/?igsh=abc123#top",
+		AcceptLanguage: "en-GB,en;q=0.9",
+	})
+	assert.NoError(t, err)
+	_, _ = uc.VisitLink(ctx, link.ID, usecase.VisitRequest{IP: "81.2.69.160", UserAgent: iPhoneSafariUA, Accept: htmlAccept})
+	_, _ = uc.VisitLink(ctx, link.ID, usecase.VisitRequest{IP: "2001:db8::1"})
+
+	if assert.Len(t, visitRepo.visits, 3) {
+		visit := visitRepo.visits[0]
+		assert.Equal(t, "https://www.Instagram.com/some.creator/", visit.Referrer)
+		assert.Equal(t, "instagram.com", visit.ReferrerHost)
+		assert.Equal(t, "Safari", visit.Browser)
+		assert.Equal(t, "iOS", visit.OS)
+		assert.Equal(t, usecase.DeviceMobile, visit.Device)
+		assert.Equal(t, "en-GB", visit.Language)
+		assert.Equal(t, "GB", visit.Country)
+		assert.Len(t, visit.VisitorHash, 32)
+		assert.NotContains(t, visit.VisitorHash, "81.2.69.160")
+
+		assert.Equal(t, visit.VisitorHash, visitRepo.visits[1].VisitorHash)
+		assert.NotEqual(t, visit.VisitorHash, visitRepo.visits[2].VisitorHash)
+		assert.Empty(t, visitRepo.visits[2].Country)
+		assert.Equal(t, usecase.DeviceUnknown, visitRepo.visits[2].Device)
+	}
+}
+
+func TestVisitLinkClassifiesUserAgents(t *testing.T) {
+	cases := []struct {
+		ua, browser, os, device string
+	}{
+		{instagramUA, "Instagram", "Android", usecase.DeviceMobile},
+		{windowsEdgeUA, "Edge", "Windows", usecase.DeviceDesktop},
+		{iPadChromeUA, "Chrome", "iOS", usecase.DeviceTablet},
+		{macFirefoxUA, "Firefox", "macOS", usecase.DeviceDesktop},
+		{"curl/8.4.0", "Other", "Other", usecase.DeviceUnknown},
+	}
+	ctx := context.Background()
+	for _, tc := range cases {
+		visitRepo := newMockVisitRepository()
+		uc := usecase.NewLinkUsecase(newMockLinkRepository(), visitRepo)
+		link, _ := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.com"})
+		_, _ = uc.VisitLink(ctx, link.ID, usecase.VisitRequest{UserAgent: tc.ua})
+
+		if assert.Len(t, visitRepo.visits, 1) {
+			visit := visitRepo.visits[0]
+			assert.Equal(t, tc.browser, visit.Browser, tc.ua)
+			assert.Equal(t, tc.os, visit.OS, tc.ua)
+			assert.Equal(t, tc.device, visit.Device, tc.ua)
+		}
+	}
+}
+
+func TestRedirectEndpointRecordsRequestDetails(t *testing.T) {
+	linkRepo := newMockLinkRepository()
+	visitRepo := newMockVisitRepository()
+	uc := usecase.NewLinkUsecase(linkRepo, visitRepo, usecase.WithGeoIP(fakeGeoIP{"192.0.2.10": "DE"}))
+	router := gin.New()
+	httphandler.NewRedirectHandler(uc, http.StatusFound).RegisterPublicRoutes(router)
+
+	link, _ := uc.CreateLink(context.Background(), &entity.Link{OwnerID: testOwnerID, URL: "http://example.com"})
+	req, _ := http.NewRequest("GET", "/r/"+link.Slug, nil)
+	req.RemoteAddr = "192.0.2.10:52100"
+	req.Header.Set("User-Agent", windowsEdgeUA)
+	req.Header.Set("Referer", "https://t.co/abc")
+	req.Header.Set("Accept-Language", "de-DE,de;q=0.8")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	if assert.Len(t, visitRepo.visits, 1) {
+		visit := visitRepo.visits[0]
+		assert.Equal(t, "DE", visit.Country)
+		assert.Equal(t, "t.co", visit.ReferrerHost)
+		assert.Equal(t, "de-DE", visit.Language)
+		assert.Equal(t, "Edge", visit.Browser)
+	}
+}
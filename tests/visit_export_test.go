@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	httphandler "github.com/hussainr95/link-in-bio-service/internal/delivery/http"
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupExportRouter(f *statsFixture) *gin.Engine {
+	router := gin.New()
+	api := router.Group("/", func(c *gin.Context) {
+		c.Set("principal", &entity.APIKey{UserID: testOwnerID})
+	})
+	httphandler.NewExportHandler(usecase.NewExportUsecase(f.links, f.visits, f.profiles)).RegisterAPIRoutes(api)
+	return router
+}
+
+func TestExportLinkVisitsCSV(t *testing.T) {
+	f := newStatsFixture()
+	link := f.link(testOwnerID, "launch")
+	other := f.link(testOwnerID, "other")
+	at := func(value string) time.Time {
+		t, _ := time.Parse(time.RFC3339, value)
+		return t
+	}
+	f.visits.visits = append(f.visits.visits,
+		&entity.Visit{ID: "v2", LinkID: link.ID, VisitedAt: at("2024-03-02T09:30:00Z"), Browser: "Slack", BotName: "Slack", Bot: true},
+		&entity.Visit{ID: "v1", LinkID: link.ID, VisitedAt: at("2024-03-01T23:30:00Z"), ReferrerHost: "t.co", Browser: "Chrome", Language: "=HYPERLINK(\"x\")", Country: "DE"},
+		&entity.Visit{ID: "v3", LinkID: link.ID, VisitedAt: at("2024-03-05T10:00:00Z")},
+		&entity.Visit{ID: "x1", LinkID: other.ID, VisitedAt: at("2024-03-01T12:00:00Z")},
+	)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/links/launch/visits/export?from=2024-03-01&to=2024-03-02&tz=Europe/Berlin", nil)
+	setupExportRouter(f).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), `filename="launch-visits-2024-03-01-2024-03-03.csv"`)
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	assert.NoError(t, err)
+	if assert.Len(t, records, 3) {
+		assert.Equal(t, []string{"id", "linkId", "visitedAt", "referrer", "referrerHost", "browser", "os", "device",
+			"language", "country", "visitorHash", "bot", "botName", "repeat"}, records[0])
+		assert.Equal(t, "v1", records[1][0])
+		assert.Equal(t, "2024-03-02T00:30:00+01:00", records[1][2])
+		assert.Equal(t, "t.co", records[1][4])
+		assert.Equal(t, `'=HYPERLINK("x")`, records[1][8])
+		assert.Equal(t, "false", records[1][11])
+		assert.Equal(t, "v2", records[2][0])
+		assert.Equal(t, "true", records[2][11])
+		assert.Equal(t, "Slack", records[2][12])
+	}
+}
+
+func TestExportProfileVisitsJSONLines(t *testing.T) {
+	ctx := context.Background()
+	f := newStatsFixture()
+	first := f.link(testOwnerID, "first")
+	second := f.link(testOwnerID, "second")
+	hidden := f.link(testOwnerID, "hidden")
+	_, _ = f.profiles.Create(ctx, &entity.Profile{UserID: testOwnerID, Handle: "creator", LinkIDs: []string{first.ID, second.ID}})
+	now := time.Now().UTC()
+	f.visitAt(second, now.Add(-2*time.Hour).Format(time.RFC3339))
+	f.visitAt(first, now.Add(-time.Hour).Format(time.RFC3339))
+	f.visitAt(hidden, now.Add(-time.Hour).Format(time.RFC3339))
+	f.visitAt(first, now.AddDate(0, 0, -40).Format(time.RFC3339))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/profile/visits/export?format=jsonl", nil)
+	setupExportRouter(f).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	var linkIDs []string
+	scanner := bufio.NewScanner(strings.NewReader(w.Body.String()))
+	for scanner.Scan() {
+		var visit entity.Visit
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &visit))
+		linkIDs = append(linkIDs, visit.LinkID)
+	}
+	assert.Equal(t, []string{second.ID, first.ID}, linkIDs)
+}
+
+func TestExportValidation(t *testing.T) {
+	f := newStatsFixture()
+	f.link(testOwnerID, "launch")
+	f.link("someone-else", "theirs")
+	router := setupExportRouter(f)
+
+	cases := map[string]int{
+		"/links/launch/visits/export?format=xlsx":      http.StatusBadRequest,
+		"/links/launch/visits/export?from=yesterday":   http.StatusBadRequest,
+		"/links/theirs/visits/export":                  http.StatusNotFound,
+		"/profile/visits/export":                       http.StatusNotFound,
+		"/links/launch/visits/export?format=jsonl&tz=": http.StatusOK,
+	}
+	for path, status := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(t, status, w.Code, path)
+	}
+}
@@ -0,0 +1,318 @@
+package tests
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockVisitRollupRepository struct {
+	rollups   []*entity.VisitRollup
+	watermark time.Time
+}
+
+func newMockVisitRollupRepository() *mockVisitRollupRepository {
+	return &mockVisitRollupRepository{rollups: make([]*entity.VisitRollup, 0)}
+}
+
+func (r *mockVisitRollupRepository) Save(ctx context.Context, rollups []*entity.VisitRollup) error {
+	for _, rollup := range rollups {
+		replaced := false
+		for i, existing := range r.rollups {
+			if existing.LinkID == rollup.LinkID && existing.Granularity == rollup.Granularity && existing.Start.Equal(rollup.Start) {
+				r.rollups[i], replaced = rollup, true
+			}
+		}
+		if !replaced {
+			r.rollups = append(r.rollups, rollup)
+		}
+	}
+	return nil
+}
+
+func (r *mockVisitRollupRepository) CountByInterval(ctx context.Context, q repository.RollupQuery, interval entity.StatsInterval, loc *time.Location) ([]*entity.StatsBucket, error) {
+	counts := map[time.Time]int64{}
+	for _, rollup := range r.matching(q) {
+		if rollup.Clicks > 0 {
+			counts[repository.BucketStart(rollup.Start, interval, loc)] += rollup.Clicks
+		}
+	}
+	buckets := make([]*entity.StatsBucket, 0, len(counts))
+	for start, clicks := range counts {
+		buckets = append(buckets, &entity.StatsBucket{Start: start, Clicks: clicks})
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Start.Before(buckets[j].Start) })
+	return buckets, nil
+}
+
+func (r *mockVisitRollupRepository) CountByLink(ctx context.Context, q repository.RollupQuery) (map[string]int64, error) {
+	counts := map[string]int64{}
+	for _, rollup := range r.matching(q) {
+		if rollup.Clicks > 0 {
+			counts[rollup.LinkID] += rollup.Clicks
+		}
+	}
+	return counts, nil
+}
+
+func (r *mockVisitRollupRepository) Count(ctx context.Context, q repository.RollupQuery) (int64, int64, error) {
+	var clicks, bots int64
+	for _, rollup := range r.matching(q) {
+		clicks += rollup.Clicks
+		bots += rollup.BotHits
+	}
+	return clicks, bots, nil
+}
+
+func (r *mockVisitRollupRepository) TopValues(ctx context.Context, q repository.RollupQuery, dimension entity.BreakdownDimension, limit int) ([]*entity.BreakdownItem, error) {
+	counts := map[string]int64{}
+	for _, rollup := range r.matching(q) {
+		for _, value := range rollup.Values {
+			if value.Dimension == dimension {
+				counts[value.Value] += value.Clicks
+			}
+		}
+	}
+	items := make([]*entity.BreakdownItem, 0, len(counts))
+	for value, clicks := range counts {
+		items = append(items, &entity.BreakdownItem{Value: value, Clicks: clicks})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Clicks != items[j].Clicks {
+			return items[i].Clicks > items[j].Clicks
+		}
+		return items[i].Value < items[j].Value
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	return items, nil
+}
+
+func (r *mockVisitRollupRepository) DeleteByLinkIDs(ctx context.Context, linkIDs []string) (int64, error) {
+	kept := r.rollups[:0]
+	for _, rollup := range r.rollups {
+		if !slices.Contains(linkIDs, rollup.LinkID) {
+			kept = append(kept, rollup)
+		}
+	}
+	deleted := int64(len(r.rollups) - len(kept))
+	r.rollups = kept
+	return deleted, nil
+}
+
+func (r *mockVisitRollupRepository) Watermark(ctx context.Context) (time.Time, error) {
+	return r.watermark, nil
+}
+
+func (r *mockVisitRollupRepository) SetWatermark(ctx context.Context, t time.Time) error {
+	r.watermark = t
+	return nil
+}
+
+func (r *mockVisitRollupRepository) matching(q repository.RollupQuery) []*entity.VisitRollup {
+	var matched []*entity.VisitRollup
+	for _, rollup := range r.rollups {
+		if q.Matches(rollup) {
+			matched = append(matched, rollup)
+		}
+	}
+	return matched
+}
+
+func (r *mockVisitRollupRepository) count(granularity entity.StatsInterval) int {
+	n := 0
+	for _, rollup := range r.rollups {
+		if rollup.Granularity == granularity {
+			n++
+		}
+	}
+	return n
+}
+
+// rollupFixture records visits over the last few weeks, including a bot
+// hit and a repeat click on the oldest day.
+func rollupFixture(t *testing.T, retention time.Duration) (*statsFixture, *entity.Link, *entity.Link, time.Time) {
+	f := newStatsFixture()
+	f.uc = usecase.NewStatsUsecase(f.links, f.visits, f.profiles, f.sketches, f.anomalies, f.rollups, usecase.WithVisitRetention(retention))
+	launch := f.link(testOwnerID, "launch")
+	shop := f.link(testOwnerID, "shop")
+	_, err := f.profiles.Create(context.Background(), &entity.Profile{UserID: testOwnerID, Handle: "jane", LinkIDs: []string{launch.ID, shop.ID}})
+	require.NoError(t, err)
+
+	start := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -20)
+	for day := 0; day < 20; day++ {
+		for hour := 0; hour < 24; hour += 5 {
+			at := start.AddDate(0, 0, day).Add(time.Duration(hour)*time.Hour + 17*time.Minute)
+			f.visits.visits = append(f.visits.visits,
+				&entity.Visit{LinkID: launch.ID, VisitedAt: at, Country: "DE", Browser: "Safari"},
+				&entity.Visit{LinkID: shop.ID, VisitedAt: at.Add(30 * time.Minute), Country: "FR", Browser: "Chrome"})
+			if day%3 == 0 {
+				f.visits.visits = append(f.visits.visits, &entity.Visit{LinkID: launch.ID, VisitedAt: at.Add(time.Minute), Country: "US"})
+			}
+		}
+	}
+	f.visits.visits = append(f.visits.visits,
+		&entity.Visit{LinkID: launch.ID, VisitedAt: start.Add(time.Hour), Bot: true},
+		&entity.Visit{LinkID: launch.ID, VisitedAt: start.Add(2 * time.Hour), Repeat: true})
+	return f, launch, shop, start
+}
+
+func TestRollupVisitsBuildsHourlyAndDailyRollups(t *testing.T) {
+	ctx := context.Background()
+	f, launch, _, start := rollupFixture(t, 0)
+
+	require.NoError(t, f.uc.RollupVisits(ctx))
+	// A run rolls up at most a week, so older history takes a few runs.
+	assert.Equal(t, start.AddDate(0, 0, 7), f.rollups.watermark)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, f.uc.RollupVisits(ctx))
+	}
+	assert.Equal(t, time.Now().Add(-5*time.Minute).Truncate(time.Hour), f.rollups.watermark)
+	assert.Equal(t, 2*20, f.rollups.count(entity.IntervalDay))
+
+	var first *entity.VisitRollup
+	for _, rollup := range f.rollups.rollups {
+		if rollup.LinkID == launch.ID && rollup.Granularity == entity.IntervalDay && rollup.Start.Equal(start) {
+			first = rollup
+		}
+	}
+	require.NotNil(t, first)
+	assert.Equal(t, int64(10), first.Clicks)
+	assert.Equal(t, int64(1), first.BotHits)
+	assert.Contains(t, first.Values, &entity.RollupValue{Dimension: entity.DimensionCountry, Value: "US", Clicks: 5})
+
+	// Nothing is deleted without a retention.
+	visits := len(f.visits.visits)
+	require.NoError(t, f.uc.RollupVisits(ctx))
+	assert.Len(t, f.visits.visits, visits)
+}
+
+func TestStatsReadRollupsOnceRawVisitsExpire(t *testing.T) {
+	ctx := context.Background()
+	f, launch, _, start := rollupFixture(t, 7*24*time.Hour)
+
+	from := start.AddDate(0, 0, 1).Format(time.DateOnly)
+	to := start.AddDate(0, 0, 18).Format(time.DateOnly)
+	queries := []usecase.StatsQuery{
+		{From: from, To: to},
+		{From: from, To: to, Interval: "week"},
+		{From: from, To: to, Timezone: "America/New_York"},
+		{From: start.Add(90 * time.Minute).Format(time.RFC3339), To: start.AddDate(0, 0, 2).Format(time.RFC3339), Interval: "hour"},
+	}
+	type snapshot struct {
+		link      []*entity.LinkStats
+		profile   []*entity.ProfileStats
+		breakdown *entity.Breakdown
+	}
+	take := func() snapshot {
+		var s snapshot
+		for _, q := range queries {
+			link, err := f.uc.LinkStats(ctx, testOwnerID, launch.ID, q)
+			require.NoError(t, err)
+			profile, err := f.uc.ProfileStats(ctx, testOwnerID, q)
+			require.NoError(t, err)
+			s.link, s.profile = append(s.link, link), append(s.profile, profile)
+		}
+		var err error
+		s.breakdown, err = f.uc.LinkBreakdown(ctx, testOwnerID, launch.ID, usecase.BreakdownQuery{
+			StatsQuery: usecase.StatsQuery{From: start.Format(time.DateOnly), To: to}, Dimension: "country"})
+		require.NoError(t, err)
+		return s
+	}
+
+	before := take()
+	assert.Equal(t, int64(1), before.link[3].BotHits)
+	for i := 0; i < 4; i++ {
+		require.NoError(t, f.uc.RollupVisits(ctx))
+	}
+	for _, visit := range f.visits.visits {
+		assert.False(t, visit.VisitedAt.Before(time.Now().Add(-7*24*time.Hour)), "visits past the retention are deleted")
+	}
+	after := take()
+
+	for i := range queries {
+		assert.Equal(t, before.link[i].Total, after.link[i].Total, "query %d", i)
+		assert.Equal(t, bucketClicks(before.link[i].Buckets), bucketClicks(after.link[i].Buckets), "query %d", i)
+		assert.Equal(t, before.link[i].BotHits, after.link[i].BotHits, "query %d", i)
+		assert.Equal(t, bucketClicks(before.profile[i].Buckets), bucketClicks(after.profile[i].Buckets), "query %d", i)
+		assert.Equal(t, before.profile[i].Links, after.profile[i].Links, "query %d", i)
+	}
+	assert.Equal(t, before.breakdown.Total, after.breakdown.Total)
+	assert.Equal(t, before.breakdown.Items, after.breakdown.Items)
+}
+
+func TestRetentionKeepsVisitsNotYetRolledUp(t *testing.T) {
+	ctx := context.Background()
+	f, _, _, start := rollupFixture(t, time.Hour)
+
+	require.NoError(t, f.uc.RollupVisits(ctx))
+	oldest, err := f.visits.Oldest(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, start.AddDate(0, 0, 7), oldest.Truncate(24*time.Hour))
+
+	// Retentions under a week are raised to a week.
+	for i := 0; i < 3; i++ {
+		require.NoError(t, f.uc.RollupVisits(ctx))
+	}
+	oldest, err = f.visits.Oldest(ctx)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(-7*24*time.Hour), oldest, 5*time.Hour)
+}
+
+// slowClickLinkRepository takes its time counting clicks, like a busy
+// database, and records when it finished.
+type slowClickLinkRepository struct {
+	*mockLinkRepository
+	counted time.Time
+}
+
+func (r *slowClickLinkRepository) IncrementClicks(ctx context.Context, id string) error {
+	time.Sleep(20 * time.Millisecond)
+	r.counted = time.Now()
+	return r.mockLinkRepository.IncrementClicks(ctx, id)
+}
+
+// A slow request must not record its visit at a time the rollup job may
+// already have passed.
+func TestVisitLinkStampsVisitsAsTheyAreStored(t *testing.T) {
+	ctx := context.Background()
+	linkRepo := &slowClickLinkRepository{mockLinkRepository: newMockLinkRepository()}
+	visitRepo := newMockVisitRepository()
+	uc := usecase.NewLinkUsecase(linkRepo, visitRepo)
+
+	link, err := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, Slug: "launch", URL: "http://example.com"})
+	require.NoError(t, err)
+	_, err = uc.VisitLink(ctx, link.ID, browserVisit)
+	require.NoError(t, err)
+	require.Len(t, visitRepo.visits, 1)
+	assert.False(t, visitRepo.visits[0].VisitedAt.Before(linkRepo.counted))
+}
+
+func TestPurgeDeletesVisitRollups(t *testing.T) {
+	ctx := context.Background()
+	rollups := newMockVisitRollupRepository()
+	rollups.rollups = append(rollups.rollups,
+		&entity.VisitRollup{LinkID: "1", Granularity: entity.IntervalDay},
+		&entity.VisitRollup{LinkID: "2", Granularity: entity.IntervalDay})
+	links := newMockLinkRepository()
+	uc := usecase.NewLinkUsecase(links, newMockVisitRepository(),
+		usecase.WithTrashRetention(time.Hour), usecase.WithVisitRollups(rollups))
+	link, _ := uc.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, URL: "http://example.com"})
+	require.NoError(t, uc.DeleteLink(ctx, testOwnerID, link.ID))
+	deletedAt := time.Now().Add(-2 * time.Hour)
+	link.DeletedAt = &deletedAt
+	saveLink(t, links, link)
+
+	require.NoError(t, uc.PurgeDeletedLinks(ctx))
+	require.Len(t, rollups.rollups, 1)
+	assert.Equal(t, "2", rollups.rollups[0].LinkID)
+}
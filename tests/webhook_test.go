@@ -0,0 +1,441 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	httphandler "github.com/hussainr95/link-in-bio-service/internal/delivery/http"
+	"github.com/hussainr95/link-in-bio-service/internal/entity"
+	"github.com/hussainr95/link-in-bio-service/internal/repository"
+	"github.com/hussainr95/link-in-bio-service/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockWebhookRepository struct {
+	webhooks []*entity.Webhook
+	nextID   int
+}
+
+func newMockWebhookRepository() *mockWebhookRepository {
+	return &mockWebhookRepository{webhooks: make([]*entity.Webhook, 0), nextID: 1}
+}
+
+func (r *mockWebhookRepository) Create(ctx context.Context, webhook *entity.Webhook) (*entity.Webhook, error) {
+	webhook.ID = fmt.Sprintf("webhook-%d", r.nextID)
+	r.nextID++
+	r.webhooks = append(r.webhooks, webhook)
+	stored := *webhook
+	return &stored, nil
+}
+
+func (r *mockWebhookRepository) GetByID(ctx context.Context, id string) (*entity.Webhook, error) {
+	for _, webhook := range r.webhooks {
+		if webhook.ID == id {
+			stored := *webhook
+			return &stored, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *mockWebhookRepository) ListByOwner(ctx context.Context, ownerID string) ([]*entity.Webhook, error) {
+	webhooks := []*entity.Webhook{}
+	for i := len(r.webhooks) - 1; i >= 0; i-- {
+		if r.webhooks[i].OwnerID == ownerID {
+			stored := *r.webhooks[i]
+			webhooks = append(webhooks, &stored)
+		}
+	}
+	return webhooks, nil
+}
+
+func (r *mockWebhookRepository) ListSubscribed(ctx context.Context, ownerID string, event entity.WebhookEvent) ([]*entity.Webhook, error) {
+	webhooks := []*entity.Webhook{}
+	for _, webhook := range r.webhooks {
+		if webhook.OwnerID == ownerID && slices.Contains(webhook.Events, event) {
+			stored := *webhook
+			webhooks = append(webhooks, &stored)
+		}
+	}
+	return webhooks, nil
+}
+
+func (r *mockWebhookRepository) Delete(ctx context.Context, id string) error {
+	for i, webhook := range r.webhooks {
+		if webhook.ID == id {
+			r.webhooks = slices.Delete(r.webhooks, i, i+1)
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+type mockWebhookDeliveryRepository struct {
+	deliveries []*entity.WebhookDelivery
+}
+
+func newMockWebhookDeliveryRepository() *mockWebhookDeliveryRepository {
+	return &mockWebhookDeliveryRepository{deliveries: make([]*entity.WebhookDelivery, 0)}
+}
+
+func (r *mockWebhookDeliveryRepository) Create(ctx context.Context, delivery *entity.WebhookDelivery) (*entity.WebhookDelivery, error) {
+	delivery.ID = fmt.Sprintf("delivery-%d", len(r.deliveries)+1)
+	r.deliveries = append(r.deliveries, delivery)
+	return delivery, nil
+}
+
+func (r *mockWebhookDeliveryRepository) GetByID(ctx context.Context, id string) (*entity.WebhookDelivery, error) {
+	for _, delivery := range r.deliveries {
+		if delivery.ID == id {
+			return delivery, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *mockWebhookDeliveryRepository) ListByWebhook(ctx context.Context, webhookID string, limit int) ([]*entity.WebhookDelivery, error) {
+	deliveries := []*entity.WebhookDelivery{}
+	for i := len(r.deliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		if r.deliveries[i].WebhookID == webhookID {
+			deliveries = append(deliveries, r.deliveries[i])
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *mockWebhookDeliveryRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration) (*entity.WebhookDelivery, error) {
+	var due *entity.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.Status != entity.DeliveryPending || delivery.NextAttemptAt == nil || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		if due == nil || delivery.NextAttemptAt.Before(*due.NextAttemptAt) {
+			due = delivery
+		}
+	}
+	if due == nil {
+		return nil, repository.ErrNotFound
+	}
+	leased := now.Add(lease)
+	due.NextAttemptAt = &leased
+	return due, nil
+}
+
+func (r *mockWebhookDeliveryRepository) Update(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	return nil
+}
+
+func (r *mockWebhookDeliveryRepository) DeleteByWebhookID(ctx context.Context, webhookID string) (int64, error) {
+	kept := r.deliveries[:0]
+	for _, delivery := range r.deliveries {
+		if delivery.WebhookID != webhookID {
+			kept = append(kept, delivery)
+		}
+	}
+	deleted := int64(len(r.deliveries) - len(kept))
+	r.deliveries = kept
+	return deleted, nil
+}
+
+// makeDue moves every pending delivery's next attempt into the past.
+func (r *mockWebhookDeliveryRepository) makeDue() {
+	past := time.Now().Add(-time.Second)
+	for _, delivery := range r.deliveries {
+		if delivery.Status == entity.DeliveryPending {
+			delivery.NextAttemptAt = &past
+		}
+	}
+}
+
+// webhookReceiver records the requests sent to it and answers with status.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []receivedWebhook
+}
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, receivedWebhook{header: req.Header, body: body})
+	w.WriteHeader(r.status)
+}
+
+func (r *webhookReceiver) received() []receivedWebhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.requests)
+}
+
+type webhookFixture struct {
+	webhooks   *mockWebhookRepository
+	deliveries *mockWebhookDeliveryRepository
+	receiver   *webhookReceiver
+	server     *httptest.Server
+	uc         usecase.WebhookUsecase
+}
+
+func newWebhookFixture(t *testing.T) *webhookFixture {
+	f := &webhookFixture{
+		webhooks:   newMockWebhookRepository(),
+		deliveries: newMockWebhookDeliveryRepository(),
+		receiver:   &webhookReceiver{status: http.StatusOK},
+	}
+	f.server = httptest.NewServer(f.receiver)
+	t.Cleanup(f.server.Close)
+	f.uc = usecase.NewWebhookUsecase(f.webhooks, f.deliveries, usecase.WithWebhookClient(f.server.Client()))
+	return f
+}
+
+func TestCreateWebhookValidatesInput(t *testing.T) {
+	ctx := context.Background()
+	f := newWebhookFixture(t)
+
+	for _, webhook := range []*entity.Webhook{
+		{OwnerID: testOwnerID, URL: "ftp://example.com/hook"},
+		{OwnerID: testOwnerID, URL: "/hook"},
+		{OwnerID: testOwnerID, URL: "https://example.com/hook", Events: []entity.WebhookEvent{"link.renamed"}},
+	} {
+		_, err := f.uc.CreateWebhook(ctx, webhook)
+		assert.ErrorIs(t, err, usecase.ErrInvalidWebhook, webhook.URL)
+	}
+
+	created, err := f.uc.CreateWebhook(ctx, &entity.Webhook{OwnerID: testOwnerID, URL: "https://example.com/hook"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.Secret, "whsec_"))
+	assert.Equal(t, entity.WebhookEvents, created.Events)
+
+	listed, err := f.uc.ListWebhooks(ctx, testOwnerID)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Empty(t, listed[0].Secret)
+
+	for i := 1; i < 10; i++ {
+		_, err := f.uc.CreateWebhook(ctx, &entity.Webhook{OwnerID: testOwnerID, URL: "https://example.com/hook"})
+		require.NoError(t, err)
+	}
+	_, err = f.uc.CreateWebhook(ctx, &entity.Webhook{OwnerID: testOwnerID, URL: "https://example.com/hook"})
+	assert.ErrorIs(t, err, usecase.ErrInvalidWebhook)
+}
+
+func TestWebhookDeliveriesAreSigned(t *testing.T) {
+	ctx := context.Background()
+	f := newWebhookFixture(t)
+	webhook, _ := f.uc.CreateWebhook(ctx, &entity.Webhook{OwnerID: testOwnerID, URL: f.server.URL,
+		Events: []entity.WebhookEvent{entity.EventLinkCreated}})
+	_, _ = f.uc.CreateWebhook(ctx, &entity.Webhook{OwnerID: "someone-else", URL: f.server.URL})
+
+	link := &entity.Link{ID: "1", OwnerID: testOwnerID, Slug: "launch"}
+	require.NoError(t, f.uc.Emit(ctx, testOwnerID, entity.EventLinkCreated, entity.WebhookData{Link: link}))
+	require.NoError(t, f.uc.Emit(ctx, testOwnerID, entity.EventLinkDeleted, entity.WebhookData{Link: link}))
+	require.NoError(t, f.uc.DeliverDue(ctx))
+
+	received := f.receiver.received()
+	require.Len(t, received, 1)
+	header := received[0].header
+	assert.Equal(t, string(entity.EventLinkCreated), header.Get("X-Webhook-Event"))
+	assert.Equal(t, "delivery-1", header.Get("X-Webhook-Id"))
+	timestamp, err := strconv.ParseInt(header.Get("X-Webhook-Timestamp"), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, usecase.SignWebhook(webhook.Secret, timestamp, received[0].body), header.Get("X-Webhook-Signature"))
+	assert.NotEqual(t, usecase.SignWebhook("whsec_wrong", timestamp, received[0].body), header.Get("X-Webhook-Signature"))
+
+	var payload entity.WebhookPayload
+	require.NoError(t, json.Unmarshal(received[0].body, &payload))
+	assert.True(t, strings.HasPrefix(payload.ID, "evt_"))
+	assert.Equal(t, entity.EventLinkCreated, payload.Event)
+	assert.Equal(t, "launch", payload.Data.Link.Slug)
+
+	delivery := f.deliveries.deliveries[0]
+	assert.Equal(t, entity.DeliverySucceeded, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusOK, delivery.ResponseStatus)
+	assert.Nil(t, delivery.NextAttemptAt)
+}
+
+func TestFailingWebhookDeliveriesBackOffThenFail(t *testing.T) {
+	ctx := context.Background()
+	f := newWebhookFixture(t)
+	f.receiver.status = http.StatusServiceUnavailable
+	_, _ = f.uc.CreateWebhook(ctx, &entity.Webhook{OwnerID: testOwnerID, URL: f.server.URL})
+	require.NoError(t, f.uc.Emit(ctx, testOwnerID, entity.EventLinkUpdated, entity.WebhookData{Link: &entity.Link{ID: "1"}}))
+	delivery := f.deliveries.deliveries[0]
+
+	require.NoError(t, f.uc.DeliverDue(ctx))
+	assert.Equal(t, entity.DeliveryPending, delivery.Status)
+	assert.Equal(t, http.StatusServiceUnavailable, delivery.ResponseStatus)
+	assert.Contains(t, delivery.LastError, "503")
+	assert.WithinDuration(t, delivery.LastAttemptAt.Add(time.Minute), *delivery.NextAttemptAt, time.Second)
+
+	// Not due yet, so nothing is sent.
+	require.NoError(t, f.uc.DeliverDue(ctx))
+	assert.Len(t, f.receiver.received(), 1)
+
+	f.deliveries.makeDue()
+	require.NoError(t, f.uc.DeliverDue(ctx))
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.WithinDuration(t, delivery.LastAttemptAt.Add(2*time.Minute), *delivery.NextAttemptAt, time.Second)
+
+	for delivery.Status == entity.DeliveryPending {
+		f.deliveries.makeDue()
+		require.NoError(t, f.uc.DeliverDue(ctx))
+	}
+	assert.Equal(t, entity.DeliveryFailed, delivery.Status)
+	assert.Equal(t, 8, delivery.Attempts)
+	assert.Nil(t, delivery.NextAttemptAt)
+	assert.Len(t, f.receiver.received(), 8)
+}
+
+func TestRedeliverResendsSamePayload(t *testing.T) {
+	ctx := context.Background()
+	f := newWebhookFixture(t)
+	webhook, _ := f.uc.CreateWebhook(ctx, &entity.Webhook{OwnerID: testOwnerID, URL: f.server.URL})
+	require.NoError(t, f.uc.Emit(ctx, testOwnerID, entity.EventLinkCreated, entity.WebhookData{Link: &entity.Link{ID: "1"}}))
+	require.NoError(t, f.uc.DeliverDue(ctx))
+
+	_, err := f.uc.Redeliver(ctx, "someone-else", webhook.ID, "delivery-1")
+	assert.ErrorIs(t, err, usecase.ErrWebhookNotFound)
+	_, err = f.uc.Redeliver(ctx, testOwnerID, webhook.ID, "delivery-9")
+	assert.ErrorIs(t, err, usecase.ErrDeliveryNotFound)
+
+	redelivery, err := f.uc.Redeliver(ctx, testOwnerID, webhook.ID, "delivery-1")
+	require.NoError(t, err)
+	assert.Equal(t, "delivery-1", redelivery.RedeliveryOf)
+	require.NoError(t, f.uc.DeliverDue(ctx))
+
+	received := f.receiver.received()
+	require.Len(t, received, 2)
+	assert.Equal(t, received[0].body, received[1].body)
+	assert.Equal(t, redelivery.ID, received[1].header.Get("X-Webhook-Id"))
+
+	deliveries, err := f.uc.ListDeliveries(ctx, testOwnerID, webhook.ID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, redelivery.ID, deliveries[0].ID)
+
+	require.NoError(t, f.uc.DeleteWebhook(ctx, testOwnerID, webhook.ID))
+	assert.Empty(t, f.deliveries.deliveries)
+	assert.ErrorIs(t, f.uc.DeleteWebhook(ctx, testOwnerID, webhook.ID), usecase.ErrWebhookNotFound)
+}
+
+func TestDefaultWebhookClientRefusesPrivateAddresses(t *testing.T) {
+	ctx := context.Background()
+	f := newWebhookFixture(t)
+	uc := usecase.NewWebhookUsecase(f.webhooks, f.deliveries)
+	_, _ = uc.CreateWebhook(ctx, &entity.Webhook{OwnerID: testOwnerID, URL: f.server.URL})
+	require.NoError(t, uc.Emit(ctx, testOwnerID, entity.EventLinkCreated, entity.WebhookData{Link: &entity.Link{ID: "1"}}))
+	require.NoError(t, uc.DeliverDue(ctx))
+
+	assert.Empty(t, f.receiver.received())
+	assert.Contains(t, f.deliveries.deliveries[0].LastError, "private or loopback")
+}
+
+func TestLinkUsecaseEmitsWebhookEvents(t *testing.T) {
+	ctx := context.Background()
+	f := newWebhookFixture(t)
+	_, _ = f.uc.CreateWebhook(ctx, &entity.Webhook{OwnerID: testOwnerID, URL: f.server.URL})
+	linkRepo := newMockLinkRepository()
+	visitRepo := newMockVisitRepository()
+	links := usecase.NewLinkUsecase(linkRepo, visitRepo,
+		usecase.WithVisitorSalt([]byte("salt")),
+		usecase.WithClickDedup(newMockClickWindowRepository(), 30*time.Second),
+		usecase.WithLinkEvents(f.uc))
+
+	link, err := links.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, Slug: "launch", URL: "http://example.com"})
+	require.NoError(t, err)
+	link.Title = "Launch"
+	_, err = links.UpdateLink(ctx, testOwnerID, link)
+	require.NoError(t, err)
+	visit := browserVisit
+	visit.IP = "198.51.100.7"
+	_, _ = links.VisitLink(ctx, link.ID, visit)
+	// Neither repeats nor bots are reported.
+	_, _ = links.VisitLink(ctx, link.ID, visit)
+	_, _ = links.VisitLink(ctx, link.ID, usecase.VisitRequest{UserAgent: "Googlebot/2.1"})
+
+	past := time.Now().Add(-time.Minute)
+	expiring, _ := links.CreateLink(ctx, &entity.Link{OwnerID: testOwnerID, Slug: "sale", URL: "http://example.com"})
+	expiring.ExpiresAt = &past
+	saveLink(t, linkRepo, expiring)
+	require.NoError(t, links.CleanupExpiredLinks(ctx))
+	require.NoError(t, links.DeleteLink(ctx, testOwnerID, link.ID))
+
+	var events []entity.WebhookEvent
+	for _, delivery := range f.deliveries.deliveries {
+		events = append(events, delivery.Event)
+	}
+	assert.Equal(t, []entity.WebhookEvent{
+		entity.EventLinkCreated, entity.EventLinkUpdated, entity.EventLinkVisited,
+		entity.EventLinkCreated, entity.EventLinkExpired, entity.EventLinkDeleted,
+	}, events)
+
+	var visited entity.WebhookPayload
+	require.NoError(t, json.Unmarshal(f.deliveries.deliveries[2].Payload, &visited))
+	assert.Equal(t, link.ID, visited.Data.Link.ID)
+	require.NotNil(t, visited.Data.Visit)
+	assert.Equal(t, visitRepo.visits[0].ID, visited.Data.Visit.ID)
+	assert.NotEmpty(t, visited.Data.Visit.ID)
+	assert.Equal(t, "Safari", visited.Data.Visit.Browser)
+	// The visitor hash stays inside the service.
+	require.NotEmpty(t, visitRepo.visits[0].VisitorHash)
+	assert.NotContains(t, string(f.deliveries.deliveries[2].Payload), "visitorHash")
+}
+
+func TestWebhookEndpoints(t *testing.T) {
+	f := newWebhookFixture(t)
+	router := gin.New()
+	api := router.Group("/", func(c *gin.Context) {
+		c.Set("principal", &entity.APIKey{UserID: testOwnerID})
+	})
+	httphandler.NewWebhookHandler(f.uc).RegisterAPIRoutes(api)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadRequest, serve("POST", "/webhooks", `{}`).Code)
+	assert.Equal(t, http.StatusBadRequest, serve("POST", "/webhooks", `{"url":"https://example.com","events":["nope"]}`).Code)
+
+	w := serve("POST", "/webhooks", `{"url":"https://example.com/hook","events":["link.created"]}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created entity.Webhook
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.NotEmpty(t, created.Secret)
+
+	w = serve("GET", "/webhooks", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), created.Secret)
+
+	require.NoError(t, f.uc.Emit(context.Background(), testOwnerID, entity.EventLinkCreated, entity.WebhookData{Link: &entity.Link{ID: "1"}}))
+	w = serve("GET", "/webhooks/"+created.ID+"/deliveries", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"pending"`)
+
+	assert.Equal(t, http.StatusAccepted, serve("POST", "/webhooks/"+created.ID+"/deliveries/delivery-1/redeliver", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve("POST", "/webhooks/"+created.ID+"/deliveries/delivery-9/redeliver", "").Code)
+	assert.Equal(t, http.StatusNotFound, serve("GET", "/webhooks/webhook-9/deliveries", "").Code)
+	assert.Equal(t, http.StatusOK, serve("DELETE", "/webhooks/"+created.ID, "").Code)
+	assert.Equal(t, http.StatusNotFound, serve("DELETE", "/webhooks/"+created.ID, "").Code)
+}